@@ -0,0 +1,29 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Спецификация генерируется из proto/auth.proto через `make generate`
+//
+//go:embed openapi.yaml
+var openAPIYAML []byte
+
+// OpenAPIJSON возвращает OpenAPI v3 спецификацию в формате JSON
+func OpenAPIJSON() ([]byte, error) {
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(openAPIYAML, &spec); err != nil {
+		return nil, fmt.Errorf("parse openapi spec: %w", err)
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("encode openapi spec: %w", err)
+	}
+
+	return data, nil
+}
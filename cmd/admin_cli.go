@@ -0,0 +1,301 @@
+package main
+
+import (
+	"auth_test/internal/service"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const userUsage = `usage: user <command> [flags] <username>
+commands:
+  create [-role user|admin] [-password P] <username>
+  list [-role R] [-status S] [-filter F]
+  get <username>
+  disable [-reason R] <username>
+  enable [-reason R] <username>
+  delete [-reason R] <username>
+  reset-password [-password P] <username>
+  set-role -role user|admin <username>
+  revoke-sessions <username>
+  import [-format csv|jsonl] <file|->
+  export [-format csv|jsonl] [file|-]
+Without -password the password is read from stdin.
+Import expects pre-hashed passwords; the format defaults to the file extension.`
+
+const tokenUsage = `usage: token <command>
+commands:
+  mint [-type access|refresh] <username>
+  decode <token>
+  verify <token>`
+
+// runUserCommand управляет пользователями напрямую через хранилище (подкоманда user)
+func runUserCommand(ctx context.Context, adminService service.AdminService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	ctx = service.WithActor(ctx, cliActor())
+
+	command := args[0]
+	fs := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+	role := fs.String("role", "", "user role")
+	password := fs.String("password", "", "password (read from stdin if empty)")
+	reason := fs.String("reason", "", "reason for the status change")
+	status := fs.String("status", "", "status filter")
+	filter := fs.String("filter", "", "username substring filter")
+	format := fs.String("format", "", "import/export format: csv or jsonl")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if command == "list" {
+		return listUsers(ctx, adminService, service.UserFilter{
+			Username: *filter,
+			Role:     *role,
+			Status:   *status,
+			PageSize: service.MaxPageSize,
+		})
+	}
+
+	switch command {
+	case "import":
+		if fs.Arg(0) == "" {
+			return errors.New(userUsage)
+		}
+		return importUsers(ctx, adminService, fs.Arg(0), *format)
+	case "export":
+		return exportUsers(ctx, adminService, fs.Arg(0), *format)
+	}
+
+	username := fs.Arg(0)
+	if username == "" {
+		return errors.New(userUsage)
+	}
+
+	switch command {
+	case "create":
+		pwd, err := passwordOrStdin(*password)
+		if err != nil {
+			return err
+		}
+		if err := adminService.CreateUser(ctx, username, pwd, *role); err != nil {
+			return err
+		}
+		fmt.Printf("User %s created\n", username)
+	case "get":
+		user, err := adminService.GetUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		return printUsers([]service.UserInfo{*user})
+	case "disable":
+		if err := adminService.DisableUser(ctx, username, *reason); err != nil {
+			return err
+		}
+		fmt.Printf("User %s disabled\n", username)
+	case "enable":
+		if err := adminService.EnableUser(ctx, username, *reason); err != nil {
+			return err
+		}
+		fmt.Printf("User %s enabled\n", username)
+	case "delete":
+		if err := adminService.DeleteUser(ctx, username, *reason); err != nil {
+			return err
+		}
+		fmt.Printf("User %s deleted\n", username)
+	case "reset-password":
+		pwd, err := passwordOrStdin(*password)
+		if err != nil {
+			return err
+		}
+		if err := adminService.ResetPassword(ctx, username, pwd); err != nil {
+			return err
+		}
+		fmt.Printf("Password for %s reset, existing sessions revoked\n", username)
+	case "set-role":
+		if err := adminService.SetRole(ctx, username, *role); err != nil {
+			return err
+		}
+		fmt.Printf("User %s now has role %s\n", username, *role)
+	case "revoke-sessions":
+		if err := adminService.RevokeSessions(ctx, username); err != nil {
+			return err
+		}
+		fmt.Printf("Sessions of %s revoked\n", username)
+	default:
+		return errors.New(userUsage)
+	}
+
+	return nil
+}
+
+// runTokenCommand выпускает и проверяет токены для отладки (подкоманда token)
+func runTokenCommand(ctx context.Context, userService service.UserService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(tokenUsage)
+	}
+
+	command := args[0]
+	fs := flag.NewFlagSet("token "+command, flag.ContinueOnError)
+	tokenType := fs.String("type", service.TokenTypeAccess, "token type: access or refresh")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	arg := fs.Arg(0)
+	if arg == "" {
+		return errors.New(tokenUsage)
+	}
+
+	switch command {
+	case "mint":
+		token, err := userService.GenerateToken(ctx, arg, *tokenType)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+	case "decode":
+		// Подпись не проверяется: команда только показывает содержимое
+		token, _, err := jwt.NewParser().ParseUnverified(arg, jwt.MapClaims{})
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"header": token.Header,
+			"claims": token.Claims,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	case "verify":
+		claims, err := userService.ParseAccessToken(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("token is not valid: %w", err)
+		}
+		fmt.Printf("Token is valid: user=%s role=%s\n", claims.Username, claims.Role)
+	default:
+		return errors.New(tokenUsage)
+	}
+
+	return nil
+}
+
+// cliActor - actor журнала аудита для изменений из командной строки: "cli:<пользователь ОС>"
+func cliActor() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return "cli:" + current.Username
+	}
+	return "cli"
+}
+
+// importUsers загружает файл (или stdin для "-") и печатает ошибки строк
+func importUsers(ctx context.Context, adminService service.AdminService, path, format string) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	result, err := adminService.ImportUsers(ctx, in, transferFormat(path, format))
+	if result != nil {
+		for _, rowErr := range result.Errors {
+			fmt.Fprintln(os.Stderr, rowErr.Error())
+		}
+		fmt.Printf("Imported %d of %d users, %d failed\n", result.Imported, result.Total, len(result.Errors))
+	}
+	return err
+}
+
+// exportUsers пишет выгрузку в файл или в stdout, если путь не задан или равен "-"
+func exportUsers(ctx context.Context, adminService service.AdminService, path, format string) error {
+	var out io.Writer = os.Stdout
+	if path != "" && path != "-" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	exported, err := adminService.ExportUsers(ctx, out, transferFormat(path, format))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d users\n", exported)
+	return nil
+}
+
+// transferFormat берёт формат из флага, иначе из расширения файла; по умолчанию csv
+func transferFormat(path, format string) string {
+	if format != "" {
+		return format
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext == service.FormatJSONL || ext == "ndjson" {
+		return service.FormatJSONL
+	}
+	return service.FormatCSV
+}
+
+func listUsers(ctx context.Context, adminService service.AdminService, filter service.UserFilter) error {
+	var all []service.UserInfo
+	for {
+		users, next, err := adminService.ListUsers(ctx, filter)
+		if err != nil {
+			return err
+		}
+		all = append(all, users...)
+		if next == "" {
+			break
+		}
+		filter.PageToken = next
+	}
+	return printUsers(all)
+}
+
+func printUsers(users []service.UserInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tSTATUS\tREASON\tCREATED")
+	for _, user := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			user.ID, user.Username, user.Role, user.Status, user.StatusReason, user.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// passwordOrStdin позволяет не передавать пароль в аргументах, где он попадёт в историю shell
+func passwordOrStdin(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is required")
+	}
+	return password, nil
+}
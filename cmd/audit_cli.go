@@ -0,0 +1,135 @@
+package main
+
+import (
+	"auth_test/configs"
+	"auth_test/internal/auditchain"
+	"auth_test/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const auditUsage = `usage: audit <command> [flags]
+commands:
+  verify
+  export [-after-seq N] [-batch-size N] [-sink DIR|s3://bucket/prefix] [-signing-key FILE]
+  verify-batch -public-key FILE <batch.jsonl>
+verify checks the hash chain of AUDIT_TENANT and exits with an error if it is broken.
+export uploads signed batches of events with seq > -after-seq; pass the printed last seq
+as -after-seq next time. The sink and the key default to AUDIT_EXPORT_SINK and
+AUDIT_EXPORT_SIGNING_KEY_FILE. verify-batch expects <batch>.manifest.json next to the batch.`
+
+// runAuditCommand проверяет и выгружает журнал аудита (подкоманда audit)
+func runAuditCommand(ctx context.Context, cfg *configs.Config, adminService service.AdminService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(auditUsage)
+	}
+
+	ctx = service.WithActor(ctx, cliActor())
+
+	command := args[0]
+	fs := flag.NewFlagSet("audit "+command, flag.ContinueOnError)
+	afterSeq := fs.Int64("after-seq", 0, "export events with seq greater than this")
+	batchSize := fs.Int("batch-size", service.DefaultExportBatchSize, "events per exported batch")
+	sinkTarget := fs.String("sink", cfg.AuditExportSink, "directory or s3://bucket/prefix")
+	signingKey := fs.String("signing-key", cfg.AuditExportSigningKeyFile, "Ed25519 private key (PEM)")
+	publicKey := fs.String("public-key", "", "Ed25519 public key (PEM)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch command {
+	case "verify":
+		return verifyAuditChain(ctx, adminService)
+	case "export":
+		if *sinkTarget == "" || *signingKey == "" {
+			return errors.New("export requires -sink and -signing-key (or AUDIT_EXPORT_SINK and AUDIT_EXPORT_SIGNING_KEY_FILE)")
+		}
+		return exportAuditEvents(ctx, cfg, adminService, *sinkTarget, *signingKey, service.ExportAuditOptions{
+			AfterSeq:  *afterSeq,
+			BatchSize: *batchSize,
+		})
+	case "verify-batch":
+		if *publicKey == "" || fs.Arg(0) == "" {
+			return errors.New(auditUsage)
+		}
+		return verifyAuditBatch(fs.Arg(0), *publicKey)
+	default:
+		return errors.New(auditUsage)
+	}
+}
+
+func verifyAuditChain(ctx context.Context, adminService service.AdminService) error {
+	report, err := adminService.VerifyAuditChain(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Tenant %s: checked %d events (seq %d..%d), %d recorded before the chain was enabled\n",
+		report.Tenant, report.Checked, report.FirstSeq, report.LastSeq, report.Unsealed)
+	for _, b := range report.Breaks {
+		fmt.Fprintf(os.Stderr, "seq %d (id %d): %s\n", b.Seq, b.ID, b.Reason)
+	}
+	if !report.OK() {
+		return fmt.Errorf("audit chain is broken in %d places", len(report.Breaks))
+	}
+	fmt.Println("Audit chain is intact")
+	return nil
+}
+
+func exportAuditEvents(ctx context.Context, cfg *configs.Config, adminService service.AdminService, target, keyFile string, opts service.ExportAuditOptions) error {
+	signer, err := auditchain.LoadSigner(keyFile)
+	if err != nil {
+		return err
+	}
+	sink, err := auditchain.NewSink(target, auditchain.S3Config{
+		Endpoint:  cfg.AuditExportS3Endpoint,
+		Region:    cfg.AuditExportS3Region,
+		AccessKey: cfg.AuditExportS3AccessKey,
+		SecretKey: cfg.AuditExportS3SecretKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := adminService.ExportAuditEvents(ctx, sink, signer, opts)
+	if result != nil {
+		for _, name := range result.Batches {
+			fmt.Fprintln(os.Stderr, name)
+		}
+		fmt.Printf("Exported %d events in %d batches, last seq %d\n", result.Exported, len(result.Batches), result.LastSeq)
+	}
+	return err
+}
+
+func verifyAuditBatch(path, keyFile string) error {
+	key, err := auditchain.LoadPublicKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rawManifest, err := os.ReadFile(strings.TrimSuffix(path, ".jsonl") + ".manifest.json")
+	if err != nil {
+		return err
+	}
+	var manifest auditchain.Manifest
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	records, err := auditchain.VerifyBatch(data, &manifest, key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Batch is valid: tenant %s, %d events, seq %d..%d, signed by key %s\n",
+		manifest.Tenant, len(records), manifest.FirstSeq, manifest.LastSeq, manifest.KeyID)
+	return nil
+}
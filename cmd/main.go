@@ -3,16 +3,37 @@ package main
 import (
 	"auth_test/configs"
 	"auth_test/internal/handler"
+	"auth_test/internal/health"
+	"auth_test/internal/lifecycle"
+	"auth_test/internal/logging"
+	"auth_test/internal/mail"
+	"auth_test/internal/migrate"
+	"auth_test/internal/ratelimit"
 	"auth_test/internal/service"
 	"auth_test/internal/store"
+	"auth_test/internal/tlsutil"
+	"auth_test/internal/tracing"
+	"auth_test/pkg/metrics"
 	"auth_test/pkg/pb"
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log"
-	"net"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -22,27 +43,305 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	dbStore, err := store.NewPostgresStore(cfg.DBConnectionString())
+	logger, err := logging.New(os.Stderr, logging.Config{
+		Level:           cfg.LogLevel,
+		Format:          cfg.LogFormat,
+		RedactUsernames: cfg.LogRedactUsernames,
+	})
+	if err != nil {
+		log.Fatalf("Invalid logging config: %v", err)
+	}
+	// log.Printf тоже идёт через этот обработчик
+	slog.SetDefault(logger)
+
+	// До подключения к базе: запросы pgx пишут спаны в установленный здесь провайдер
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		ServiceName:  cfg.TracingServiceName,
+		Environment:  cfg.AppEnv,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPInsecure: cfg.TracingOTLPInsecure,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure tracing: %v", err)
+	}
+
+	userStore, migrator, err := newStore(cfg)
 	if err != nil {
 		log.Fatalf("Database connection failed: %v", err)
 	}
-	defer dbStore.Close()
+	// Для подкоманд, завершающихся до запуска серверов. Дальше хранилище и трассировку закрывает lc
+	closeOnReturn := true
+	defer func() {
+		if !closeOnReturn {
+			return
+		}
+		userStore.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
 
-	// userStore := store.NewInMemoryStore()
-	userService := service.NewUserService(dbStore, cfg.JWTSecret)
-	grpcHandler := handler.NewGRPCHandler(userService)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(context.Background(), migrator, os.Args[2:]); err != nil {
+			userStore.Close()
+			log.Fatalf("Migrate: %v", err)
+		}
+		return
+	}
+
+	if err := prepareSchema(context.Background(), migrator, cfg.DBMigrationsMode); err != nil {
+		userStore.Close()
+		log.Fatalf("Database schema is not ready: %v", err)
+	}
+
+	var userServiceOpts []service.UserServiceOption
+	if cfg.EmailVerificationEnabled {
+		sender, err := mail.NewSender(mail.Config{
+			Driver:       cfg.MailDriver,
+			From:         cfg.MailFrom,
+			SMTPHost:     cfg.SMTPHost,
+			SMTPPort:     cfg.SMTPPort,
+			SMTPUsername: cfg.SMTPUsername,
+			SMTPPassword: cfg.SMTPPassword,
+			FileDir:      cfg.MailFileDir,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure mail sender: %v", err)
+		}
+
+		userServiceOpts = append(userServiceOpts, service.WithEmailVerification(sender, service.VerificationConfig{
+			LinkBaseURL:    cfg.EmailVerificationURL,
+			TokenTTL:       cfg.EmailVerificationTTL,
+			ResendInterval: cfg.EmailVerificationResendInterval,
+		}))
+	}
+
+	if len(cfg.TokenProfileClaims) > 0 {
+		claimMapping, err := service.ParseClaimMapping(cfg.TokenProfileClaims)
+		if err != nil {
+			log.Fatalf("Invalid TOKEN_PROFILE_CLAIMS: %v", err)
+		}
+		userServiceOpts = append(userServiceOpts, service.WithProfileClaims(claimMapping))
+	}
+
+	registry := newMetricsRegistry(userStore)
+	serviceMetrics := metrics.NewPrometheus(registry)
+
+	userServiceOpts = append(userServiceOpts, service.WithAuditTenant(cfg.AuditTenant), service.WithMetrics(serviceMetrics))
+	userService := service.NewUserService(userStore, cfg.JWTSecret, userServiceOpts...)
+	adminService := service.NewAdminService(userStore, service.WithAdminAuditTenant(cfg.AuditTenant))
 
 	ctx := context.Background()
-	userService.CreateUser(ctx, "admin", "admin123")
-	userService.CreateUser(ctx, "user", "user123")
 
-	go startMetricsServer(cfg.MetricsPort)
-	startGRPCServer(grpcHandler, cfg.GRPCPort, true)
+	// Административные подкоманды работают напрямую с хранилищем и не запускают серверы
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "user":
+			err = runUserCommand(ctx, adminService, os.Args[2:])
+		case "token":
+			err = runTokenCommand(ctx, userService, os.Args[2:])
+		case "audit":
+			err = runAuditCommand(ctx, cfg, adminService, os.Args[2:])
+		default:
+			userStore.Close()
+			log.Fatalf("Unknown command %q, expected migrate, user, token or audit", os.Args[1])
+		}
+		if err != nil {
+			userStore.Close()
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	grpcHandler := handler.NewGRPCHandler(userService)
+	adminHandler := handler.NewAdminGRPCHandler(adminService)
+
+	if err := bootstrapUsers(ctx, cfg, userStore); err != nil {
+		log.Fatalf("Failed to bootstrap users: %v", err)
+	}
+
+	var tlsConfig *tls.Config
+	var gatewayCreds credentials.TransportCredentials
+	if cfg.TLSEnabled() {
+		reloader, err := tlsutil.NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificate: %v", err)
+		}
+		defer reloader.Close()
+
+		tlsConfig, err = tlsutil.ServerConfig(reloader, cfg.TLSClientCAFile)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+
+		clientTLS, err := tlsutil.GatewayClientConfig(reloader, cfg.TLSGatewayCAFile, cfg.TLSServerName)
+		if err != nil {
+			log.Fatalf("Failed to configure gateway TLS: %v", err)
+		}
+		gatewayCreds = credentials.NewTLS(clientTLS)
+	}
+
+	// SIGTERM (Kubernetes, docker stop) и SIGINT запускают остановку с дозавершением запросов
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	lc := lifecycle.New(cfg.ShutdownDrainTimeout)
+	// Закрываются в обратном порядке: сначала отправляются накопленные спаны, последним - пул базы
+	closeOnReturn = false
+	lc.OnShutdown("store", func(context.Context) error {
+		userStore.Close()
+		return nil
+	})
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	lc.Go("user retention", func(ctx context.Context) {
+		service.RunUserRetention(ctx, adminService, cfg.UserRetentionPeriod, cfg.UserRetentionInterval)
+	})
+	if cfg.EmailVerificationEnabled {
+		// Ссылка из письма к этому моменту истекла: имя и адрес освобождаются для новой регистрации
+		lc.Go("registration retention", func(ctx context.Context) {
+			service.RunRegistrationRetention(ctx, adminService, cfg.EmailVerificationTTL, cfg.UserRetentionInterval)
+		})
+	}
+	if cfg.AuditRetentionPeriod > 0 {
+		lc.Go("audit retention", func(ctx context.Context) {
+			service.RunAuditRetention(ctx, adminService, cfg.AuditRetentionPeriod, cfg.AuditRetentionInterval)
+		})
+	}
+
+	checker := newHealthChecker(cfg, userStore, migrator)
+	// NOT_SERVING выставляется сразу по сигналу, пока серверы ещё дорабатывают начатые запросы
+	lc.OnStopping(checker.Shutdown)
+	lc.Go("readiness", func(ctx context.Context) {
+		checker.Run(ctx, cfg.HealthCheckInterval)
+	})
+
+	// Соединение gateway с gRPC живёт до конца дренирования, а не до сигнала
+	gatewayCtx, cancelGateway := context.WithCancel(context.WithoutCancel(ctx))
+	lc.OnShutdown("gateway connection", func(context.Context) error {
+		cancelGateway()
+		return nil
+	})
+
+	addMetricsServer(lc, cfg.MetricsPort, registry, checker)
+	if err := addHTTPServer(gatewayCtx, lc, cfg.Port, "localhost:"+cfg.GRPCPort, tlsConfig, gatewayCreds); err != nil {
+		log.Fatalf("Failed to create REST gateway: %v", err)
+	}
+	if err := addGRPCServer(lc, grpcHandler, adminHandler, userService, serviceMetrics, checker, cfg, tlsConfig, true); err != nil {
+		log.Fatalf("Failed to create gRPC server: %v", err)
+	}
+
+	if err := lc.Run(ctx); err != nil {
+		log.Fatalf("Service stopped with error: %v", err)
+	}
+}
+
+// bootstrapUsers создаёт пользователей из BOOTSTRAP_* настроек; в production ничего не создаёт
+func bootstrapUsers(ctx context.Context, cfg *configs.Config, userStore store.Querier) error {
+	if err := service.SecureLegacyUsers(ctx, userStore); err != nil {
+		return err
+	}
+	if cfg.IsProduction() {
+		log.Printf("Production mode: user bootstrap is disabled")
+		return nil
+	}
+
+	bootstrapCfg := service.BootstrapConfig{
+		PasswordOutput: os.Stderr,
+	}
+	if cfg.BootstrapUsersFile != "" {
+		users, err := service.LoadSeedUsers(cfg.BootstrapUsersFile)
+		if err != nil {
+			return err
+		}
+		bootstrapCfg.Users = users
+	}
+
+	if cfg.BootstrapAdminPasswordHash != "" || cfg.BootstrapAdminPasswordFile != "" {
+		bootstrapCfg.Users = append(bootstrapCfg.Users, service.SeedUser{
+			Username:     cfg.BootstrapAdminUsername,
+			Role:         service.RoleAdmin,
+			PasswordHash: cfg.BootstrapAdminPasswordHash,
+			PasswordFile: cfg.BootstrapAdminPasswordFile,
+		})
+	} else {
+		bootstrapCfg.GeneratedAdminUsername = cfg.BootstrapAdminUsername
+	}
+
+	return service.Bootstrap(ctx, userStore, bootstrapCfg)
+}
+
+// newStore выбирает хранилище пользователей по DB_DRIVER.
+// Мигратор равен nil для хранилища в памяти
+func newStore(cfg *configs.Config) (store.Store, *migrate.Migrator, error) {
+	switch cfg.DBDriver {
+	case configs.DBDriverMemory:
+		log.Printf("Using in-memory user store, data will be lost on restart")
+		return store.NewInMemoryStore(), nil, nil
+	case configs.DBDriverSQLite:
+		sqliteStore, err := store.NewSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sqliteStore, migrate.NewSQLite(sqliteStore.GetDB()), nil
+	default:
+		pgStore, err := store.NewPostgresStore(cfg.DBConnectionString())
+		if err != nil {
+			return nil, nil, err
+		}
+		return pgStore, migrate.NewPostgres(pgStore.GetDB()), nil
+	}
+}
+
+// newHealthChecker собирает проверки готовности: пул базы, версия схемы и ключ подписи токенов
+func newHealthChecker(cfg *configs.Config, userStore store.Store, migrator *migrate.Migrator) *health.Checker {
+	checker := health.New(cfg.HealthCheckTimeout, pb.AuthService_ServiceDesc.ServiceName, pb.AdminService_ServiceDesc.ServiceName)
+	checker.Add("database", userStore.Ping)
+	// С выключенными миграциями схемой управляет внешний инструмент
+	if migrator != nil && cfg.DBMigrationsMode != configs.MigrationsModeOff {
+		checker.Add("migrations", migrator.Ready)
+	}
+	checker.Add("signing_key", func(context.Context) error {
+		return service.CheckSigningKey(cfg.JWTSecret)
+	})
+	return checker
 }
 
-func startGRPCServer(grpcHandler *handler.GRPCHandler, port string, enableReflection bool) {
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(handler.MetricsInterceptor()))
+// addGRPCServer обслуживает gRPC, gRPC-Web и Connect на одном порту (h2c + HTTP/1.1, либо TLS)
+func addGRPCServer(lc *lifecycle.Manager, grpcHandler *handler.GRPCHandler, adminHandler *handler.AdminGRPCHandler, userService service.UserService, serviceMetrics metrics.Metrics, checker *health.Checker, cfg *configs.Config, tlsConfig *tls.Config, enableReflection bool) error {
+	policies, err := ratelimit.ParsePolicies(cfg.RateLimitPolicies)
+	if err != nil {
+		return err
+	}
+	methodTimeouts, err := handler.ParseMethodTimeouts(cfg.GRPCMethodTimeouts)
+	if err != nil {
+		return err
+	}
+
+	interceptors := handler.InterceptorConfig{
+		Logger:              slog.Default(),
+		UserService:         userService,
+		Metrics:             serviceMetrics,
+		MTLSRequiredMethods: cfg.MTLSRequiredMethods,
+		DefaultTimeout:      cfg.GRPCDefaultTimeout,
+		MethodTimeouts:      methodTimeouts,
+	}
+	if len(policies) > 0 {
+		interceptors.Limiter = ratelimit.New(ratelimit.NewMemoryBackend(), policies)
+		log.Printf("Rate limiting enabled with %d policies", len(policies))
+	}
+
+	// StatsHandler открывает серверный спан до интерцепторов, поэтому request_id и логи его видят
+	opts := []grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler())}
+	grpcServer := grpc.NewServer(append(opts, handler.ServerInterceptors(interceptors)...)...)
 	pb.RegisterAuthServiceServer(grpcServer, grpcHandler)
+	pb.RegisterAdminServiceServer(grpcServer, adminHandler)
+	healthpb.RegisterHealthServer(grpcServer, checker.GRPC())
 
 	// для проверки reflection api
 	if enableReflection {
@@ -50,20 +349,98 @@ func startGRPCServer(grpcHandler *handler.GRPCHandler, port string, enableReflec
 		log.Printf("gRPC reflection enabled")
 	}
 
-	lis, err := net.Listen("tcp", ":"+port)
+	multiHandler, err := handler.NewMultiProtocolHandler(grpcServer, cfg.CORSAllowedOrigins)
+	if err != nil {
+		return fmt.Errorf("create gRPC-Web/Connect handler: %w", err)
+	}
+
+	server := newHTTPServer(cfg.GRPCPort, multiHandler, tlsConfig)
+
+	lc.AddServer("grpc", func() error {
+		log.Printf("gRPC server starting on %s (tls: %t)", cfg.GRPCPort, tlsConfig != nil)
+		return listenAndServe(server)
+	}, func(ctx context.Context) error {
+		// gRPC обслуживается через http.Server (ServeHTTP), поэтому запросы дренирует он.
+		// GracefulStop вызывается, когда HTTP соединений уже нет, и ждёт оставшиеся обработчики
+		if err := lifecycle.ShutdownHTTP(server)(ctx); err != nil {
+			grpcServer.Stop()
+			return err
+		}
+		grpcServer.GracefulStop()
+		return nil
+	})
+	return nil
+}
+
+func addHTTPServer(ctx context.Context, lc *lifecycle.Manager, port string, grpcEndpoint string, tlsConfig *tls.Config, gatewayCreds credentials.TransportCredentials) error {
+	gatewayHandler, err := handler.NewGatewayHandler(ctx, grpcEndpoint, gatewayCreds)
 	if err != nil {
-		log.Fatalf("Failed to listen for gRPC: %v", err)
+		return err
 	}
 
-	log.Printf("gRPC server starting on %s", port)
+	server := newHTTPServer(port, gatewayHandler, tlsConfig)
+	lc.AddServer("http gateway", func() error {
+		log.Printf("HTTP gateway starting on %s (tls: %t)", port, tlsConfig != nil)
+		return listenAndServe(server)
+	}, lifecycle.ShutdownHTTP(server))
+	return nil
+}
 
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("Failed to serve gRPC: %v", err)
+// newMetricsRegistry создаёт реестр метрик процесса: рантайм Go, процесс и пул соединений с базой
+func newMetricsRegistry(userStore store.Store) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if pgStore, ok := userStore.(*store.PostgresStore); ok {
+		registry.MustRegister(metrics.NewPoolCollector(pgStore.GetDB()))
 	}
+	return registry
 }
 
-func startMetricsServer(port string) {
-	http.Handle("/metrics", promhttp.Handler())
-	log.Printf("Metrics server starting on %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+// addMetricsServer отдаёт метрики и проверки /healthz, /readyz на отдельном порту
+func addMetricsServer(lc *lifecycle.Manager, port string, registry *prometheus.Registry, checker *health.Checker) {
+	mux := http.NewServeMux()
+	// Exemplars с trace_id отдаются только в формате OpenMetrics
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+		Registry:          registry,
+	}))
+	mux.Handle("/healthz", checker.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+
+	server := &http.Server{Addr: ":" + port, Handler: mux}
+	lc.AddServer("metrics", func() error {
+		log.Printf("Metrics server starting on %s", port)
+		return server.ListenAndServe()
+	}, func(context.Context) error { return nil })
+	// Останавливается после дренирования остальных серверов: всё это время /readyz отвечает 503,
+	// а Prometheus успевает собрать последние метрики
+	lc.OnShutdown("metrics server", lifecycle.ShutdownHTTP(server))
+}
+
+func newHTTPServer(port string, h http.Handler, tlsConfig *tls.Config) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if tlsConfig != nil {
+		protocols.SetHTTP2(true)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return &http.Server{
+		Addr:      ":" + port,
+		Handler:   h,
+		Protocols: protocols,
+		TLSConfig: tlsConfig,
+	}
+}
+
+func listenAndServe(server *http.Server) error {
+	if server.TLSConfig != nil {
+		// сертификат берётся из TLSConfig.GetCertificate
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"auth_test/configs"
+	"auth_test/internal/migrate"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down [N] | status"
+
+// runMigrateCommand выполняет подкоманду migrate: up, down [N] (по умолчанию одна миграция) или status
+func runMigrateCommand(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	if migrator == nil {
+		return errors.New("migrations are not used with the in-memory store")
+	}
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		// Каждая применённая миграция уже записана в лог мигратором
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+			steps = n
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No applied migrations")
+		}
+		return nil
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Current version: %d (dirty: %t)\n", status.Current, status.Dirty)
+		fmt.Printf("Latest version:  %d\n", status.Latest)
+		for _, migration := range status.Pending {
+			fmt.Printf("Pending: %d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// prepareSchema применяет или проверяет миграции перед запуском сервиса согласно DB_MIGRATIONS_MODE
+func prepareSchema(ctx context.Context, migrator *migrate.Migrator, mode string) error {
+	if migrator == nil || mode == configs.MigrationsModeOff {
+		return nil
+	}
+
+	if mode == configs.MigrationsModeAuto {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Database migrations applied: %d", len(applied))
+		return nil
+	}
+
+	return migrator.Check(ctx)
+}
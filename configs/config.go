@@ -1,21 +1,149 @@
 package configs
 
 import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/ratelimit"
+	"auth_test/internal/tracing"
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
+const (
+	DBDriverPostgres = "postgres"
+	DBDriverMemory   = "memory"
+	DBDriverSQLite   = "sqlite"
+)
+
+const (
+	MigrationsModeCheck = "check"
+	MigrationsModeAuto  = "auto"
+	MigrationsModeOff   = "off"
+)
+
+const RateLimitBackendMemory = "memory"
+
+const (
+	AppEnvDevelopment = "development"
+	AppEnvProduction  = "production"
+)
+
 type Config struct {
+	// development или production. В production пользователи при старте не создаются
+	AppEnv string `mapstructure:"APP_ENV"`
+
+	// Логи: уровень debug/info/warn/error, формат json или text.
+	// Пароли и токены не пишутся никогда, имена пользователей - если не включено LOG_REDACT_USERNAMES
+	LogLevel           string `mapstructure:"LOG_LEVEL"`
+	LogFormat          string `mapstructure:"LOG_FORMAT"`
+	LogRedactUsernames bool   `mapstructure:"LOG_REDACT_USERNAMES"`
+
+	// Трассировка OpenTelemetry: none, otlp (OTLP/gRPC на TRACING_OTLP_ENDPOINT) или stdout
+	TracingExporter     string  `mapstructure:"TRACING_EXPORTER"`
+	TracingServiceName  string  `mapstructure:"TRACING_SERVICE_NAME"`
+	TracingOTLPEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure bool    `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingSampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
 	JWTSecret   string `mapstructure:"JWT_SECRET"`
 	Port        string `mapstructure:"SERVER_PORT"`
 	MetricsPort string `mapstructure:"METRICS_PORT"`
 	GRPCPort    string `mapstructure:"GRPC_PORT"`
-	DBHost      string `mapstructure:"DB_HOST"`
-	DBPort      string `mapstructure:"POSTGRES_PORT"`
-	DBName      string `mapstructure:"POSTGRES_DB"`
-	DBUser      string `mapstructure:"POSTGRES_USER"`
-	DBPass      string `mapstructure:"POSTGRES_PASSWORD"`
+
+	// Хранилище пользователей: postgres, sqlite или memory (без базы, данные теряются при перезапуске)
+	DBDriver string `mapstructure:"DB_DRIVER"`
+	// Путь к файлу базы при DB_DRIVER=sqlite
+	SQLitePath string `mapstructure:"SQLITE_PATH"`
+	// Миграции при старте: check - отказ от запуска, если схема отстаёт; auto - применить; off - не проверять
+	DBMigrationsMode string `mapstructure:"DB_MIGRATIONS_MODE"`
+
+	DBHost string `mapstructure:"DB_HOST"`
+	DBPort string `mapstructure:"POSTGRES_PORT"`
+	DBName string `mapstructure:"POSTGRES_DB"`
+	DBUser string `mapstructure:"POSTGRES_USER"`
+	DBPass string `mapstructure:"POSTGRES_PASSWORD"`
+
+	// sslmode и корневой сертификат для подключения к Postgres
+	DBSSLMode     string `mapstructure:"POSTGRES_SSLMODE"`
+	DBSSLRootCert string `mapstructure:"POSTGRES_SSLROOTCERT"`
+
+	CORSAllowedOrigins []string `mapstructure:"CORS_ALLOWED_ORIGINS"`
+
+	// TLS для gRPC и HTTP. Пустые пути - сервер работает без шифрования
+	TLSCertFile     string `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile      string `mapstructure:"TLS_KEY_FILE"`
+	TLSClientCAFile string `mapstructure:"TLS_CLIENT_CA_FILE"`
+	TLSServerName   string `mapstructure:"TLS_SERVER_NAME"`
+	// CA, по которому REST gateway проверяет сертификат gRPC сервера. Пустой путь - gateway
+	// принимает только текущий сертификат сервера, в том числе после его перечитывания
+	TLSGatewayCAFile string `mapstructure:"TLS_GATEWAY_CA_FILE"`
+	// Методы (префиксы полных имён gRPC), для которых обязателен клиентский сертификат
+	MTLSRequiredMethods []string `mapstructure:"MTLS_REQUIRED_METHODS"`
+
+	// Deadline вызова gRPC, если клиент не передал свой (0 - без ограничения), и его значения
+	// для отдельных методов, записи вида /auth.AdminService/ExportUsers=5m
+	GRPCDefaultTimeout time.Duration `mapstructure:"GRPC_DEFAULT_TIMEOUT"`
+	GRPCMethodTimeouts []string      `mapstructure:"GRPC_METHOD_TIMEOUTS"`
+
+	// Ограничение частоты вызовов, записи вида префикс_метода:ключ:частота/единица:burst
+	// (ключ ip, client или username). Пустой список выключает ограничение.
+	// Адрес клиента за балансировщиком - адрес балансировщика, лимиты по ip нужно выбирать с учётом этого
+	RateLimitPolicies []string `mapstructure:"RATE_LIMIT_POLICIES"`
+	// Где хранятся бакеты: memory - отдельный лимит у каждого экземпляра сервиса
+	RateLimitBackend string `mapstructure:"RATE_LIMIT_BACKEND"`
+
+	// Сколько ждать завершения начатых запросов после SIGTERM, прежде чем закрыть соединения.
+	// Должно быть меньше terminationGracePeriodSeconds в Kubernetes
+	ShutdownDrainTimeout time.Duration `mapstructure:"SHUTDOWN_DRAIN_TIMEOUT"`
+
+	// Как часто проверять готовность зависимостей для grpc.health.v1 и сколько ждать одну проверку
+	HealthCheckInterval time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL"`
+	HealthCheckTimeout  time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+
+	// Сколько хранить мягко удалённых пользователей и как часто их вычищать
+	UserRetentionPeriod   time.Duration `mapstructure:"USER_RETENTION_PERIOD"`
+	UserRetentionInterval time.Duration `mapstructure:"USER_RETENTION_INTERVAL"`
+
+	// Сколько хранить события аудита (0 - хранить бессрочно) и как часто вычищать старые
+	AuditRetentionPeriod   time.Duration `mapstructure:"AUDIT_RETENTION_PERIOD"`
+	AuditRetentionInterval time.Duration `mapstructure:"AUDIT_RETENTION_INTERVAL"`
+	// Цепочка хешей журнала аудита, в которую пишет этот экземпляр сервиса
+	AuditTenant string `mapstructure:"AUDIT_TENANT"`
+	// Выгрузка подписанных пакетов аудита (auth-admin audit export): закрытый ключ Ed25519 в PEM
+	// и каталог или s3://bucket/prefix
+	AuditExportSigningKeyFile string `mapstructure:"AUDIT_EXPORT_SIGNING_KEY_FILE"`
+	AuditExportSink           string `mapstructure:"AUDIT_EXPORT_SINK"`
+	AuditExportS3Endpoint     string `mapstructure:"AUDIT_EXPORT_S3_ENDPOINT"`
+	AuditExportS3Region       string `mapstructure:"AUDIT_EXPORT_S3_REGION"`
+	AuditExportS3AccessKey    string `mapstructure:"AUDIT_EXPORT_S3_ACCESS_KEY"`
+	AuditExportS3SecretKey    string `mapstructure:"AUDIT_EXPORT_S3_SECRET_KEY"`
+
+	// Подтверждение почты при регистрации
+	EmailVerificationEnabled        bool          `mapstructure:"EMAIL_VERIFICATION_ENABLED"`
+	EmailVerificationURL            string        `mapstructure:"EMAIL_VERIFICATION_URL"`
+	EmailVerificationTTL            time.Duration `mapstructure:"EMAIL_VERIFICATION_TTL"`
+	EmailVerificationResendInterval time.Duration `mapstructure:"EMAIL_VERIFICATION_RESEND_INTERVAL"`
+
+	// Отправка писем: smtp, file или memory
+	MailDriver   string `mapstructure:"MAIL_DRIVER"`
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	MailFileDir  string `mapstructure:"MAIL_FILE_DIR"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	// Пользователи, создаваемые при старте (YAML файл со списком users)
+	BootstrapUsersFile string `mapstructure:"BOOTSTRAP_USERS_FILE"`
+	// Администратор из env: пароль задаётся bcrypt хешем или файлом. Если не задан и администраторов нет,
+	// он создаётся со сгенерированным одноразовым паролем
+	BootstrapAdminUsername     string `mapstructure:"BOOTSTRAP_ADMIN_USERNAME"`
+	BootstrapAdminPasswordHash string `mapstructure:"BOOTSTRAP_ADMIN_PASSWORD_HASH"`
+	BootstrapAdminPasswordFile string `mapstructure:"BOOTSTRAP_ADMIN_PASSWORD_FILE"`
+
+	// Поля профиля в access токене, записи вида claim=field (например name=display_name)
+	TokenProfileClaims []string `mapstructure:"TOKEN_PROFILE_CLAIMS"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -27,6 +155,7 @@ func LoadConfig() (*Config, error) {
 	}
 
 	viper.AutomaticEnv()
+	setDefaults()
 
 	cfg := &Config{}
 	if err := viper.Unmarshal(&cfg); err != nil {
@@ -40,21 +169,159 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// setDefaults регистрирует необязательные параметры, чтобы их можно было задать через env без .env
+func setDefaults() {
+	viper.SetDefault("APP_ENV", AppEnvDevelopment)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("LOG_REDACT_USERNAMES", false)
+	viper.SetDefault("TRACING_EXPORTER", tracing.ExporterNone)
+	viper.SetDefault("TRACING_SERVICE_NAME", "auth-service")
+	viper.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4317")
+	viper.SetDefault("TRACING_OTLP_INSECURE", true)
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("DB_DRIVER", DBDriverPostgres)
+	viper.SetDefault("SQLITE_PATH", "auth.db")
+	viper.SetDefault("DB_MIGRATIONS_MODE", MigrationsModeCheck)
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", []string{})
+	viper.SetDefault("POSTGRES_SSLMODE", "disable")
+	viper.SetDefault("POSTGRES_SSLROOTCERT", "")
+	viper.SetDefault("TLS_CERT_FILE", "")
+	viper.SetDefault("TLS_KEY_FILE", "")
+	viper.SetDefault("TLS_CLIENT_CA_FILE", "")
+	viper.SetDefault("TLS_SERVER_NAME", "localhost")
+	viper.SetDefault("TLS_GATEWAY_CA_FILE", "")
+	viper.SetDefault("MTLS_REQUIRED_METHODS", []string{})
+	viper.SetDefault("GRPC_DEFAULT_TIMEOUT", 10*time.Second)
+	viper.SetDefault("GRPC_METHOD_TIMEOUTS", []string{})
+	viper.SetDefault("RATE_LIMIT_POLICIES", ratelimit.DefaultPolicies)
+	viper.SetDefault("RATE_LIMIT_BACKEND", RateLimitBackendMemory)
+	viper.SetDefault("USER_RETENTION_PERIOD", 30*24*time.Hour)
+	viper.SetDefault("USER_RETENTION_INTERVAL", time.Hour)
+	viper.SetDefault("SHUTDOWN_DRAIN_TIMEOUT", 20*time.Second)
+	viper.SetDefault("HEALTH_CHECK_INTERVAL", 10*time.Second)
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", 2*time.Second)
+	viper.SetDefault("AUDIT_RETENTION_PERIOD", 365*24*time.Hour)
+	viper.SetDefault("AUDIT_RETENTION_INTERVAL", time.Hour)
+	viper.SetDefault("AUDIT_TENANT", auditchain.DefaultTenant)
+	viper.SetDefault("AUDIT_EXPORT_SIGNING_KEY_FILE", "")
+	viper.SetDefault("AUDIT_EXPORT_SINK", "")
+	viper.SetDefault("AUDIT_EXPORT_S3_ENDPOINT", "")
+	viper.SetDefault("AUDIT_EXPORT_S3_REGION", "us-east-1")
+	viper.SetDefault("AUDIT_EXPORT_S3_ACCESS_KEY", "")
+	viper.SetDefault("AUDIT_EXPORT_S3_SECRET_KEY", "")
+	viper.SetDefault("EMAIL_VERIFICATION_ENABLED", false)
+	viper.SetDefault("EMAIL_VERIFICATION_URL", "http://localhost:8080/verify-email")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	viper.SetDefault("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+	viper.SetDefault("MAIL_DRIVER", "file")
+	viper.SetDefault("MAIL_FROM", "noreply@localhost")
+	viper.SetDefault("MAIL_FILE_DIR", "mail")
+	viper.SetDefault("SMTP_HOST", "")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("SMTP_USERNAME", "")
+	viper.SetDefault("SMTP_PASSWORD", "")
+	viper.SetDefault("TOKEN_PROFILE_CLAIMS", []string{})
+	viper.SetDefault("BOOTSTRAP_USERS_FILE", "")
+	viper.SetDefault("BOOTSTRAP_ADMIN_USERNAME", "admin")
+	viper.SetDefault("BOOTSTRAP_ADMIN_PASSWORD_HASH", "")
+	viper.SetDefault("BOOTSTRAP_ADMIN_PASSWORD_FILE", "")
+}
+
 func (c *Config) DBConnectionString() string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", c.DBHost, c.DBPort, c.DBUser, c.DBPass, c.DBName)
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", c.DBHost, c.DBPort, c.DBUser, c.DBPass, c.DBName, c.DBSSLMode)
+	if c.DBSSLRootCert != "" {
+		connStr += " sslrootcert=" + c.DBSSLRootCert
+	}
+	return connStr
+}
+
+func (c *Config) IsProduction() bool {
+	return c.AppEnv == AppEnvProduction
+}
+
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
 }
 
 func validateConfig(cfg *Config) error {
 	required := map[string]string{
-		"JWT_SECRET":        cfg.JWTSecret,
-		"SERVER_PORT":       cfg.Port,
-		"METRICS_PORT":      cfg.MetricsPort,
-		"GRPC_PORT":         cfg.GRPCPort,
-		"DB_HOST":           cfg.DBHost,
-		"POSTGRES_PORT":     cfg.DBPort,
-		"POSTGRES_DB":       cfg.DBName,
-		"POSTGRES_USER":     cfg.DBUser,
-		"POSTGRES_PASSWORD": cfg.DBPass,
+		"JWT_SECRET":   cfg.JWTSecret,
+		"SERVER_PORT":  cfg.Port,
+		"METRICS_PORT": cfg.MetricsPort,
+		"GRPC_PORT":    cfg.GRPCPort,
+	}
+
+	switch cfg.DBDriver {
+	case DBDriverPostgres:
+		required["DB_HOST"] = cfg.DBHost
+		required["POSTGRES_PORT"] = cfg.DBPort
+		required["POSTGRES_DB"] = cfg.DBName
+		required["POSTGRES_USER"] = cfg.DBUser
+		required["POSTGRES_PASSWORD"] = cfg.DBPass
+	case DBDriverSQLite:
+		required["SQLITE_PATH"] = cfg.SQLitePath
+	case DBDriverMemory:
+	default:
+		return fmt.Errorf("unsupported DB_DRIVER: %s", cfg.DBDriver)
+	}
+
+	switch cfg.AppEnv {
+	case AppEnvDevelopment, AppEnvProduction:
+	default:
+		return fmt.Errorf("unsupported APP_ENV: %s", cfg.AppEnv)
+	}
+
+	switch cfg.DBMigrationsMode {
+	case MigrationsModeCheck, MigrationsModeAuto, MigrationsModeOff:
+	default:
+		return fmt.Errorf("unsupported DB_MIGRATIONS_MODE: %s", cfg.DBMigrationsMode)
+	}
+
+	// Нулевой срок удалял бы пользователя окончательно сразу после DeleteUser, без возможности восстановить
+	if cfg.UserRetentionPeriod <= 0 || cfg.UserRetentionInterval <= 0 {
+		return fmt.Errorf("invalid USER_RETENTION_PERIOD/USER_RETENTION_INTERVAL: %s/%s", cfg.UserRetentionPeriod, cfg.UserRetentionInterval)
+	}
+
+	if cfg.AuditRetentionPeriod < 0 || (cfg.AuditRetentionPeriod > 0 && cfg.AuditRetentionInterval <= 0) {
+		return fmt.Errorf("invalid AUDIT_RETENTION_PERIOD/AUDIT_RETENTION_INTERVAL: %s/%s", cfg.AuditRetentionPeriod, cfg.AuditRetentionInterval)
+	}
+
+	// Неподтверждённые регистрации удаляются по истечении ссылки, поэтому нулевой срок удалял бы их сразу
+	if cfg.EmailVerificationEnabled && cfg.EmailVerificationTTL <= 0 {
+		return fmt.Errorf("EMAIL_VERIFICATION_TTL must be positive, got %s", cfg.EmailVerificationTTL)
+	}
+
+	if cfg.ShutdownDrainTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_TIMEOUT must be positive, got %s", cfg.ShutdownDrainTimeout)
+	}
+
+	if cfg.GRPCDefaultTimeout < 0 {
+		return fmt.Errorf("GRPC_DEFAULT_TIMEOUT must not be negative, got %s", cfg.GRPCDefaultTimeout)
+	}
+
+	if _, err := ratelimit.ParsePolicies(cfg.RateLimitPolicies); err != nil {
+		return fmt.Errorf("invalid RATE_LIMIT_POLICIES: %w", err)
+	}
+	if cfg.RateLimitBackend != RateLimitBackendMemory {
+		return fmt.Errorf("unsupported RATE_LIMIT_BACKEND: %s", cfg.RateLimitBackend)
+	}
+
+	if cfg.HealthCheckInterval <= 0 || cfg.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("invalid HEALTH_CHECK_INTERVAL/HEALTH_CHECK_TIMEOUT: %s/%s", cfg.HealthCheckInterval, cfg.HealthCheckTimeout)
+	}
+
+	switch cfg.TracingExporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		return fmt.Errorf("unsupported TRACING_EXPORTER: %s", cfg.TracingExporter)
+	}
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %v", cfg.TracingSampleRatio)
+	}
+
+	if !auditchain.ValidTenant(cfg.AuditTenant) {
+		return fmt.Errorf("invalid AUDIT_TENANT %q: lowercase letters, digits, '-' and '_', up to 64 characters", cfg.AuditTenant)
 	}
 
 	for field, value := range required {
@@ -0,0 +1,134 @@
+// Package apierror - единая модель ошибок API. Ошибки сервиса (service.Err*) переводятся
+// в статусы gRPC с причиной в errdetails.ErrorInfo и в ответы application/problem+json
+// (RFC 7807) для HTTP. Клиенты ветвятся по причине, а не по тексту сообщения
+package apierror
+
+import (
+	"auth_test/internal/service"
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain - домен причин в ErrorInfo
+const Domain = "auth-service"
+
+// Причины ошибок: ErrorInfo.Reason в gRPC и поле reason в problem+json
+const (
+	ReasonInvalidCredentials     = "INVALID_CREDENTIALS"
+	ReasonPasswordChangeRequired = "PASSWORD_CHANGE_REQUIRED"
+	ReasonWeakPassword           = "WEAK_PASSWORD"
+	ReasonUserDisabled           = "USER_DISABLED"
+	ReasonUserNotVerified        = "USER_NOT_VERIFIED"
+	ReasonUserNotFound           = "USER_NOT_FOUND"
+	ReasonUserAlreadyExists      = "USER_ALREADY_EXISTS"
+	ReasonEmailAlreadyInUse      = "EMAIL_ALREADY_IN_USE"
+	ReasonMissingToken           = "MISSING_TOKEN"
+	ReasonInvalidToken           = "INVALID_TOKEN"
+	ReasonTokenExpired           = "TOKEN_EXPIRED"
+	ReasonPermissionDenied       = "PERMISSION_DENIED"
+	ReasonVersionConflict        = "VERSION_CONFLICT"
+	ReasonInvalidArgument        = "INVALID_ARGUMENT"
+	ReasonVerificationDisabled   = "VERIFICATION_DISABLED"
+	ReasonTooManyRequests        = "TOO_MANY_REQUESTS"
+	ReasonRateLimited            = "RATE_LIMITED"
+	ReasonUnavailable            = "SERVICE_UNAVAILABLE"
+	ReasonInternal               = "INTERNAL"
+)
+
+type rule struct {
+	err    error
+	code   codes.Code
+	reason string
+	// Пустое сообщение - текст самой ошибки: он предназначен клиенту
+	message string
+}
+
+// rules проверяются по порядку через errors.Is
+var rules = []rule{
+	{service.ErrInvalidCredentials, codes.Unauthenticated, ReasonInvalidCredentials, "invalid credentials"},
+	// Пароль верный: клиент должен вызвать ChangePassword и войти заново
+	{service.ErrPasswordChangeRequired, codes.FailedPrecondition, ReasonPasswordChangeRequired, "password change required"},
+	{service.ErrWeakPassword, codes.InvalidArgument, ReasonWeakPassword, ""},
+	{service.ErrUserDisabled, codes.PermissionDenied, ReasonUserDisabled, "user is disabled"},
+	{service.ErrUserNotVerified, codes.FailedPrecondition, ReasonUserNotVerified, "email is not verified"},
+	{service.ErrUserNotFound, codes.NotFound, ReasonUserNotFound, "user not found"},
+	{service.ErrUserAlreadyExists, codes.AlreadyExists, ReasonUserAlreadyExists, "user already exists"},
+	{service.ErrEmailAlreadyInUse, codes.AlreadyExists, ReasonEmailAlreadyInUse, "email already in use"},
+	{service.ErrInvalidToken, codes.Unauthenticated, ReasonInvalidToken, "invalid token"},
+	{service.ErrInvalidTypeToken, codes.Unauthenticated, ReasonInvalidToken, "invalid token type"},
+	{service.ErrExpiredToken, codes.Unauthenticated, ReasonTokenExpired, "token expired"},
+	{service.ErrVersionConflict, codes.Aborted, ReasonVersionConflict, "profile was modified, reload and retry"},
+	{service.ErrInvalidProfile, codes.InvalidArgument, ReasonInvalidArgument, ""},
+	{service.ErrInvalidFormat, codes.InvalidArgument, ReasonInvalidArgument, ""},
+	{service.ErrInvalidRole, codes.InvalidArgument, ReasonInvalidArgument, "invalid role"},
+	{service.ErrInvalidStatus, codes.InvalidArgument, ReasonInvalidArgument, "invalid status"},
+	{service.ErrInvalidOutcome, codes.InvalidArgument, ReasonInvalidArgument, "invalid outcome"},
+	{service.ErrInvalidPageToken, codes.InvalidArgument, ReasonInvalidArgument, "invalid page token"},
+	{service.ErrInvalidArgument, codes.InvalidArgument, ReasonInvalidArgument, "invalid argument"},
+	{service.ErrVerificationDisabled, codes.Unimplemented, ReasonVerificationDisabled, "email verification is disabled"},
+	{service.ErrTooManyRequests, codes.ResourceExhausted, ReasonTooManyRequests, "too many requests, try again later"},
+	{service.ErrUnavailable, codes.Unavailable, ReasonUnavailable, "service temporarily unavailable, try again later"},
+}
+
+// Status переводит ошибку в статус gRPC. Статусы gRPC возвращаются как есть, ошибки
+// контекста - Canceled и DeadlineExceeded, неизвестные ошибки - Internal без подробностей
+func Status(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	// Текст обёрток вокруг ошибки контекста клиенту не нужен
+	for _, ctxErr := range []error{context.Canceled, context.DeadlineExceeded} {
+		if errors.Is(err, ctxErr) {
+			return status.FromContextError(ctxErr)
+		}
+	}
+
+	for _, r := range rules {
+		if errors.Is(err, r.err) {
+			message := r.message
+			if message == "" {
+				message = err.Error()
+			}
+			return withReason(r.code, r.reason, message)
+		}
+	}
+	return withReason(codes.Internal, ReasonInternal, "internal error")
+}
+
+// GRPC - Status(err) как ошибка для возврата из обработчика gRPC
+func GRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	return Status(err).Err()
+}
+
+// New создаёт ошибку gRPC с причиной для случаев, когда код или текст задаёт обработчик
+func New(code codes.Code, reason, message string) error {
+	return withReason(code, reason, message).Err()
+}
+
+// Reason возвращает причину из ErrorInfo статуса ошибки или пустую строку
+func Reason(err error) string {
+	for _, detail := range Status(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+func withReason(code codes.Code, reason, message string) *status.Status {
+	st := status.New(code, message)
+	if withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: Domain}); err == nil {
+		st = withDetails
+	}
+	return st
+}
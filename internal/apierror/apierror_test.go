@@ -0,0 +1,158 @@
+package apierror
+
+import (
+	"auth_test/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    codes.Code
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "invalid credentials",
+			err:             service.ErrInvalidCredentials,
+			expectedCode:    codes.Unauthenticated,
+			expectedReason:  ReasonInvalidCredentials,
+			expectedMessage: "invalid credentials",
+		},
+		{
+			name:            "storage failure hides details",
+			err:             fmt.Errorf("%w: dial tcp 10.0.0.5:5432: connection refused", service.ErrUnavailable),
+			expectedCode:    codes.Unavailable,
+			expectedReason:  ReasonUnavailable,
+			expectedMessage: "service temporarily unavailable, try again later",
+		},
+		{
+			name:            "message for client is kept",
+			err:             fmt.Errorf("%w: line 3: unknown role", service.ErrInvalidFormat),
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  ReasonInvalidArgument,
+			expectedMessage: "invalid import format: line 3: unknown role",
+		},
+		{
+			name:            "password change required",
+			err:             service.ErrPasswordChangeRequired,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  ReasonPasswordChangeRequired,
+			expectedMessage: "password change required",
+		},
+		{
+			name:            "unknown error",
+			err:             errors.New("pq: syntax error"),
+			expectedCode:    codes.Internal,
+			expectedReason:  ReasonInternal,
+			expectedMessage: "internal error",
+		},
+		{
+			name:            "context deadline",
+			err:             fmt.Errorf("get user: %w", context.DeadlineExceeded),
+			expectedCode:    codes.DeadlineExceeded,
+			expectedMessage: context.DeadlineExceeded.Error(),
+		},
+		{
+			name:            "status is passed through",
+			err:             status.Error(codes.PermissionDenied, "admin role required"),
+			expectedCode:    codes.PermissionDenied,
+			expectedMessage: "admin role required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := Status(tt.err)
+			assert.Equal(t, tt.expectedCode, st.Code())
+			assert.Equal(t, tt.expectedMessage, st.Message())
+			assert.Equal(t, tt.expectedReason, Reason(tt.err))
+
+			if tt.expectedReason != "" {
+				require.Len(t, st.Details(), 1)
+				info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+				require.True(t, ok)
+				assert.Equal(t, Domain, info.Domain)
+			}
+		})
+	}
+
+	assert.NoError(t, GRPC(nil))
+	assert.Equal(t, codes.OK, Status(nil).Code())
+}
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expected       Problem
+	}{
+		{
+			name:           "wrong password",
+			err:            service.ErrInvalidCredentials,
+			expectedStatus: http.StatusUnauthorized,
+			expected: Problem{
+				Type:     "urn:auth-service:problem:invalid-credentials",
+				Title:    "Unauthorized",
+				Status:   http.StatusUnauthorized,
+				Detail:   "invalid credentials",
+				Instance: "/v1/auth/login",
+				Reason:   ReasonInvalidCredentials,
+			},
+		},
+		{
+			name:           "storage unavailable",
+			err:            fmt.Errorf("%w: connection refused", service.ErrUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+			expected: Problem{
+				Type:     "urn:auth-service:problem:service-unavailable",
+				Title:    "Service Unavailable",
+				Status:   http.StatusServiceUnavailable,
+				Detail:   "service temporarily unavailable, try again later",
+				Instance: "/v1/auth/login",
+				Reason:   ReasonUnavailable,
+			},
+		},
+		{
+			name:           "status without reason",
+			err:            &runtime.HTTPStatusError{HTTPStatus: http.StatusMethodNotAllowed, Err: status.Error(codes.Unimplemented, "Method Not Allowed")},
+			expectedStatus: http.StatusMethodNotAllowed,
+			expected: Problem{
+				Type:     "about:blank",
+				Title:    "Method Not Allowed",
+				Status:   http.StatusMethodNotAllowed,
+				Detail:   "Method Not Allowed",
+				Instance: "/v1/auth/login",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteProblem(rec, httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil), tt.err)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, ProblemContentType, rec.Header().Get("Content-Type"))
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expected, problem)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// ProblemContentType - тип ответа с ошибкой по RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix + причина в нижнем регистре через дефис - поле type ответа
+const problemTypePrefix = "urn:" + Domain + ":problem:"
+
+// Problem - тело ответа application/problem+json. Reason повторяет ErrorInfo.Reason ответа gRPC
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// NewProblem описывает ошибку для HTTP. Код ответа выводится из кода gRPC, как в REST gateway,
+// или берётся из runtime.HTTPStatusError. Ошибка без причины получает type "about:blank"
+func NewProblem(err error) Problem {
+	var httpErr *runtime.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		err = httpErr.Err
+	}
+
+	st := Status(err)
+	httpStatus := runtime.HTTPStatusFromCode(st.Code())
+	if httpErr != nil {
+		httpStatus = httpErr.HTTPStatus
+	}
+	reason := Reason(err)
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(httpStatus),
+		Status: httpStatus,
+		Detail: st.Message(),
+		Reason: reason,
+	}
+	if reason != "" {
+		problem.Type = problemTypePrefix + strings.ReplaceAll(strings.ToLower(reason), "_", "-")
+	}
+	if problem.Title == "" {
+		// 499 (Canceled) нет в net/http
+		problem.Title = st.Code().String()
+	}
+	return problem
+}
+
+// WriteProblem отвечает на запрос ошибкой err в формате application/problem+json
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := NewProblem(err)
+	problem.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,215 @@
+package auditchain
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const ManifestVersion = 1
+
+var ErrInvalidSignature = errors.New("invalid batch signature")
+
+// Manifest описывает выгруженный пакет записей. Подпись Ed25519 покрывает JSON манифеста
+// без поля signature, а манифест - SHA-256 файла с записями
+type Manifest struct {
+	Version  int    `json:"version"`
+	Tenant   string `json:"tenant"`
+	FirstSeq int64  `json:"first_seq"`
+	LastSeq  int64  `json:"last_seq"`
+	Count    int    `json:"count"`
+	// prev_hash первой записи: связывает пакет с предыдущим
+	PrevHash  string    `json:"prev_hash"`
+	LastHash  string    `json:"last_hash"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+	// Первые 8 байт SHA-256 открытого ключа в hex
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// BatchName - имя файла пакета без расширения; seq дополняется нулями, чтобы имена сортировались
+func BatchName(tenant string, firstSeq, lastSeq int64) string {
+	return fmt.Sprintf("audit-%s-%020d-%020d", tenant, firstSeq, lastSeq)
+}
+
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}
+}
+
+// LoadSigner читает закрытый ключ Ed25519 в PEM (PKCS#8), например из
+// openssl genpkey -algorithm ed25519 -out audit-signing.pem
+func LoadSigner(path string) (*Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key must be Ed25519, got %T", key)
+	}
+	return NewSigner(edKey), nil
+}
+
+// LoadPublicKey читает открытый ключ Ed25519 в PEM (PKIX), например из
+// openssl pkey -in audit-signing.pem -pubout
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key must be Ed25519, got %T", key)
+	}
+	return edKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
+
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// SignBatch кодирует записи одного тенанта в JSONL и подписывает манифест пакета
+func (s *Signer) SignBatch(records []Record, createdAt time.Time) ([]byte, *Manifest, error) {
+	if len(records) == 0 {
+		return nil, nil, errors.New("empty batch")
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	first, last := records[0], records[len(records)-1]
+	sum := sha256.Sum256(buf.Bytes())
+	manifest := &Manifest{
+		Version:   ManifestVersion,
+		Tenant:    first.Tenant,
+		FirstSeq:  first.Seq,
+		LastSeq:   last.Seq,
+		Count:     len(records),
+		PrevHash:  first.PrevHash,
+		LastHash:  last.Hash,
+		SHA256:    hex.EncodeToString(sum[:]),
+		CreatedAt: createdAt.UTC(),
+		KeyID:     s.keyID,
+	}
+
+	payload, err := signingPayload(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload))
+
+	return buf.Bytes(), manifest, nil
+}
+
+// VerifyBatch проверяет подпись манифеста, хеш файла и цепочку внутри пакета
+func VerifyBatch(data []byte, manifest *Manifest, key ed25519.PublicKey) ([]Record, error) {
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	payload, err := signingPayload(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(key, payload, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != manifest.SHA256 {
+		return nil, errors.New("batch file does not match the manifest sha256")
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(records) != manifest.Count {
+		return nil, fmt.Errorf("batch has %d records, manifest says %d", len(records), manifest.Count)
+	}
+
+	prev := Head{Seq: manifest.FirstSeq - 1, Hash: manifest.PrevHash}
+	for _, record := range records {
+		if record.Tenant != manifest.Tenant || record.Seq != prev.Seq+1 || record.PrevHash != prev.Hash {
+			return nil, fmt.Errorf("record seq %d does not continue the chain", record.Seq)
+		}
+		hash, err := ComputeHash(record)
+		if err != nil {
+			return nil, err
+		}
+		if hash != record.Hash {
+			return nil, fmt.Errorf("record seq %d: hash mismatch", record.Seq)
+		}
+		prev = Head{Seq: record.Seq, Hash: record.Hash}
+	}
+	if prev.Seq != manifest.LastSeq || prev.Hash != manifest.LastHash {
+		return nil, errors.New("last record does not match the manifest")
+	}
+
+	return records, nil
+}
+
+func signingPayload(manifest *Manifest) ([]byte, error) {
+	unsigned := *manifest
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
@@ -0,0 +1,97 @@
+package auditchain
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerifyBatch(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer := NewSigner(key)
+
+	records := buildChain(t, Head{Seq: 10, Hash: "previous-batch"}, 3)
+	data, manifest, err := signer.SignBatch(records, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), manifest.FirstSeq)
+	assert.Equal(t, int64(13), manifest.LastSeq)
+	assert.Equal(t, "previous-batch", manifest.PrevHash)
+	assert.Equal(t, KeyID(signer.PublicKey()), manifest.KeyID)
+
+	verified, err := VerifyBatch(data, manifest, signer.PublicKey())
+	require.NoError(t, err)
+	require.Len(t, verified, 3)
+	assert.Equal(t, records[2].Hash, verified[2].Hash)
+	assert.True(t, records[0].OccurredAt.Equal(verified[0].OccurredAt))
+
+	t.Run("tampered record", func(t *testing.T) {
+		tampered := bytes.Replace(data, []byte(`"subject":"u1"`), []byte(`"subject":"mallory"`), 1)
+		_, err := VerifyBatch(tampered, manifest, signer.PublicKey())
+		assert.ErrorContains(t, err, "sha256")
+	})
+
+	t.Run("tampered manifest", func(t *testing.T) {
+		changed := *manifest
+		changed.LastSeq = 12
+		_, err := VerifyBatch(data, &changed, signer.PublicKey())
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		_, err = VerifyBatch(data, manifest, other)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("broken chain signed by the key", func(t *testing.T) {
+		broken := append([]Record{records[0]}, records[2])
+		data, manifest, err := signer.SignBatch(broken, time.Now())
+		require.NoError(t, err)
+		_, err = VerifyBatch(data, manifest, signer.PublicKey())
+		assert.ErrorContains(t, err, "does not continue the chain")
+	})
+
+	_, _, err = signer.SignBatch(nil, time.Now())
+	assert.Error(t, err)
+}
+
+func TestLoadKeys(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	rawPrivate, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	privatePath := filepath.Join(dir, "signing.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rawPrivate}), 0o600))
+
+	rawPublic, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "signing.pub.pem")
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rawPublic}), 0o600))
+
+	signer, err := LoadSigner(privatePath)
+	require.NoError(t, err)
+	loaded, err := LoadPublicKey(publicPath)
+	require.NoError(t, err)
+	assert.Equal(t, public, loaded)
+	assert.Equal(t, public, signer.PublicKey())
+
+	_, err = LoadSigner(publicPath)
+	assert.Error(t, err)
+
+	garbage := filepath.Join(dir, "garbage.pem")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a key"), 0o600))
+	_, err = LoadPublicKey(garbage)
+	assert.ErrorContains(t, err, "no PEM data")
+}
@@ -0,0 +1,121 @@
+// Package auditchain делает журнал аудита проверяемым: каждая запись содержит хеш предыдущей
+// записи своего тенанта, поэтому удаление или изменение записи разрывает цепочку.
+// Пакет также подписывает выгружаемые пакеты записей и отправляет их во внешнее хранилище
+package auditchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultTenant - цепочка развёртывания, для которого тенант не задан
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidTenant проверяет имя тенанта: оно входит в имена файлов выгрузки
+func ValidTenant(tenant string) bool {
+	return tenantPattern.MatchString(tenant)
+}
+
+// TimeFormat - точность occurred_at, входящая в хеш. Хранилища округляют время до миллисекунд
+// перед записью, чтобы хеш совпадал после чтения из любой базы
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// Record - поля записи аудита, покрываемые хешем
+type Record struct {
+	ID         int64     `json:"id"`
+	Tenant     string    `json:"tenant"`
+	Seq        int64     `json:"seq"`
+	OccurredAt time.Time `json:"occurred_at"`
+	EventType  string    `json:"event_type"`
+	Outcome    string    `json:"outcome"`
+	Actor      string    `json:"actor"`
+	Subject    string    `json:"subject"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id"`
+	// JSON-объект; в хеш входит в канонической форме (ключи по алфавиту), так как JSONB
+	// в Postgres не сохраняет исходный порядок ключей и пробелы
+	Metadata json.RawMessage `json:"metadata"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// Head - последняя запись цепочки тенанта
+type Head struct {
+	Seq  int64
+	Hash string
+}
+
+// Truncate округляет время события до точности, входящей в хеш
+func Truncate(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+// Seal заполняет Seq, PrevHash и Hash записи, добавляемой после head
+func Seal(r *Record, head Head) error {
+	r.Seq = head.Seq + 1
+	r.PrevHash = head.Hash
+	r.OccurredAt = Truncate(r.OccurredAt)
+
+	hash, err := ComputeHash(*r)
+	if err != nil {
+		return err
+	}
+	r.Hash = hash
+	return nil
+}
+
+// ComputeHash - SHA-256 от полей записи, включая хеш предыдущей записи. ID не входит в хеш:
+// он назначается базой и не переносится при выгрузке
+func ComputeHash(r Record) (string, error) {
+	metadata, err := canonicalJSON(r.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("record %d: %w", r.Seq, err)
+	}
+
+	h := sha256.New()
+	for _, field := range []string{
+		r.Tenant,
+		strconv.FormatInt(r.Seq, 10),
+		r.PrevHash,
+		r.OccurredAt.UTC().Format(TimeFormat),
+		r.EventType,
+		r.Outcome,
+		r.Actor,
+		r.Subject,
+		r.IP,
+		r.UserAgent,
+		r.RequestID,
+		string(metadata),
+	} {
+		// Длина перед каждым полем: значения не могут "перетечь" в соседнее поле
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+		h.Write(size[:])
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func canonicalJSON(raw json.RawMessage) ([]byte, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return []byte("{}"), nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	return json.Marshal(value)
+}
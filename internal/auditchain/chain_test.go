@@ -0,0 +1,189 @@
+package auditchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildChain возвращает n связанных записей тенанта acme, начиная после head
+func buildChain(t *testing.T, head Head, n int) []Record {
+	t.Helper()
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	records := make([]Record, 0, n)
+	for i := 0; i < n; i++ {
+		r := Record{
+			ID:         head.Seq + 1,
+			Tenant:     "acme",
+			OccurredAt: base.Add(time.Duration(head.Seq) * time.Minute),
+			EventType:  "user.login",
+			Outcome:    "success",
+			Actor:      fmt.Sprintf("user:u%d", i),
+			Subject:    fmt.Sprintf("u%d", i),
+			Metadata:   json.RawMessage(`{"b":"2","a":"1"}`),
+		}
+		require.NoError(t, Seal(&r, head))
+		records = append(records, r)
+		head = Head{Seq: r.Seq, Hash: r.Hash}
+	}
+	return records
+}
+
+func verify(records []Record, head *Head) *Report {
+	v := NewVerifier("acme")
+	for _, r := range records {
+		v.Add(r)
+	}
+	return v.Finish(head)
+}
+
+func headOf(records []Record) *Head {
+	last := records[len(records)-1]
+	return &Head{Seq: last.Seq, Hash: last.Hash}
+}
+
+func TestSeal(t *testing.T) {
+	records := buildChain(t, Head{}, 2)
+
+	assert.Equal(t, int64(1), records[0].Seq)
+	assert.Empty(t, records[0].PrevHash)
+	assert.Equal(t, records[0].Hash, records[1].PrevHash)
+	assert.Equal(t, 123000000, records[0].OccurredAt.Nanosecond(), "time is truncated to milliseconds")
+
+	// Порядок ключей и пробелы в metadata не влияют на хеш (JSONB их не сохраняет)
+	reordered := records[0]
+	reordered.Metadata = json.RawMessage(`{ "a": "1", "b": "2" }`)
+	hash, err := ComputeHash(reordered)
+	require.NoError(t, err)
+	assert.Equal(t, records[0].Hash, hash)
+
+	// ID назначается базой и не входит в хеш
+	reordered.ID = 100
+	hash, err = ComputeHash(reordered)
+	require.NoError(t, err)
+	assert.Equal(t, records[0].Hash, hash)
+
+	// Значение не может перетечь в соседнее поле
+	shifted := records[0]
+	shifted.Actor, shifted.Subject = records[0].Actor+records[0].Subject[:1], records[0].Subject[1:]
+	hash, err = ComputeHash(shifted)
+	require.NoError(t, err)
+	assert.NotEqual(t, records[0].Hash, hash)
+
+	invalid := records[0]
+	invalid.Metadata = json.RawMessage(`{`)
+	_, err = ComputeHash(invalid)
+	assert.Error(t, err)
+}
+
+func TestVerifier(t *testing.T) {
+	anchored := func(t *testing.T) []Record {
+		chain := buildChain(t, Head{}, 5)
+		purge := Record{
+			Tenant:     "acme",
+			OccurredAt: time.Now(),
+			EventType:  PurgeEventType,
+			Outcome:    "success",
+			Metadata: mustJSON(t, map[string]string{
+				AnchorSeqKey:  strconv.FormatInt(chain[2].Seq, 10),
+				AnchorHashKey: chain[2].Hash,
+			}),
+		}
+		require.NoError(t, Seal(&purge, Head{Seq: chain[4].Seq, Hash: chain[4].Hash}))
+		// Удалены записи 1-3
+		return append(chain[3:], purge)
+	}
+
+	tests := []struct {
+		name   string
+		build  func(t *testing.T) ([]Record, *Head)
+		breaks []int64
+	}{
+		{"intact", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 5)
+			return chain, headOf(chain)
+		}, nil},
+		{"modified record", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 5)
+			chain[2].Subject = "mallory"
+			return chain, headOf(chain)
+		}, []int64{3}},
+		{"modified and rehashed record", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 5)
+			chain[2].Subject = "mallory"
+			chain[2].Hash, _ = ComputeHash(chain[2])
+			return chain, headOf(chain)
+		}, []int64{4}},
+		{"deleted middle record", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 5)
+			return append(chain[:2:2], chain[3:]...), headOf(chain)
+		}, []int64{4}},
+		{"deleted trailing records", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 5)
+			return chain[:3], headOf(chain)
+		}, []int64{5}},
+		{"deleted chain start without anchor", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 5)
+			return chain[2:], headOf(chain)
+		}, []int64{3}},
+		{"purge with anchor", func(t *testing.T) ([]Record, *Head) {
+			chain := anchored(t)
+			return chain, headOf(chain)
+		}, nil},
+		{"anchor does not cover deleted records", func(t *testing.T) ([]Record, *Head) {
+			chain := anchored(t)
+			return chain[1:], headOf(chain)
+		}, []int64{5}},
+		{"legacy records before the chain", func(t *testing.T) ([]Record, *Head) {
+			legacy := []Record{{Tenant: "acme", Seq: 1}, {Tenant: "acme", Seq: 2}}
+			chain := buildChain(t, Head{Seq: 2}, 3)
+			return append(legacy, chain...), headOf(chain)
+		}, nil},
+		{"unsealed record inside the chain", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 3)
+			chain = append(chain, Record{Tenant: "acme", Seq: 4})
+			return chain, &Head{Seq: 4}
+		}, []int64{4}},
+		{"record of another tenant", func(t *testing.T) ([]Record, *Head) {
+			chain := buildChain(t, Head{}, 2)
+			chain[1].Tenant = "other"
+			return chain, headOf(chain)
+		}, []int64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, head := tt.build(t)
+			report := verify(records, head)
+
+			var breaks []int64
+			for _, b := range report.Breaks {
+				breaks = append(breaks, b.Seq)
+			}
+			assert.Equal(t, tt.breaks, breaks, "%+v", report.Breaks)
+			assert.Equal(t, tt.breaks == nil, report.OK())
+			assert.Equal(t, int64(len(records)), report.Checked)
+		})
+	}
+}
+
+func TestValidTenant(t *testing.T) {
+	assert.True(t, ValidTenant(DefaultTenant))
+	assert.True(t, ValidTenant("acme-prod_1"))
+	assert.False(t, ValidTenant(""))
+	assert.False(t, ValidTenant("Acme"))
+	assert.False(t, ValidTenant("../etc"))
+	assert.False(t, ValidTenant("-acme"))
+}
+
+func mustJSON(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,207 @@
+package auditchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sink сохраняет выгруженные пакеты
+type Sink interface {
+	Put(ctx context.Context, name string, data []byte, contentType string) error
+}
+
+// S3Config - доступ к S3-совместимому хранилищу (AWS S3, MinIO, Ceph RGW)
+type S3Config struct {
+	// Например https://s3.eu-central-1.amazonaws.com или http://minio:9000
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// NewSink создаёт приёмник по адресу: путь к каталогу (или file:///path) либо s3://bucket/prefix
+func NewSink(target string, s3 S3Config) (Sink, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" {
+		return NewFileSink(target), nil
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewFileSink(u.Path), nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("s3 sink %q: bucket is required", target)
+		}
+		return NewS3Sink(s3, u.Host, strings.Trim(u.Path, "/"))
+	default:
+		return nil, fmt.Errorf("unsupported sink %q, expected a directory or s3://bucket/prefix", target)
+	}
+}
+
+// FileSink пишет пакеты в локальный каталог
+type FileSink struct {
+	dir string
+}
+
+func NewFileSink(dir string) *FileSink {
+	return &FileSink{dir: dir}
+}
+
+func (s *FileSink) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	// Запись через временный файл: читатель каталога не увидит недописанный пакет
+	tmp, err := os.CreateTemp(s.dir, "."+name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
+
+// S3Sink загружает пакеты запросом PutObject с подписью AWS Signature V4 (path-style адреса)
+type S3Sink struct {
+	cfg    S3Config
+	bucket string
+	prefix string
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Sink(cfg S3Config, bucket, prefix string) (*S3Sink, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("s3 endpoint is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("s3 access key and secret key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &S3Sink{
+		cfg:    cfg,
+		bucket: bucket,
+		prefix: prefix,
+		client: &http.Client{Timeout: time.Minute},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *S3Sink) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	key := name
+	if s.prefix != "" {
+		key = s.prefix + "/" + name
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(s.cfg.Endpoint, "/"))
+	if err != nil {
+		return fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	endpoint.Path = path.Join("/", endpoint.Path, s.bucket, key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	sum := sha256.Sum256(data)
+	signV4(req, hex.EncodeToString(sum[:]), s.cfg, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put %s: %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signV4 добавляет x-amz-date, x-amz-content-sha256 и Authorization. Подписываются host
+// и все заголовки, уже заданные в запросе
+func signV4(req *http.Request, payloadHash string, cfg S3Config, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+cfg.SecretKey), date)
+	key = hmacSHA256(key, cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,137 @@
+package auditchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Событие, которое сервис записывает перед удалением старых записей по сроку хранения.
+// Оно фиксирует хеш последней удаляемой записи, и цепочка остаётся проверяемой без неё
+const (
+	PurgeEventType = "audit.purge"
+	AnchorSeqKey   = "anchor_seq"
+	AnchorHashKey  = "anchor_hash"
+)
+
+// Break - место, где цепочка нарушена
+type Break struct {
+	Seq    int64  `json:"seq"`
+	ID     int64  `json:"id"`
+	Reason string `json:"reason"`
+}
+
+type Report struct {
+	Tenant string `json:"tenant"`
+	// Проверено записей, в том числе без хеша
+	Checked int64 `json:"checked"`
+	// Записи, созданные до включения цепочки (без хеша)
+	Unsealed int64   `json:"unsealed"`
+	FirstSeq int64   `json:"first_seq"`
+	LastSeq  int64   `json:"last_seq"`
+	LastHash string  `json:"last_hash"`
+	Breaks   []Break `json:"breaks"`
+}
+
+func (r *Report) OK() bool {
+	return len(r.Breaks) == 0
+}
+
+// Verifier проверяет записи одного тенанта, переданные по возрастанию seq
+type Verifier struct {
+	report Report
+	// Последняя запись с хешем
+	prev *Record
+	// Первая запись с хешем: её prev_hash проверяется в Finish
+	first *Record
+	// anchor_hash -> anchor_seq из событий audit.purge
+	anchors map[string]int64
+}
+
+func NewVerifier(tenant string) *Verifier {
+	return &Verifier{
+		report:  Report{Tenant: tenant},
+		anchors: make(map[string]int64),
+	}
+}
+
+func (v *Verifier) Add(r Record) {
+	v.report.Checked++
+	if v.report.FirstSeq == 0 {
+		v.report.FirstSeq = r.Seq
+	}
+	v.report.LastSeq = r.Seq
+	v.report.LastHash = r.Hash
+
+	if r.Tenant != v.report.Tenant {
+		v.fail(r, fmt.Sprintf("record belongs to tenant %q", r.Tenant))
+		return
+	}
+
+	if r.Hash == "" {
+		v.report.Unsealed++
+		if v.prev != nil {
+			v.fail(r, "record without hash after the start of the chain")
+		}
+		return
+	}
+
+	if hash, err := ComputeHash(r); err != nil {
+		v.fail(r, err.Error())
+	} else if hash != r.Hash {
+		v.fail(r, "hash mismatch: record was modified")
+	}
+
+	switch {
+	case v.prev == nil:
+		first := r
+		v.first = &first
+	case r.Seq != v.prev.Seq+1:
+		v.fail(r, fmt.Sprintf("sequence gap: expected seq %d, records were deleted", v.prev.Seq+1))
+	case r.PrevHash != v.prev.Hash:
+		v.fail(r, "prev_hash does not match the previous record")
+	}
+
+	if r.EventType == PurgeEventType {
+		v.addAnchor(r)
+	}
+
+	prev := r
+	v.prev = &prev
+}
+
+// Finish завершает проверку. head - последняя запись по таблице голов цепочек (nil, если её нет);
+// расхождение с ней означает, что удалены записи в конце цепочки
+func (v *Verifier) Finish(head *Head) *Report {
+	if v.first != nil && v.first.PrevHash != "" {
+		if seq, ok := v.anchors[v.first.PrevHash]; !ok || seq != v.first.Seq-1 {
+			v.fail(*v.first, "chain start is missing: earlier records were deleted without a retention anchor")
+		}
+	}
+
+	if head != nil && (head.Seq != v.report.LastSeq || head.Hash != v.report.LastHash) {
+		v.report.Breaks = append(v.report.Breaks, Break{
+			Seq:    head.Seq,
+			Reason: fmt.Sprintf("chain head is seq %d, last record is seq %d: trailing records were deleted or modified", head.Seq, v.report.LastSeq),
+		})
+	}
+
+	report := v.report
+	return &report
+}
+
+func (v *Verifier) addAnchor(r Record) {
+	var metadata map[string]string
+	if err := json.Unmarshal(r.Metadata, &metadata); err != nil {
+		return
+	}
+	seq, err := strconv.ParseInt(metadata[AnchorSeqKey], 10, 64)
+	if err != nil || metadata[AnchorHashKey] == "" {
+		return
+	}
+	v.anchors[metadata[AnchorHashKey]] = seq
+}
+
+func (v *Verifier) fail(r Record, reason string) {
+	v.report.Breaks = append(v.report.Breaks, Break{Seq: r.Seq, ID: r.ID, Reason: reason})
+}
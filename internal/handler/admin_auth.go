@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/logging"
+	"auth_test/internal/service"
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const AdminServicePrefix = "/auth.AdminService/"
+
+// AdminInterceptor пропускает к методам AdminService только вызовы с access токеном роли admin
+// или с проверенным клиентским сертификатом. Каждый вызов (в том числе отклонённый) попадает в лог;
+// изменения пользователей записываются в журнал аудита от имени actor
+func AdminInterceptor(userService service.UserService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, AdminServicePrefix) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+
+		actor, err := authorizeAdmin(ctx, userService)
+		if err != nil {
+			auditAdminCall(ctx, actor, info.FullMethod, err, time.Since(start))
+			return nil, err
+		}
+
+		resp, err := handler(service.WithActor(ctx, actor), req)
+		auditAdminCall(ctx, actor, info.FullMethod, err, time.Since(start))
+
+		return resp, err
+	}
+}
+
+// AdminStreamInterceptor - AdminInterceptor для потоковых методов (импорт и экспорт пользователей)
+func AdminStreamInterceptor(userService service.UserService) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, AdminServicePrefix) {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		ctx := ss.Context()
+
+		actor, err := authorizeAdmin(ctx, userService)
+		if err != nil {
+			auditAdminCall(ctx, actor, info.FullMethod, err, time.Since(start))
+			return err
+		}
+
+		err = handler(srv, &contextServerStream{
+			ServerStream: ss,
+			ctx:          service.WithActor(ctx, actor),
+		})
+		auditAdminCall(ctx, actor, info.FullMethod, err, time.Since(start))
+
+		return err
+	}
+}
+
+// contextServerStream подменяет контекст потока
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func authorizeAdmin(ctx context.Context, userService service.UserService) (string, error) {
+	if identity, ok := ClientCertIdentity(ctx); ok {
+		return "mtls:" + identity, nil
+	}
+
+	token, ok := bearerTokenFromMetadata(ctx)
+	if !ok {
+		return "anonymous", apierror.New(codes.Unauthenticated, apierror.ReasonMissingToken, "missing bearer token")
+	}
+
+	claims, err := userService.ParseAccessToken(ctx, token)
+	if err != nil {
+		return "anonymous", tokenError(err, "invalid access token")
+	}
+
+	actor := "user:" + claims.Username
+	if claims.Role != service.RoleAdmin {
+		return actor, apierror.New(codes.PermissionDenied, apierror.ReasonPermissionDenied, "admin role required")
+	}
+
+	return actor, nil
+}
+
+func bearerTokenFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(parts[1])
+	return token, token != ""
+}
+
+func auditAdminCall(ctx context.Context, actor, fullMethod string, err error, duration time.Duration) {
+	logging.FromContext(ctx).Info("Admin call",
+		"audit", true,
+		"actor", actor,
+		"method", extractMethodName(fullMethod),
+		"code", status.Code(err).String(),
+		"duration", duration)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		fullMethod    string
+		ctx           context.Context
+		token         string
+		claims        *service.TokenClaims
+		parseErr      error
+		expectedCode  codes.Code
+		expectedActor string
+	}{
+		{
+			name:         "public method is not checked",
+			fullMethod:   "/auth.AuthService/Login",
+			ctx:          context.Background(),
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "missing token",
+			fullMethod:   "/auth.AdminService/ListUsers",
+			ctx:          context.Background(),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "invalid token",
+			fullMethod:   "/auth.AdminService/ListUsers",
+			ctx:          metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer bad-token")),
+			token:        "bad-token",
+			parseErr:     service.ErrInvalidToken,
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "non-admin role",
+			fullMethod:   "/auth.AdminService/DeleteUser",
+			ctx:          metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer user-token")),
+			token:        "user-token",
+			claims:       &service.TokenClaims{Username: "user", Role: service.RoleUser},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:          "admin role",
+			fullMethod:    "/auth.AdminService/DeleteUser",
+			ctx:           metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer admin-token")),
+			token:         "admin-token",
+			claims:        &service.TokenClaims{Username: "admin", Role: service.RoleAdmin},
+			expectedCode:  codes.OK,
+			expectedActor: "user:admin",
+		},
+		{
+			name:          "mtls identity",
+			fullMethod:    "/auth.AdminService/ListUsers",
+			ctx:           contextWithClientCert("ops"),
+			expectedCode:  codes.OK,
+			expectedActor: "mtls:ops",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := service.NewMockUserService(ctrl)
+			if tt.token != "" {
+				mockService.EXPECT().ParseAccessToken(gomock.Any(), tt.token).Return(tt.claims, tt.parseErr)
+			}
+
+			var actor string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				actor = service.ActorFromContext(ctx)
+				return "ok", nil
+			}
+
+			interceptor := AdminInterceptor(mockService)
+			resp, err := interceptor(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.fullMethod}, handler)
+
+			if tt.expectedCode == codes.OK {
+				require.NoError(t, err)
+				assert.Equal(t, "ok", resp)
+				assert.Equal(t, tt.expectedActor, actor)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
+
+func TestAdminStreamInterceptor_ClientCert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	interceptor := AdminStreamInterceptor(service.NewMockUserService(ctrl))
+	info := &grpc.StreamServerInfo{FullMethod: "/auth.AdminService/ImportUsers"}
+
+	var actor string
+	err := interceptor(nil, &testServerStream{ctx: contextWithClientCert("ops")}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		actor = service.ActorFromContext(stream.Context())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "mtls:ops", actor)
+}
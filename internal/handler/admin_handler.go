@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"bufio"
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AdminGRPCHandler struct {
+	pb.UnimplementedAdminServiceServer
+	adminService service.AdminService
+}
+
+func NewAdminGRPCHandler(adminService service.AdminService) *AdminGRPCHandler {
+	return &AdminGRPCHandler{
+		adminService: adminService,
+	}
+}
+
+func (h *AdminGRPCHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	filter := service.UserFilter{
+		Username:  req.UsernameFilter,
+		Role:      req.Role,
+		Status:    req.Status,
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+
+	users, nextPageToken, err := h.adminService.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	resp := &pb.ListUsersResponse{
+		Users:         make([]*pb.User, 0, len(users)),
+		NextPageToken: nextPageToken,
+	}
+	for i := range users {
+		resp.Users = append(resp.Users, toPBUser(&users[i]))
+	}
+
+	return resp, nil
+}
+
+func (h *AdminGRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if req.Username == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "username is required")
+	}
+
+	user, err := h.adminService.GetUser(ctx, req.Username)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.GetUserResponse{User: toPBUser(user)}, nil
+}
+
+func (h *AdminGRPCHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := h.adminService.CreateUser(ctx, req.Username, req.Password, req.Role); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.CreateUserResponse{Message: "User created"}, nil
+}
+
+func (h *AdminGRPCHandler) DisableUser(ctx context.Context, req *pb.DisableUserRequest) (*pb.DisableUserResponse, error) {
+	if req.Username == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "username is required")
+	}
+
+	if err := h.adminService.DisableUser(ctx, req.Username, req.Reason); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.DisableUserResponse{Message: "User disabled"}, nil
+}
+
+func (h *AdminGRPCHandler) EnableUser(ctx context.Context, req *pb.EnableUserRequest) (*pb.EnableUserResponse, error) {
+	if req.Username == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "username is required")
+	}
+
+	if err := h.adminService.EnableUser(ctx, req.Username, req.Reason); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.EnableUserResponse{Message: "User enabled"}, nil
+}
+
+func (h *AdminGRPCHandler) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "username is required")
+	}
+
+	if err := h.adminService.DeleteUser(ctx, req.Username, req.Reason); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.DeleteUserResponse{Message: "User deleted"}, nil
+}
+
+// exportChunkSize - размер части выгрузки в одном сообщении потока
+const exportChunkSize = 32 * 1024
+
+func (h *AdminGRPCHandler) ImportUsers(stream grpc.ClientStreamingServer[pb.ImportUsersRequest, pb.ImportUsersResponse]) error {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "empty import")
+	}
+	if err != nil {
+		return err
+	}
+
+	reader := &importStreamReader{stream: stream, buf: first.Chunk}
+	result, err := h.adminService.ImportUsers(stream.Context(), reader, first.Format)
+	if err != nil {
+		if reader.err != nil {
+			return reader.err
+		}
+		return apierror.GRPC(err)
+	}
+
+	resp := &pb.ImportUsersResponse{
+		Total:    int32(result.Total),
+		Imported: int32(result.Imported),
+		Errors:   make([]*pb.ImportRowError, 0, len(result.Errors)),
+	}
+	for _, rowErr := range result.Errors {
+		resp.Errors = append(resp.Errors, &pb.ImportRowError{
+			Line:     int32(rowErr.Line),
+			Username: rowErr.Username,
+			Message:  rowErr.Err.Error(),
+		})
+	}
+
+	return stream.SendAndClose(resp)
+}
+
+// importStreamReader читает части файла из потока по мере разбора
+type importStreamReader struct {
+	stream grpc.ClientStreamingServer[pb.ImportUsersRequest, pb.ImportUsersResponse]
+	buf    []byte
+	// Ошибка потока (не io.EOF), которую нужно вернуть клиенту как есть
+	err error
+}
+
+func (r *importStreamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				r.err = err
+			}
+			return 0, err
+		}
+		r.buf = req.Chunk
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (h *AdminGRPCHandler) ExportUsers(req *pb.ExportUsersRequest, stream grpc.ServerStreamingServer[pb.ExportUsersResponse]) error {
+	out := bufio.NewWriterSize(exportStreamWriter{stream: stream}, exportChunkSize)
+
+	if _, err := h.adminService.ExportUsers(stream.Context(), out, req.Format); err != nil {
+		return apierror.GRPC(err)
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type exportStreamWriter struct {
+	stream grpc.ServerStreamingServer[pb.ExportUsersResponse]
+}
+
+func (w exportStreamWriter) Write(p []byte) (int, error) {
+	// Буфер переиспользуется bufio.Writer, поэтому отправляем копию
+	if err := w.stream.Send(&pb.ExportUsersResponse{Chunk: append([]byte(nil), p...)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (h *AdminGRPCHandler) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	filter := service.AuditFilter{
+		EventType: req.EventType,
+		Subject:   req.Subject,
+		Actor:     req.Actor,
+		Outcome:   req.Outcome,
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		filter.Until = req.Until.AsTime()
+	}
+
+	events, nextPageToken, err := h.adminService.ListAuditEvents(ctx, filter)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	resp := &pb.ListAuditEventsResponse{
+		Events:        make([]*pb.AuditEvent, 0, len(events)),
+		NextPageToken: nextPageToken,
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &pb.AuditEvent{
+			Id:         event.ID,
+			OccurredAt: timestamppb.New(event.OccurredAt),
+			EventType:  event.EventType,
+			Outcome:    event.Outcome,
+			Actor:      event.Actor,
+			Subject:    event.Subject,
+			Ip:         event.IP,
+			UserAgent:  event.UserAgent,
+			RequestId:  event.RequestID,
+			Metadata:   event.Metadata,
+			Tenant:     event.Tenant,
+			Seq:        event.Seq,
+			PrevHash:   event.PrevHash,
+			Hash:       event.Hash,
+		})
+	}
+
+	return resp, nil
+}
+
+func toPBUser(user *service.UserInfo) *pb.User {
+	pbUser := &pb.User{
+		Id:              user.ID,
+		Username:        user.Username,
+		Role:            user.Role,
+		Status:          user.Status,
+		StatusReason:    user.StatusReason,
+		StatusChangedAt: timestamppb.New(user.StatusChangedAt),
+		CreatedAt:       timestamppb.New(user.CreatedAt),
+		UpdatedAt:       timestamppb.New(user.UpdatedAt),
+	}
+	if user.DeletedAt != nil {
+		pbUser.DeletedAt = timestamppb.New(*user.DeletedAt)
+	}
+	return pbUser
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminHandler_ListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mockService := service.NewMockAdminService(ctrl)
+	mockService.EXPECT().ListUsers(gomock.Any(), service.UserFilter{
+		Username:  "adm",
+		Role:      service.RoleAdmin,
+		Status:    service.StatusDisabled,
+		PageSize:  10,
+		PageToken: "token",
+	}).Return([]service.UserInfo{
+		{ID: 1, Username: "admin", Role: service.RoleAdmin, Status: service.StatusDisabled, StatusReason: "left company", CreatedAt: createdAt, UpdatedAt: createdAt},
+	}, "next", nil)
+
+	handler := NewAdminGRPCHandler(mockService)
+	resp, err := handler.ListUsers(context.Background(), &pb.ListUsersRequest{
+		PageSize:       10,
+		PageToken:      "token",
+		UsernameFilter: "adm",
+		Role:           service.RoleAdmin,
+		Status:         service.StatusDisabled,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Users, 1)
+	assert.Equal(t, "admin", resp.Users[0].Username)
+	assert.Equal(t, service.StatusDisabled, resp.Users[0].Status)
+	assert.Equal(t, "left company", resp.Users[0].StatusReason)
+	assert.Nil(t, resp.Users[0].DeletedAt)
+	assert.Equal(t, createdAt, resp.Users[0].CreatedAt.AsTime())
+	assert.Equal(t, "next", resp.NextPageToken)
+}
+
+func TestAdminHandler_ErrorMapping(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockErr      error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "user not found",
+			mockErr:      service.ErrUserNotFound,
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "database failure",
+			mockErr:      errors.New("connection refused"),
+			expectedCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := service.NewMockAdminService(ctrl)
+			mockService.EXPECT().DisableUser(gomock.Any(), "user", "abuse").Return(tt.mockErr)
+
+			handler := NewAdminGRPCHandler(mockService)
+			_, err := handler.DisableUser(context.Background(), &pb.DisableUserRequest{Username: "user", Reason: "abuse"})
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
+
+func TestAdminHandler_CreateUserConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockAdminService(ctrl)
+	mockService.EXPECT().CreateUser(gomock.Any(), "admin", "secret", service.RoleAdmin).Return(service.ErrUserAlreadyExists)
+
+	handler := NewAdminGRPCHandler(mockService)
+	_, err := handler.CreateUser(context.Background(), &pb.CreateUserRequest{Username: "admin", Password: "secret", Role: service.RoleAdmin})
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.AlreadyExists, st.Code())
+}
+
+func TestAdminHandler_MissingUsername(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewAdminGRPCHandler(service.NewMockAdminService(ctrl))
+	_, err := handler.DeleteUser(context.Background(), &pb.DeleteUserRequest{})
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/internal/store"
+	"auth_test/pkg/pb"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAdminHandler_ListAuditEvents(t *testing.T) {
+	client := startTestAdminServer(t, service.NewAdminService(store.NewInMemoryStore()))
+	ctx := adminContext()
+
+	_, err := client.CreateUser(ctx, &pb.CreateUserRequest{Username: "alice", Password: "secret123"})
+	require.NoError(t, err)
+	_, err = client.DisableUser(ctx, &pb.DisableUserRequest{Username: "alice", Reason: "offboarding"})
+	require.NoError(t, err)
+	_, err = client.DisableUser(ctx, &pb.DisableUserRequest{Username: "ghost"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	resp, err := client.ListAuditEvents(ctx, &pb.ListAuditEventsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 2)
+	require.NotEmpty(t, resp.NextPageToken)
+
+	failed := resp.Events[0]
+	assert.Equal(t, service.AuditAdminUserDisable, failed.EventType)
+	assert.Equal(t, service.OutcomeFailure, failed.Outcome)
+	assert.Equal(t, "ghost", failed.Subject)
+	assert.Equal(t, "user not found", failed.Metadata["reason"])
+
+	disabled := resp.Events[1]
+	assert.Equal(t, "user:admin", disabled.Actor)
+	assert.Equal(t, "127.0.0.1", disabled.Ip)
+	assert.Contains(t, disabled.UserAgent, "grpc-go")
+	assert.NotEmpty(t, disabled.RequestId)
+	assert.Equal(t, "offboarding", disabled.Metadata["status_reason"])
+
+	next, err := client.ListAuditEvents(ctx, &pb.ListAuditEventsRequest{PageToken: resp.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, next.Events, 1)
+	assert.Equal(t, service.AuditAdminUserCreate, next.Events[0].EventType)
+	assert.Empty(t, next.NextPageToken)
+
+	tests := []struct {
+		name         string
+		req          *pb.ListAuditEventsRequest
+		expected     int
+		expectedCode codes.Code
+	}{
+		{name: "by outcome", req: &pb.ListAuditEventsRequest{Outcome: service.OutcomeSuccess}, expected: 2},
+		{name: "by subject", req: &pb.ListAuditEventsRequest{Subject: "alice", EventType: service.AuditAdminUserCreate}, expected: 1},
+		{name: "by actor", req: &pb.ListAuditEventsRequest{Actor: "user:someone"}, expected: 0},
+		{name: "since", req: &pb.ListAuditEventsRequest{Since: timestamppb.New(time.Now().Add(time.Hour))}, expected: 0},
+		{name: "until", req: &pb.ListAuditEventsRequest{Until: timestamppb.New(time.Now().Add(time.Hour))}, expected: 3},
+		{name: "invalid outcome", req: &pb.ListAuditEventsRequest{Outcome: "unknown"}, expectedCode: codes.InvalidArgument},
+		{name: "invalid page token", req: &pb.ListAuditEventsRequest{PageToken: "%%%"}, expectedCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.ListAuditEvents(ctx, tt.req)
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, resp.Events, tt.expected)
+		})
+	}
+
+	_, err = client.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestClientInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		peerAddr string
+		md       metadata.MD
+		expected service.ClientInfo
+	}{
+		{
+			name:     "direct grpc client",
+			peerAddr: "198.51.100.4:51234",
+			md:       metadata.Pairs("user-agent", "grpc-go/1.76.0", "x-forwarded-for", "10.0.0.1"),
+			expected: service.ClientInfo{IP: "198.51.100.4", UserAgent: "grpc-go/1.76.0"},
+		},
+		{
+			name:     "rest gateway",
+			peerAddr: "127.0.0.1:40000",
+			md: metadata.Pairs(
+				"user-agent", "grpc-go/1.76.0",
+				"grpcgateway-user-agent", "curl/8.0",
+				"x-forwarded-for", "10.9.9.9, 203.0.113.7",
+				gatewaySecretKey, gatewaySecret,
+			),
+			expected: service.ClientInfo{IP: "203.0.113.7", UserAgent: "curl/8.0"},
+		},
+		{
+			name:     "gateway with malformed forwarded address",
+			peerAddr: "127.0.0.1:40000",
+			md:       metadata.Pairs("x-forwarded-for", "not-an-ip", gatewaySecretKey, gatewaySecret),
+			expected: service.ClientInfo{IP: "127.0.0.1"},
+		},
+		{
+			// Локальный процесс или sidecar без секрета не может подменить адрес
+			name:     "loopback client without gateway secret",
+			peerAddr: "127.0.0.1:40000",
+			md:       metadata.Pairs("x-forwarded-for", "203.0.113.7"),
+			expected: service.ClientInfo{IP: "127.0.0.1"},
+		},
+		{
+			name:     "forged gateway secret",
+			peerAddr: "127.0.0.1:40000",
+			md:       metadata.Pairs("x-forwarded-for", "203.0.113.7", gatewaySecretKey, "guess"),
+			expected: service.ClientInfo{IP: "127.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tt.peerAddr)
+			require.NoError(t, err)
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			ctx = metadata.NewIncomingContext(ctx, tt.md)
+
+			assert.Equal(t, tt.expected, clientInfo(ctx))
+		})
+	}
+}
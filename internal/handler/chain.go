@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"auth_test/internal/ratelimit"
+	"auth_test/internal/service"
+	"auth_test/pkg/metrics"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// InterceptorConfig - настройки цепочки интерцепторов gRPC сервера
+type InterceptorConfig struct {
+	Logger      *slog.Logger
+	UserService service.UserService
+	// nil - метрики не записываются
+	Metrics metrics.Metrics
+	// nil - частота вызовов не ограничивается
+	Limiter *ratelimit.Limiter
+	// Методы, для которых обязателен клиентский сертификат (см. ClientCertInterceptor)
+	MTLSRequiredMethods []string
+	// Deadline вызова, если клиент не передал свой; MethodTimeouts переопределяет его для отдельных методов
+	DefaultTimeout time.Duration
+	MethodTimeouts map[string]time.Duration
+}
+
+// ServerInterceptors возвращает опции grpc.NewServer с цепочками unary и stream интерцепторов.
+//
+// Порядок: ID запроса и логгер, лог вызова, метрики, deadline, recovery, ограничение частоты,
+// mTLS, проверка администратора. Recovery стоит после лога и метрик, чтобы паника попала в них
+// как codes.Internal; интерцепторы до него сами паниковать не должны
+func ServerInterceptors(cfg InterceptorConfig) []grpc.ServerOption {
+	m := cfg.Metrics
+	if m == nil {
+		m = metrics.Noop{}
+	}
+
+	unary := []grpc.UnaryServerInterceptor{
+		RequestIDInterceptor(cfg.Logger),
+		LoggingInterceptor(),
+		MetricsInterceptor(m),
+		DeadlineInterceptor(cfg.DefaultTimeout, cfg.MethodTimeouts),
+		RecoveryInterceptor(),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		RequestIDStreamInterceptor(cfg.Logger),
+		LoggingStreamInterceptor(),
+		MetricsStreamInterceptor(m),
+		DeadlineStreamInterceptor(cfg.MethodTimeouts),
+		RecoveryStreamInterceptor(),
+	}
+
+	// До проверки токенов и сертификатов: отклонённый по лимиту запрос не нагружает сервис
+	if cfg.Limiter != nil {
+		unary = append(unary, RateLimitInterceptor(cfg.Limiter, m))
+		stream = append(stream, RateLimitStreamInterceptor(cfg.Limiter, m))
+	}
+
+	unary = append(unary,
+		ClientCertInterceptor(cfg.MTLSRequiredMethods),
+		AdminInterceptor(cfg.UserService),
+	)
+	stream = append(stream,
+		ClientCertStreamInterceptor(cfg.MTLSRequiredMethods),
+		AdminStreamInterceptor(cfg.UserService),
+	)
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
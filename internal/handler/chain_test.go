@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"auth_test/pkg/pb"
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// chainTestServer паникует в Login и возвращает в VerifyToken оставшееся до deadline время
+type chainTestServer struct {
+	pb.UnimplementedAuthServiceServer
+}
+
+func (chainTestServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	panic("boom")
+}
+
+func (chainTestServer) VerifyToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.VerifyTokenResponse, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return &pb.VerifyTokenResponse{}, nil
+	}
+	return &pb.VerifyTokenResponse{Message: time.Until(deadline).Round(time.Second).String()}, nil
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func startChainTestServer(t *testing.T, cfg InterceptorConfig) pb.AuthServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer(ServerInterceptors(cfg)...)
+	pb.RegisterAuthServiceServer(grpcServer, chainTestServer{})
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAuthServiceClient(conn)
+}
+
+func TestServerInterceptors_Recovery(t *testing.T) {
+	var logs syncBuffer
+	client := startChainTestServer(t, InterceptorConfig{
+		Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+
+	_, err := client.Login(context.Background(), &pb.LoginRequest{Username: "alice", Password: "secret"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal error", st.Message())
+
+	// Паника записана со стеком и видна логу вызова как Internal
+	assert.Contains(t, logs.String(), `msg="Panic in gRPC handler" method=Login`)
+	assert.Contains(t, logs.String(), "panic=boom")
+	assert.Contains(t, logs.String(), "chainTestServer.Login")
+	assert.Contains(t, logs.String(), `level=ERROR msg="gRPC call finished" method=Login`)
+	assert.Contains(t, logs.String(), "code=Internal")
+
+	// Сервер продолжает обслуживать запросы
+	_, err = client.VerifyToken(context.Background(), &pb.VerifyTokenRequest{})
+	assert.NoError(t, err)
+}
+
+func TestServerInterceptors_Deadline(t *testing.T) {
+	client := startChainTestServer(t, InterceptorConfig{
+		Logger:         slog.Default(),
+		DefaultTimeout: 10 * time.Second,
+	})
+
+	resp, err := client.VerifyToken(context.Background(), &pb.VerifyTokenRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "10s", resp.Message)
+
+	// Deadline клиента сохраняется, даже если он больше серверного
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	resp, err = client.VerifyToken(ctx, &pb.VerifyTokenRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "1m0s", resp.Message)
+
+	client = startChainTestServer(t, InterceptorConfig{
+		Logger:         slog.Default(),
+		DefaultTimeout: 10 * time.Second,
+		MethodTimeouts: map[string]time.Duration{"/auth.AuthService/VerifyToken": 0},
+	})
+	resp, err = client.VerifyToken(context.Background(), &pb.VerifyTokenRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Message)
+}
+
+func TestParseMethodTimeouts(t *testing.T) {
+	timeouts, err := ParseMethodTimeouts([]string{"/auth.AdminService/ExportUsers=5m", " /auth.AuthService/Login=2s", ""})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"/auth.AdminService/ExportUsers": 5 * time.Minute,
+		"/auth.AuthService/Login":        2 * time.Second,
+	}, timeouts)
+
+	for _, entry := range []string{"Login=2s", "/auth.AdminService/=1s", "/auth.AuthService/Login=soon", "/auth.AuthService/Login=-1s"} {
+		_, err := ParseMethodTimeouts([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
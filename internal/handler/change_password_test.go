@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          *pb.ChangePasswordRequest
+		mockCall     bool
+		mockErr      error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "successful change",
+			req:          &pb.ChangePasswordRequest{Username: "admin", CurrentPassword: "generated", NewPassword: "new-password"},
+			mockCall:     true,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "missing new password",
+			req:          &pb.ChangePasswordRequest{Username: "admin", CurrentPassword: "generated"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "weak password",
+			req:          &pb.ChangePasswordRequest{Username: "admin", CurrentPassword: "generated", NewPassword: "short"},
+			mockCall:     true,
+			mockErr:      service.ErrWeakPassword,
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "wrong current password",
+			req:          &pb.ChangePasswordRequest{Username: "admin", CurrentPassword: "wrong", NewPassword: "new-password"},
+			mockCall:     true,
+			mockErr:      service.ErrInvalidCredentials,
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := service.NewMockUserService(ctrl)
+			if tt.mockCall {
+				mockService.EXPECT().
+					ChangePassword(gomock.Any(), tt.req.Username, tt.req.CurrentPassword, tt.req.NewPassword).
+					Return(tt.mockErr)
+			}
+
+			handler := NewGRPCHandler(mockService)
+			resp, err := handler.ChangePassword(context.Background(), tt.req)
+
+			if tt.expectedCode == codes.OK {
+				require.NoError(t, err)
+				assert.Equal(t, "Password changed", resp.Message)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
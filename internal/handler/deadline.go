@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DeadlineInterceptor ограничивает время вызова, если клиент не передал свой deadline:
+// timeouts[полное имя метода], иначе defaultTimeout. Нулевой таймаут - без ограничения.
+// Deadline клиента не продлевается и не сокращается
+func DeadlineInterceptor(defaultTimeout time.Duration, timeouts map[string]time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		timeout, ok := timeouts[info.FullMethod]
+		if !ok {
+			timeout = defaultTimeout
+		}
+
+		ctx, cancel := withDefaultDeadline(ctx, timeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+// DeadlineStreamInterceptor - DeadlineInterceptor для потоковых методов. Общий таймаут к потокам
+// не применяется: импорт и экспорт длятся столько, сколько нужно для передачи данных
+func DeadlineStreamInterceptor(timeouts map[string]time.Duration) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := withDefaultDeadline(ss.Context(), timeouts[info.FullMethod])
+		defer cancel()
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func withDefaultDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ParseMethodTimeouts разбирает записи вида "/auth.AdminService/ExportUsers=5m"
+func ParseMethodTimeouts(entries []string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		method, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(method, "/") || strings.Count(method, "/") != 2 || strings.HasSuffix(method, "/") {
+			return nil, fmt.Errorf("invalid method timeout %q: expected /package.Service/Method=duration", entry)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid method timeout %q: bad duration", entry)
+		}
+		timeouts[method] = timeout
+	}
+	return timeouts, nil
+}
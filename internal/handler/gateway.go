@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"auth_test/api"
+	"auth_test/internal/apierror"
+	"auth_test/pkg/pb"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// gatewaySecretKey - ключ метаданных, которым REST gateway подтверждает gRPC серверу, что запрос
+// пришёл через него. Только таким запросам сервер доверяет x-forwarded-for
+const gatewaySecretKey = "x-gateway-secret"
+
+// gatewaySecret создаётся при старте процесса: gateway и gRPC сервер работают в одном процессе,
+// а клиент не может его узнать или подобрать
+var gatewaySecret = newGatewaySecret()
+
+func newGatewaySecret() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("generate gateway secret: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// NewGatewayHandler возвращает REST фасад, проксирующий запросы в AuthService по gRPC.
+// creds == nil означает подключение без TLS
+func NewGatewayHandler(ctx context.Context, grpcEndpoint string, creds credentials.TransportCredentials) (http.Handler, error) {
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+
+	gwMux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+		runtime.WithErrorHandler(problemErrorHandler),
+	)
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		// Передаёт контекст трассировки HTTP запроса в gRPC вызов
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithUnaryInterceptor(gatewaySecretInterceptor),
+	}
+
+	if err := pb.RegisterAuthServiceHandlerFromEndpoint(ctx, gwMux, grpcEndpoint, opts); err != nil {
+		return nil, fmt.Errorf("register gateway: %w", err)
+	}
+
+	spec, err := api.OpenAPIJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gwMux)
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	})
+
+	// Серверный спан на каждый HTTP запрос; traceparent клиента продолжает его трассировку
+	return otelhttp.NewHandler(mux, "gateway"), nil
+}
+
+// gatewaySecretInterceptor добавляет в вызов секрет gateway. Значение, переданное HTTP клиентом
+// в Grpc-Metadata-X-Gateway-Secret, заменяется
+func gatewaySecretInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(gatewaySecretKey, gatewaySecret)
+	return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+}
+
+// fromGateway сообщает, что запрос пришёл через REST gateway этого процесса
+func fromGateway(md metadata.MD) bool {
+	values := md.Get(gatewaySecretKey)
+	return len(values) == 1 && subtle.ConstantTimeCompare([]byte(values[0]), []byte(gatewaySecret)) == 1
+}
+
+// incomingHeaderMatcher передаёт в gRPC заголовок X-Request-Id вместе со стандартным набором
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, RequestIDHeader) {
+		return RequestIDHeader, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher возвращает ID запроса как X-Request-Id, а не Grpc-Metadata-X-Request-Id,
+// и время ожидания после лимита как стандартный Retry-After
+func outgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case RequestIDHeader:
+		return "X-Request-Id", true
+	case RetryAfterHeader:
+		return "Retry-After", true
+	}
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
+
+// problemErrorHandler отдаёт ошибки REST фасада как application/problem+json. Метаданные ответа
+// переносятся в заголовки так же, как в обработчике по умолчанию: X-Request-Id, Retry-After
+func problemErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for key, values := range md.HeaderMD {
+			name, ok := outgoingHeaderMatcher(key)
+			if !ok {
+				continue
+			}
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+	}
+
+	apierror.WriteProblem(w, r, err)
+}
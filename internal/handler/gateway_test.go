@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/logging"
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+)
+
+func startTestGRPCServer(t *testing.T, userService service.UserService) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer(ServerInterceptors(InterceptorConfig{
+		Logger:      slog.Default(),
+		UserService: userService,
+	})...)
+	pb.RegisterAuthServiceServer(grpcServer, NewGRPCHandler(userService))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestGateway_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "admin", "admin123").Return(true, nil)
+	mockService.EXPECT().GenerateToken(gomock.Any(), "admin", service.TokenTypeAccess).Return("access-token-123", nil)
+	mockService.EXPECT().GenerateToken(gomock.Any(), "admin", service.TokenTypeRefresh).Return("refresh-token-456", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gateway, err := NewGatewayHandler(ctx, startTestGRPCServer(t, mockService), nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"username":"admin","password":"admin123"}`))
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "access-token-123", resp["accessToken"])
+	assert.Equal(t, "refresh-token-456", resp["refreshToken"])
+	assert.Equal(t, "Bearer", resp["tokenType"])
+}
+
+func TestGateway_LoginInvalidCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "admin", "wrong").Return(false, service.ErrInvalidCredentials)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gateway, err := NewGatewayHandler(ctx, startTestGRPCServer(t, mockService), nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"username":"admin","password":"wrong"}`))
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, apierror.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, apierror.ReasonInvalidCredentials, problem.Reason)
+	assert.Equal(t, "/v1/auth/login", problem.Instance)
+}
+
+func TestGateway_RequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var requestIDs []string
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "admin", "wrong").
+		DoAndReturn(func(ctx context.Context, username, password string) (bool, error) {
+			requestIDs = append(requestIDs, logging.RequestID(ctx))
+			return false, service.ErrInvalidCredentials
+		}).Times(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gateway, err := NewGatewayHandler(ctx, startTestGRPCServer(t, mockService), nil)
+	require.NoError(t, err)
+
+	login := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"username":"admin","password":"wrong"}`))
+		if requestID != "" {
+			req.Header.Set("X-Request-Id", requestID)
+		}
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// ID клиента передаётся в сервис и возвращается в ответе
+	rec := login("req-42")
+	assert.Equal(t, "req-42", rec.Header().Get("X-Request-Id"))
+
+	// Без заголовка (или с небезопасным значением) ID создаётся сервером
+	rec = login("")
+	generated := rec.Header().Get("X-Request-Id")
+	assert.Len(t, generated, 32)
+
+	rec = login("bad id\nwith newline")
+	assert.Len(t, rec.Header().Get("X-Request-Id"), 32)
+
+	require.Len(t, requestIDs, 3)
+	assert.Equal(t, "req-42", requestIDs[0])
+	assert.Equal(t, generated, requestIDs[1])
+}
+
+func TestGateway_OpenAPI(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gateway, err := NewGatewayHandler(ctx, "127.0.0.1:0", nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	paths, ok := spec["paths"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, paths, "/v1/auth/login")
+	assert.Contains(t, paths, "/v1/auth/verify")
+}
@@ -1,12 +1,13 @@
 package handler
 
 import (
+	"auth_test/internal/apierror"
 	"auth_test/internal/service"
 	"auth_test/pkg/pb"
 	"context"
+	"errors"
 
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type GRPCHandler struct {
@@ -26,17 +27,24 @@ func (h *GRPCHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 	}
 
 	valid, err := h.userService.ValidateCredentials(ctx, req.Username, req.Password)
-	if err != nil || !valid {
-		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	if errors.Is(err, service.ErrUserNotFound) {
+		// Существование пользователя не раскрывается
+		err = service.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	if !valid {
+		return nil, apierror.GRPC(service.ErrInvalidCredentials)
 	}
 
 	accessToken, err := h.userService.GenerateToken(ctx, req.Username, service.TokenTypeAccess)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "failed to generate access token")
+		return nil, apierror.GRPC(err)
 	}
 	refreshToken, err := h.userService.GenerateToken(ctx, req.Username, service.TokenTypeRefresh)
 	if err != nil {
-		return nil, status.Error(codes.Internal, "failed to generate refresh token")
+		return nil, apierror.GRPC(err)
 	}
 
 	return &pb.LoginResponse{
@@ -47,6 +55,17 @@ func (h *GRPCHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 	}, nil
 }
 
+func (h *GRPCHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.Register(ctx, req.Username, req.Password, req.Email); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	return &pb.RegisterResponse{Message: "Check your email to complete the registration"}, nil
+}
+
 func (h *GRPCHandler) VerifyToken(ctx context.Context, req *pb.VerifyTokenRequest) (*pb.VerifyTokenResponse, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
@@ -60,6 +79,10 @@ func (h *GRPCHandler) VerifyToken(ctx context.Context, req *pb.VerifyTokenReques
 	}
 
 	newAccessToken, err := h.userService.RefreshToken(ctx, req.Token)
+	if err != nil && !tokenRejected(err) {
+		// Сбой проверки - не повод считать токен недействительным и выходить из системы
+		return nil, apierror.GRPC(err)
+	}
 	if err != nil {
 		return &pb.VerifyTokenResponse{
 			Message: "Token is invalid",
@@ -81,3 +104,83 @@ func (h *GRPCHandler) VerifyToken(ctx context.Context, req *pb.VerifyTokenReques
 		Valid:   true,
 	}, nil
 }
+
+func (h *GRPCHandler) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.Username == "" || req.CurrentPassword == "" || req.NewPassword == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "username, current_password and new_password are required")
+	}
+
+	err := h.userService.ChangePassword(ctx, req.Username, req.CurrentPassword, req.NewPassword)
+	switch {
+	case err == nil:
+		return &pb.ChangePasswordResponse{Message: "Password changed"}, nil
+	case errors.Is(err, service.ErrUserDisabled),
+		errors.Is(err, service.ErrUserNotVerified),
+		errors.Is(err, service.ErrUserNotFound):
+		// Состояние учётной записи не раскрывается без верного пароля
+		return nil, apierror.GRPC(service.ErrInvalidCredentials)
+	default:
+		return nil, apierror.GRPC(err)
+	}
+}
+
+func (h *GRPCHandler) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.Token == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "token is required")
+	}
+
+	err := h.userService.VerifyEmail(ctx, req.Token)
+	switch {
+	case err == nil:
+		return &pb.VerifyEmailResponse{Message: "Email verified"}, nil
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrExpiredToken), errors.Is(err, service.ErrInvalidTypeToken):
+		// Токен подтверждения - аргумент запроса, а не учётные данные
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidToken, "invalid or expired verification token")
+	default:
+		return nil, apierror.GRPC(err)
+	}
+}
+
+func (h *GRPCHandler) ResendVerification(ctx context.Context, req *pb.ResendVerificationRequest) (*pb.ResendVerificationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.Email == "" {
+		return nil, apierror.New(codes.InvalidArgument, apierror.ReasonInvalidArgument, "email is required")
+	}
+
+	if err := h.userService.ResendVerification(ctx, req.Email); err != nil {
+		return nil, apierror.GRPC(err)
+	}
+	// Ответ одинаковый для любых адресов, чтобы не раскрывать наличие аккаунта
+	return &pb.ResendVerificationResponse{Message: "If the address is pending verification, an email has been sent"}, nil
+}
+
+// tokenRejected отличает отказ в токене (недействителен, истёк, владелец отключён или удалён)
+// от сбоя при его проверке
+func tokenRejected(err error) bool {
+	switch apierror.Status(err).Code() {
+	case codes.Unauthenticated, codes.PermissionDenied, codes.NotFound, codes.FailedPrecondition:
+		return true
+	default:
+		return false
+	}
+}
+
+// tokenError - ответ на непринятый токен: Unauthenticated с причиной отказа и текстом message.
+// Сбой проверки отдаётся как есть, чтобы клиент повторил запрос, а не входил заново
+func tokenError(err error, message string) error {
+	if !tokenRejected(err) {
+		return apierror.GRPC(err)
+	}
+	return apierror.New(codes.Unauthenticated, apierror.Reason(err), message)
+}
@@ -3,31 +3,101 @@ package handler
 import (
 	"auth_test/pkg/metrics"
 	"context"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
-func MetricsInterceptor() grpc.UnaryServerInterceptor {
+// MetricsInterceptor считает вызовы по коду ответа, их длительность, размер сообщений
+// и число выполняемых сейчас запросов
+func MetricsInterceptor(m metrics.Metrics) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
-		method := extractMethodName(info.FullMethod)
+		service, method := splitMethodName(info.FullMethod)
+
+		m.GRPCStarted(service, method)
+		observeMessageSize(m, service, method, metrics.DirectionReceived, req)
 
 		resp, err := handler(ctx, req)
 
-		duration := time.Since(start).Seconds()
-		status := "success"
-		if err != nil {
-			status = "error"
+		if err == nil {
+			observeMessageSize(m, service, method, metrics.DirectionSent, resp)
 		}
-
-		metrics.GRPCRequests.WithLabelValues(method, status).Inc()
-		metrics.GRPCRequestDuration.WithLabelValues(method).Observe(duration)
+		m.GRPCFinished(ctx, service, method, status.Code(err).String(), verifyResult(resp, err), time.Since(start))
 
 		return resp, err
 	}
 }
 
+// MetricsStreamInterceptor - MetricsInterceptor для потоковых методов; длительность - время жизни потока
+func MetricsStreamInterceptor(m metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		service, method := splitMethodName(info.FullMethod)
+
+		m.GRPCStarted(service, method)
+
+		err := handler(srv, &metricsServerStream{ServerStream: ss, metrics: m, service: service, method: method})
+
+		m.GRPCFinished(ss.Context(), service, method, status.Code(err).String(), "", time.Since(start))
+
+		return err
+	}
+}
+
+// metricsServerStream записывает размер каждого принятого и отправленного сообщения
+type metricsServerStream struct {
+	grpc.ServerStream
+	metrics         metrics.Metrics
+	service, method string
+}
+
+func (s *metricsServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		observeMessageSize(s.metrics, s.service, s.method, metrics.DirectionReceived, m)
+	}
+	return err
+}
+
+func (s *metricsServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		observeMessageSize(s.metrics, s.service, s.method, metrics.DirectionSent, m)
+	}
+	return err
+}
+
+func observeMessageSize(m metrics.Metrics, service, method, direction string, msg interface{}) {
+	if message, ok := msg.(proto.Message); ok {
+		m.GRPCMessage(service, method, direction, proto.Size(message))
+	}
+}
+
+// verifyResult различает действительные и недействительные токены в успешных ответах VerifyToken
+func verifyResult(resp interface{}, err error) string {
+	r, ok := resp.(interface{ GetValid() bool })
+	if !ok || err != nil {
+		return ""
+	}
+	if r.GetValid() {
+		return metrics.VerifyResultValid
+	}
+	return metrics.VerifyResultInvalid
+}
+
+// splitMethodName разбивает "/auth.AuthService/Login" на "auth.AuthService" и "Login"
+func splitMethodName(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+	return service, method
+}
+
 func extractMethodName(fullMethod string) string {
 	for i := len(fullMethod) - 1; i >= 0; i-- {
 		if fullMethod[i] == '/' {
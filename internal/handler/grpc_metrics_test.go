@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/pkg/metrics"
+	"auth_test/pkg/pb"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "alice", "wrong").Return(false, service.ErrInvalidCredentials)
+	mockService.EXPECT().RefreshToken(gomock.Any(), "good").Return("good", nil)
+	mockService.EXPECT().RefreshToken(gomock.Any(), "bad").Return("", service.ErrInvalidToken)
+
+	m := metrics.NewRecorder()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(ServerInterceptors(InterceptorConfig{
+		Logger:      slog.Default(),
+		UserService: mockService,
+		Metrics:     m,
+	})...)
+	pb.RegisterAuthServiceServer(grpcServer, NewGRPCHandler(mockService))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewAuthServiceClient(conn)
+
+	_, err = client.Login(context.Background(), &pb.LoginRequest{Username: "alice", Password: "wrong"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.VerifyToken(context.Background(), &pb.VerifyTokenRequest{Token: "good"})
+	require.NoError(t, err)
+	_, err = client.VerifyToken(context.Background(), &pb.VerifyTokenRequest{Token: "bad"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, m.Count("GRPCFinished", "auth.AuthService", "Login", "Unauthenticated", ""))
+	assert.Equal(t, 1, m.Count("GRPCFinished", "auth.AuthService", "VerifyToken", "OK", metrics.VerifyResultValid))
+	assert.Equal(t, 1, m.Count("GRPCFinished", "auth.AuthService", "VerifyToken", "OK", metrics.VerifyResultInvalid))
+	assert.Equal(t, 0, m.InFlight("auth.AuthService", "Login"))
+
+	// Размер запроса пишется всегда, ответа - только для успешных вызовов
+	assert.Equal(t, 1, m.Count("GRPCMessage", "auth.AuthService", "Login", metrics.DirectionReceived))
+	assert.Equal(t, 0, m.Count("GRPCMessage", "auth.AuthService", "Login", metrics.DirectionSent))
+	assert.Equal(t, 2, m.Count("GRPCMessage", "auth.AuthService", "VerifyToken", metrics.DirectionSent))
+}
+
+func TestSplitMethodName(t *testing.T) {
+	service, method := splitMethodName("/auth.AdminService/ListUsers")
+	assert.Equal(t, "auth.AdminService", service)
+	assert.Equal(t, "ListUsers", method)
+
+	service, method = splitMethodName("Login")
+	assert.Equal(t, "unknown", service)
+	assert.Equal(t, "Login", method)
+}
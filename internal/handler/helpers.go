@@ -10,9 +10,3 @@ func JSONSuccess(w http.ResponseWriter, data interface{}, status int) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
-
-func JSONError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
-}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/internal/store"
+	"auth_test/pkg/pb"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testSSHAHash = "{SSHA}JLOOnBk7GsGWEiCCq0n/Z1y3TkhzYWx0"
+
+func startTestAdminServer(t *testing.T, adminService service.AdminService) pb.AdminServiceClient {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	userService := service.NewMockUserService(ctrl)
+	userService.EXPECT().ParseAccessToken(gomock.Any(), "admin-token").
+		Return(&service.TokenClaims{Username: "admin", Role: service.RoleAdmin}, nil).AnyTimes()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer(ServerInterceptors(InterceptorConfig{
+		Logger:      slog.Default(),
+		UserService: userService,
+	})...)
+	pb.RegisterAdminServiceServer(grpcServer, NewAdminGRPCHandler(adminService))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAdminServiceClient(conn)
+}
+
+func adminContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer admin-token")
+}
+
+func TestAdminHandler_ImportExportUsers(t *testing.T) {
+	client := startTestAdminServer(t, service.NewAdminService(store.NewInMemoryStore()))
+
+	input := "username,password_hash,role\n" +
+		"alice," + testSSHAHash + ",admin\n" +
+		"bob,not-a-hash,user\n" +
+		"carol," + testSSHAHash + ",user\n"
+
+	stream, err := client.ImportUsers(adminContext())
+	require.NoError(t, err)
+	// Файл режется на части посреди строк
+	for i := 0; i < len(input); i += 7 {
+		req := &pb.ImportUsersRequest{Chunk: []byte(input[i:min(i+7, len(input))])}
+		if i == 0 {
+			req.Format = service.FormatCSV
+		}
+		require.NoError(t, stream.Send(req))
+	}
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), resp.Total)
+	assert.Equal(t, int32(2), resp.Imported)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, int32(3), resp.Errors[0].Line)
+	assert.Equal(t, "bob", resp.Errors[0].Username)
+	assert.Contains(t, resp.Errors[0].Message, "password_hash")
+
+	export, err := client.ExportUsers(adminContext(), &pb.ExportUsersRequest{Format: service.FormatJSONL})
+	require.NoError(t, err)
+
+	var out strings.Builder
+	for {
+		chunk, err := export.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		out.Write(chunk.Chunk)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"username":"alice"`)
+	assert.Contains(t, lines[0], testSSHAHash)
+	assert.Contains(t, lines[1], `"username":"carol"`)
+}
+
+func TestAdminHandler_ImportUsersErrors(t *testing.T) {
+	client := startTestAdminServer(t, service.NewAdminService(store.NewInMemoryStore()))
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		requests     []*pb.ImportUsersRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "missing token",
+			ctx:          context.Background(),
+			requests:     []*pb.ImportUsersRequest{{Format: service.FormatCSV, Chunk: []byte("username,password_hash\n")}},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "empty stream",
+			ctx:          adminContext(),
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "unknown format",
+			ctx:          adminContext(),
+			requests:     []*pb.ImportUsersRequest{{Format: "xml", Chunk: []byte("<users/>")}},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "missing required column",
+			ctx:          adminContext(),
+			requests:     []*pb.ImportUsersRequest{{Format: service.FormatCSV, Chunk: []byte("username,email\n")}},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.ImportUsers(tt.ctx)
+			require.NoError(t, err)
+			for _, req := range tt.requests {
+				// Сервер может завершить поток раньше, чем клиент отправит всё
+				if err := stream.Send(req); err != nil {
+					break
+				}
+			}
+
+			_, err = stream.CloseAndRecv()
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
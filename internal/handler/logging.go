@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"auth_test/internal/logging"
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LoggingInterceptor пишет в лог завершение каждого вызова с кодом ответа и длительностью.
+// Успешные вызовы пишутся на уровне debug, ошибки сервера - на уровне error
+func LoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, err, time.Since(start))
+
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor - LoggingInterceptor для потоковых методов
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+		logCall(ss.Context(), err, time.Since(start))
+
+		return err
+	}
+}
+
+func logCall(ctx context.Context, err error, duration time.Duration) {
+	code := status.Code(err)
+	attrs := []any{"code", code.String(), "duration", duration}
+	if err != nil {
+		attrs = append(attrs, logging.Err(err))
+	}
+	logging.FromContext(ctx).Log(ctx, callLogLevel(code), "gRPC call finished", attrs...)
+}
+
+func callLogLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelDebug
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
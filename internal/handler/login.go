@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"auth_test/internal/apierror"
 	"auth_test/internal/service"
 	"errors"
+	"net"
 	"net/http"
+
+	"google.golang.org/grpc/codes"
 )
 
 type LoginHandler struct {
@@ -17,37 +21,36 @@ func NewLoginHandler(userService service.UserService) *LoginHandler {
 }
 
 func (h *LoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := service.WithClientInfo(r.Context(), service.ClientInfo{
+		IP:        remoteIP(r),
+		UserAgent: r.UserAgent(),
+	})
 
 	username, password, ok := r.BasicAuth()
 	if !ok {
-		JSONError(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+		apierror.WriteProblem(w, r, apierror.New(codes.Unauthenticated, apierror.ReasonInvalidCredentials, "missing or invalid Authorization header"))
 		return
 	}
 
 	valid, err := h.userService.ValidateCredentials(ctx, username, password)
-	if err != nil {
-		if errors.Is(err, service.ErrInvalidCredentials) || errors.Is(err, service.ErrUserNotFound) {
-			JSONError(w, "Invalid credentials", http.StatusUnauthorized)
-		} else {
-			JSONError(w, "Internal server error", http.StatusInternalServerError)
-		}
-		return
+	if errors.Is(err, service.ErrUserNotFound) || (err == nil && !valid) {
+		// Существование пользователя не раскрывается
+		err = service.ErrInvalidCredentials
 	}
-	if !valid {
-		JSONError(w, "Invalid credentials", http.StatusUnauthorized)
+	if err != nil {
+		apierror.WriteProblem(w, r, err)
 		return
 	}
 
 	accessToken, err := h.userService.GenerateToken(ctx, username, service.TokenTypeAccess)
 	if err != nil {
-		JSONError(w, "Failed to generate assecc token", http.StatusInternalServerError)
+		apierror.WriteProblem(w, r, err)
 		return
 	}
 
 	refreshToken, err := h.userService.GenerateToken(ctx, username, service.TokenTypeRefresh)
 	if err != nil {
-		JSONError(w, "Failed to generate refresh token", http.StatusInternalServerError)
+		apierror.WriteProblem(w, r, err)
 		return
 	}
 
@@ -60,3 +63,11 @@ func (h *LoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	JSONSuccess(w, response, http.StatusOK)
 }
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"auth_test/internal/apierror"
 	"auth_test/internal/service"
 	"auth_test/pkg/pb"
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,14 +18,15 @@ import (
 
 func TestAuthService_Login(t *testing.T) {
 	tests := []struct {
-		name          string
-		username      string
-		password      string
-		mockValid     bool
-		mockErr       error
-		expectedCode  codes.Code
-		expectedToken string
-		expectedError string
+		name           string
+		username       string
+		password       string
+		mockValid      bool
+		mockErr        error
+		expectedCode   codes.Code
+		expectedToken  string
+		expectedError  string
+		expectedReason string
 	}{
 		{
 			name:          "successful login",
@@ -35,31 +38,64 @@ func TestAuthService_Login(t *testing.T) {
 			expectedToken: "access-token-123",
 		},
 		{
-			name:          "invalid credentials - wrong password",
-			username:      "admin",
-			password:      "wrongpassword",
-			mockValid:     false,
-			mockErr:       service.ErrInvalidCredentials,
-			expectedCode:  codes.Unauthenticated,
-			expectedError: "invalid credentials",
+			name:           "invalid credentials - wrong password",
+			username:       "admin",
+			password:       "wrongpassword",
+			mockValid:      false,
+			mockErr:        service.ErrInvalidCredentials,
+			expectedCode:   codes.Unauthenticated,
+			expectedError:  "invalid credentials",
+			expectedReason: apierror.ReasonInvalidCredentials,
 		},
 		{
-			name:          "invalid credentials - user not found",
-			username:      "nonexists",
-			password:      "anypassword",
-			mockValid:     false,
-			mockErr:       service.ErrUserNotFound,
-			expectedCode:  codes.Unauthenticated,
-			expectedError: "invalid credentials",
+			name:           "invalid credentials - user not found",
+			username:       "nonexists",
+			password:       "anypassword",
+			mockValid:      false,
+			mockErr:        service.ErrUserNotFound,
+			expectedCode:   codes.Unauthenticated,
+			expectedError:  "invalid credentials",
+			expectedReason: apierror.ReasonInvalidCredentials,
 		},
 		{
-			name:          "server error",
-			username:      "admin",
-			password:      "admin123",
-			mockValid:     false,
-			mockErr:       errors.New("database connection failed"),
-			expectedCode:  codes.Unauthenticated,
-			expectedError: "invalid credentials",
+			name:           "password change required",
+			username:       "admin",
+			password:       "generated",
+			mockValid:      false,
+			mockErr:        service.ErrPasswordChangeRequired,
+			expectedCode:   codes.FailedPrecondition,
+			expectedError:  "password change required",
+			expectedReason: apierror.ReasonPasswordChangeRequired,
+		},
+		{
+			name:           "user disabled",
+			username:       "admin",
+			password:       "admin123",
+			mockValid:      false,
+			mockErr:        service.ErrUserDisabled,
+			expectedCode:   codes.PermissionDenied,
+			expectedError:  "user is disabled",
+			expectedReason: apierror.ReasonUserDisabled,
+		},
+		{
+			name:           "storage unavailable",
+			username:       "admin",
+			password:       "admin123",
+			mockValid:      false,
+			mockErr:        fmt.Errorf("%w: connection refused", service.ErrUnavailable),
+			expectedCode:   codes.Unavailable,
+			expectedError:  "try again later",
+			expectedReason: apierror.ReasonUnavailable,
+		},
+		{
+			name:           "server error",
+			username:       "admin",
+			password:       "admin123",
+			mockValid:      false,
+			mockErr:        errors.New("database connection failed"),
+			expectedCode:   codes.Internal,
+			expectedError:  "internal error",
+			expectedReason: apierror.ReasonInternal,
 		},
 		{
 			name:          "empty credentials",
@@ -111,6 +147,9 @@ func TestAuthService_Login(t *testing.T) {
 				require.True(t, ok)
 				assert.Equal(t, tt.expectedCode, st.Code())
 				assert.Contains(t, st.Message(), tt.expectedError)
+				if tt.expectedReason != "" {
+					assert.Equal(t, tt.expectedReason, apierror.Reason(err))
+				}
 			}
 		})
 	}
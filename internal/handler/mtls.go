@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ClientCertIdentity возвращает CommonName проверенного клиентского сертификата
+func ClientCertIdentity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return "", false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// ClientCertInterceptor требует проверенный клиентский сертификат для методов,
+// полное имя которых начинается с одного из префиксов (например, "/auth.AdminService/")
+func ClientCertInterceptor(methodPrefixes []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !matchesAnyPrefix(info.FullMethod, methodPrefixes) {
+			return handler(ctx, req)
+		}
+
+		if _, ok := ClientCertIdentity(ctx); !ok {
+			return nil, status.Error(codes.Unauthenticated, "client certificate required")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// ClientCertStreamInterceptor - ClientCertInterceptor для потоковых методов
+func ClientCertStreamInterceptor(methodPrefixes []string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !matchesAnyPrefix(info.FullMethod, methodPrefixes) {
+			return handler(srv, ss)
+		}
+
+		if _, ok := ClientCertIdentity(ss.Context()); !ok {
+			return status.Error(codes.Unauthenticated, "client certificate required")
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func matchesAnyPrefix(fullMethod string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func contextWithClientCert(commonName string) context.Context {
+	state := tls.ConnectionState{}
+	if commonName != "" {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: state},
+	})
+}
+
+func TestClientCertInterceptor(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		fullMethod   string
+		expectedCode codes.Code
+	}{
+		{
+			name:         "public method without certificate",
+			ctx:          context.Background(),
+			fullMethod:   "/auth.AuthService/Login",
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "protected method without peer",
+			ctx:          context.Background(),
+			fullMethod:   "/auth.AdminService/ListUsers",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "protected method with unverified tls",
+			ctx:          contextWithClientCert(""),
+			fullMethod:   "/auth.AdminService/ListUsers",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "protected method with verified certificate",
+			ctx:          contextWithClientCert("ops"),
+			fullMethod:   "/auth.AdminService/ListUsers",
+			expectedCode: codes.OK,
+		},
+	}
+
+	interceptor := ClientCertInterceptor([]string{"/auth.AdminService/"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &grpc.UnaryServerInfo{FullMethod: tt.fullMethod}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			}
+
+			resp, err := interceptor(tt.ctx, nil, info, handler)
+			if tt.expectedCode == codes.OK {
+				require.NoError(t, err)
+				assert.Equal(t, "ok", resp)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
+
+func TestClientCertIdentity(t *testing.T) {
+	identity, ok := ClientCertIdentity(contextWithClientCert("ops"))
+	require.True(t, ok)
+	assert.Equal(t, "ops", identity)
+
+	_, ok = ClientCertIdentity(context.Background())
+	assert.False(t, ok)
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestClientCertStreamInterceptor(t *testing.T) {
+	interceptor := ClientCertStreamInterceptor([]string{"/auth.AdminService/"})
+	info := &grpc.StreamServerInfo{FullMethod: "/auth.AdminService/ExportUsers"}
+	handler := func(srv interface{}, stream grpc.ServerStream) error { return nil }
+
+	err := interceptor(nil, &testServerStream{ctx: context.Background()}, info, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	err = interceptor(nil, &testServerStream{ctx: contextWithClientCert("ops")}, info, handler)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	connectcors "connectrpc.com/cors"
+	"connectrpc.com/vanguard/vanguardgrpc"
+	"github.com/rs/cors"
+	"google.golang.org/grpc"
+)
+
+// NewMultiProtocolHandler оборачивает gRPC сервер так, чтобы на одном порту
+// обслуживались gRPC, gRPC-Web и Connect запросы
+func NewMultiProtocolHandler(grpcServer *grpc.Server, allowedOrigins []string) (http.Handler, error) {
+	transcoder, err := vanguardgrpc.NewTranscoder(grpcServer)
+	if err != nil {
+		return nil, fmt.Errorf("create transcoder: %w", err)
+	}
+
+	return withCORS(transcoder, allowedOrigins), nil
+}
+
+func withCORS(h http.Handler, allowedOrigins []string) http.Handler {
+	if len(allowedOrigins) == 0 {
+		return h
+	}
+
+	middleware := cors.New(cors.Options{
+		AllowedOrigins: allowedOrigins,
+		AllowedMethods: connectcors.AllowedMethods(),
+		AllowedHeaders: append(connectcors.AllowedHeaders(), "Authorization"),
+		ExposedHeaders: connectcors.ExposedHeaders(),
+		MaxAge:         7200,
+	})
+
+	return middleware.Handler(h)
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+func startTestMultiProtocolServer(t *testing.T, userService service.UserService, allowedOrigins []string) *httptest.Server {
+	t.Helper()
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterAuthServiceServer(grpcServer, NewGRPCHandler(userService))
+
+	h, err := NewMultiProtocolHandler(grpcServer, allowedOrigins)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetHTTP1(true)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func expectSuccessfulLogin(mockService *service.MockUserService) {
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "admin", "admin123").Return(true, nil)
+	mockService.EXPECT().GenerateToken(gomock.Any(), "admin", service.TokenTypeAccess).Return("access-token-123", nil)
+	mockService.EXPECT().GenerateToken(gomock.Any(), "admin", service.TokenTypeRefresh).Return("refresh-token-456", nil)
+}
+
+func TestMultiProtocol_GRPC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	expectSuccessfulLogin(mockService)
+
+	srv := startTestMultiProtocolServer(t, mockService, nil)
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(srv.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resp, err := pb.NewAuthServiceClient(conn).Login(context.Background(), &pb.LoginRequest{Username: "admin", Password: "admin123"})
+	require.NoError(t, err)
+	assert.Equal(t, "access-token-123", resp.AccessToken)
+}
+
+func TestMultiProtocol_Connect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	expectSuccessfulLogin(mockService)
+
+	srv := startTestMultiProtocolServer(t, mockService, nil)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/auth.AuthService/Login", strings.NewReader(`{"username":"admin","password":"admin123"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connect-Protocol-Version", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "access-token-123", body["accessToken"])
+	assert.Equal(t, "refresh-token-456", body["refreshToken"])
+}
+
+func TestMultiProtocol_GRPCWeb(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	expectSuccessfulLogin(mockService)
+
+	srv := startTestMultiProtocolServer(t, mockService, nil)
+
+	msg, err := proto.Marshal(&pb.LoginRequest{Username: "admin", Password: "admin123"})
+	require.NoError(t, err)
+
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	frame = append(frame, msg...)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/auth.AuthService/Login", bytes.NewReader(frame))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("X-Grpc-Web", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Greater(t, len(data), 5)
+	require.Equal(t, byte(0), data[0])
+
+	size := binary.BigEndian.Uint32(data[1:5])
+	var loginResp pb.LoginResponse
+	require.NoError(t, proto.Unmarshal(data[5:5+size], &loginResp))
+	assert.Equal(t, "access-token-123", loginResp.AccessToken)
+}
+
+func TestMultiProtocol_CORS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv := startTestMultiProtocolServer(t, service.NewMockUserService(ctrl), []string{"https://app.example.com"})
+
+	tests := []struct {
+		name          string
+		origin        string
+		expectedAllow string
+	}{
+		{
+			name:          "allowed origin",
+			origin:        "https://app.example.com",
+			expectedAllow: "https://app.example.com",
+		},
+		{
+			name:          "unknown origin",
+			origin:        "https://evil.example.com",
+			expectedAllow: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, srv.URL+"/auth.AuthService/Login", nil)
+			require.NoError(t, err)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "connect-protocol-version,content-type")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.expectedAllow, resp.Header.Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
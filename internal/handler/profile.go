@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (h *GRPCHandler) GetMe(ctx context.Context, req *pb.GetMeRequest) (*pb.GetMeResponse, error) {
+	username, err := h.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := h.userService.GetProfile(ctx, username)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	pbProfile, err := toPBProfile(profile)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.GetMeResponse{Profile: pbProfile}, nil
+}
+
+func (h *GRPCHandler) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	username, err := h.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	update := service.ProfileUpdate{
+		Mask:            req.GetUpdateMask().GetPaths(),
+		Email:           req.Email,
+		DisplayName:     req.DisplayName,
+		Locale:          req.Locale,
+		Timezone:        req.Timezone,
+		ExpectedVersion: req.Version,
+	}
+	if req.Attributes != nil {
+		update.Attributes = req.Attributes.AsMap()
+	}
+
+	profile, err := h.userService.UpdateProfile(ctx, username, update)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	pbProfile, err := toPBProfile(profile)
+	if err != nil {
+		return nil, apierror.GRPC(err)
+	}
+
+	return &pb.UpdateProfileResponse{Profile: pbProfile}, nil
+}
+
+// authenticate проверяет access токен из метаданных и возвращает имя пользователя
+func (h *GRPCHandler) authenticate(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	token, ok := bearerTokenFromMetadata(ctx)
+	if !ok {
+		return "", apierror.New(codes.Unauthenticated, apierror.ReasonMissingToken, "missing bearer token")
+	}
+
+	claims, err := h.userService.ParseAccessToken(ctx, token)
+	if err != nil {
+		return "", tokenError(err, "invalid access token")
+	}
+
+	return claims.Username, nil
+}
+
+func toPBProfile(profile *service.User) (*pb.Profile, error) {
+	attributes, err := structpb.NewStruct(profile.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Profile{
+		Id:            profile.ID,
+		Username:      profile.Username,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+		DisplayName:   profile.DisplayName,
+		Locale:        profile.Locale,
+		Timezone:      profile.Timezone,
+		Attributes:    attributes,
+		Version:       profile.Version,
+		CreatedAt:     timestamppb.New(profile.CreatedAt),
+		UpdatedAt:     timestamppb.New(profile.UpdatedAt),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func authorizedContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestAuthService_GetMe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ParseAccessToken(gomock.Any(), "access-token").Return(&service.TokenClaims{Username: "admin"}, nil)
+	mockService.EXPECT().GetProfile(gomock.Any(), "admin").Return(&service.User{
+		ID:          1,
+		Username:    "admin",
+		DisplayName: "Administrator",
+		Locale:      "ru-RU",
+		Attributes:  map[string]interface{}{"department": "ops"},
+		Version:     3,
+	}, nil)
+
+	handler := NewGRPCHandler(mockService)
+	resp, err := handler.GetMe(authorizedContext("access-token"), &pb.GetMeRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Administrator", resp.Profile.DisplayName)
+	assert.Equal(t, "ru-RU", resp.Profile.Locale)
+	assert.Equal(t, int32(3), resp.Profile.Version)
+	assert.Equal(t, "ops", resp.Profile.Attributes.AsMap()["department"])
+}
+
+func TestAuthService_GetMeUnauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewGRPCHandler(service.NewMockUserService(ctrl))
+	_, err := handler.GetMe(context.Background(), &pb.GetMeRequest{})
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+}
+
+func TestAuthService_UpdateProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockErr      error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "successful update",
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "version conflict",
+			mockErr:      service.ErrVersionConflict,
+			expectedCode: codes.Aborted,
+		},
+		{
+			name:         "invalid locale",
+			mockErr:      fmt.Errorf("%w: locale", service.ErrInvalidProfile),
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			attributes, err := structpb.NewStruct(map[string]interface{}{"department": "ops"})
+			require.NoError(t, err)
+
+			expectedUpdate := service.ProfileUpdate{
+				Mask:            []string{"display_name", "attributes"},
+				DisplayName:     "New Name",
+				Attributes:      map[string]interface{}{"department": "ops"},
+				ExpectedVersion: 2,
+			}
+
+			mockService := service.NewMockUserService(ctrl)
+			mockService.EXPECT().ParseAccessToken(gomock.Any(), "access-token").Return(&service.TokenClaims{Username: "admin"}, nil)
+			call := mockService.EXPECT().UpdateProfile(gomock.Any(), "admin", expectedUpdate)
+			if tt.mockErr != nil {
+				call.Return(nil, tt.mockErr)
+			} else {
+				call.Return(&service.User{Username: "admin", DisplayName: "New Name", Version: 3}, nil)
+			}
+
+			handler := NewGRPCHandler(mockService)
+			resp, err := handler.UpdateProfile(authorizedContext("access-token"), &pb.UpdateProfileRequest{
+				UpdateMask:  &fieldmaskpb.FieldMask{Paths: []string{"display_name", "attributes"}},
+				DisplayName: "New Name",
+				Attributes:  attributes,
+				Version:     2,
+			})
+
+			if tt.expectedCode == codes.OK {
+				require.NoError(t, err)
+				assert.Equal(t, "New Name", resp.Profile.DisplayName)
+				assert.Equal(t, int32(3), resp.Profile.Version)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
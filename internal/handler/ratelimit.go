@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/logging"
+	"auth_test/internal/ratelimit"
+	"auth_test/pkg/metrics"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader - ключ метаданных с числом секунд до следующей попытки.
+// REST gateway отдаёт его как заголовок Retry-After
+const RetryAfterHeader = "retry-after"
+
+// RateLimitInterceptor отклоняет вызовы сверх лимитов limiter с кодом ResourceExhausted.
+// Должен стоять после RequestIDInterceptor: адрес клиента берётся из контекста запроса
+func RateLimitInterceptor(limiter *ratelimit.Limiter, m metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity := rateLimitIdentity(ctx)
+		if r, ok := req.(interface{ GetUsername() string }); ok {
+			identity.Username = r.GetUsername()
+		}
+
+		if err := checkRateLimit(ctx, limiter, m, info.FullMethod, identity, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor - RateLimitInterceptor для потоковых методов. Токен списывается
+// один раз на поток; политики по имени пользователя к потокам не применяются
+func RateLimitStreamInterceptor(limiter *ratelimit.Limiter, m metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkRateLimit(ss.Context(), limiter, m, info.FullMethod, rateLimitIdentity(ss.Context()), ss.SetHeader); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func rateLimitIdentity(ctx context.Context) ratelimit.Identity {
+	identity := ratelimit.Identity{IP: clientInfo(ctx).IP}
+	if cn, ok := ClientCertIdentity(ctx); ok {
+		identity.Client = cn
+	}
+	return identity
+}
+
+func checkRateLimit(ctx context.Context, limiter *ratelimit.Limiter, m metrics.Metrics, fullMethod string, identity ratelimit.Identity, setHeader func(metadata.MD) error) error {
+	err := limiter.Allow(ctx, fullMethod, identity)
+	if err == nil {
+		return nil
+	}
+
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) {
+		// Недоступный бэкенд лимитов не должен останавливать вход пользователей
+		logging.FromContext(ctx).Warn("Rate limit check failed, request allowed", logging.Err(err))
+		return nil
+	}
+
+	m.RateLimited(extractMethodName(fullMethod), limitErr.Policy.Key)
+	logging.FromContext(ctx).Info("Rate limit exceeded", "key", limitErr.Policy.Key, "retry_after", limitErr.RetryAfter.String())
+
+	setHeader(metadata.Pairs(RetryAfterHeader, strconv.Itoa(retryAfterSeconds(limitErr.RetryAfter))))
+	return rateLimitStatus(limitErr.RetryAfter)
+}
+
+func rateLimitStatus(retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if withDetails, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: apierror.ReasonRateLimited, Domain: apierror.Domain},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)},
+	); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// retryAfterSeconds округляет вверх: клиент, выждавший Retry-After, должен получить токен
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/ratelimit"
+	"auth_test/internal/service"
+	"auth_test/pkg/metrics"
+	"auth_test/pkg/pb"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestLimiter(t *testing.T, backend ratelimit.Backend, entries ...string) *ratelimit.Limiter {
+	t.Helper()
+	policies, err := ratelimit.ParsePolicies(entries)
+	require.NoError(t, err)
+	return ratelimit.New(backend, policies)
+}
+
+// startRateLimitedServer запускает AuthService с ограничением частоты и возвращает его адрес
+func startRateLimitedServer(t *testing.T, userService service.UserService, limiter *ratelimit.Limiter) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		RequestIDInterceptor(slog.Default()),
+		RateLimitInterceptor(limiter, metrics.Noop{}),
+	))
+	pb.RegisterAuthServiceServer(grpcServer, NewGRPCHandler(userService))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "alice", "wrong").Return(false, service.ErrInvalidCredentials).Times(2)
+
+	addr := startRateLimitedServer(t, mockService, newTestLimiter(t, ratelimit.NewMemoryBackend(),
+		"/auth.AuthService/Login:username:1/m:2",
+	))
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewAuthServiceClient(conn)
+
+	for range 2 {
+		_, err := client.Login(context.Background(), &pb.LoginRequest{Username: "alice", Password: "wrong"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	var header metadata.MD
+	_, err = client.Login(context.Background(), &pb.LoginRequest{Username: "alice", Password: "wrong"}, grpc.Header(&header))
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	assert.Equal(t, []string{"60"}, header.Get(RetryAfterHeader))
+
+	require.Len(t, st.Details(), 2)
+	assert.Equal(t, apierror.ReasonRateLimited, apierror.Reason(err))
+	retryInfo, ok := st.Details()[1].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, retryInfo.RetryDelay.AsDuration().Round(time.Second))
+}
+
+// Подбор пароля через ChangePassword ограничен так же, как через Login
+func TestRateLimitInterceptor_DefaultPoliciesChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ChangePassword(gomock.Any(), "alice", "wrong", "new-password").Return(service.ErrInvalidCredentials).Times(5)
+
+	addr := startRateLimitedServer(t, mockService, newTestLimiter(t, ratelimit.NewMemoryBackend(), ratelimit.DefaultPolicies...))
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewAuthServiceClient(conn)
+
+	req := &pb.ChangePasswordRequest{Username: "alice", CurrentPassword: "wrong", NewPassword: "new-password"}
+	for range 5 {
+		_, err := client.ChangePassword(context.Background(), req)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	_, err = client.ChangePassword(context.Background(), req)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, apierror.ReasonRateLimited, apierror.Reason(err))
+}
+
+func TestRateLimitInterceptor_Gateway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "alice", "wrong").Return(false, service.ErrInvalidCredentials)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Лимит по адресу: gateway передаёт адрес HTTP клиента в x-forwarded-for
+	addr := startRateLimitedServer(t, mockService, newTestLimiter(t, ratelimit.NewMemoryBackend(),
+		"/auth.AuthService/Login:ip:1/h:1",
+	))
+	gateway, err := NewGatewayHandler(ctx, addr, nil)
+	require.NoError(t, err)
+
+	login := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"username":"alice","password":"wrong"}`))
+		req.RemoteAddr = remoteAddr
+		// Секрет, переданный HTTP клиентом, заменяется настоящим
+		req.Header.Set("Grpc-Metadata-X-Gateway-Secret", "guess")
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("203.0.113.1:1000").Code)
+
+	rec := login("203.0.113.1:1001")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+	assert.Equal(t, apierror.ProblemContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"reason":"RATE_LIMITED"`)
+
+	// Другой адрес ограничивается отдельно
+	mockService.EXPECT().ValidateCredentials(gomock.Any(), "alice", "wrong").Return(false, service.ErrInvalidCredentials)
+	assert.Equal(t, http.StatusUnauthorized, login("203.0.113.2:1000").Code)
+}
+
+type unavailableBackend struct{}
+
+func (unavailableBackend) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimitInterceptor_BackendUnavailable(t *testing.T) {
+	interceptor := RateLimitInterceptor(newTestLimiter(t, unavailableBackend{}, "/:ip:1/h:1"), metrics.Noop{})
+
+	called := false
+	_, err := interceptor(context.Background(), &pb.LoginRequest{Username: "alice"},
+		&grpc.UnaryServerInfo{FullMethod: "/auth.AuthService/Login"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		})
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestRateLimitStreamInterceptor(t *testing.T) {
+	interceptor := RateLimitStreamInterceptor(newTestLimiter(t, ratelimit.NewMemoryBackend(), "/auth.AdminService/:client:1/m:1"), metrics.Noop{})
+	info := &grpc.StreamServerInfo{FullMethod: "/auth.AdminService/ExportUsers"}
+	handler := func(srv interface{}, stream grpc.ServerStream) error { return nil }
+
+	stream := &headerRecordingStream{ctx: contextWithClientCert("ops")}
+	require.NoError(t, interceptor(nil, stream, info, handler))
+
+	err := interceptor(nil, stream, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, stream.header.Get(RetryAfterHeader))
+}
+
+type headerRecordingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *headerRecordingStream) Context() context.Context { return s.ctx }
+
+func (s *headerRecordingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"auth_test/internal/logging"
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor превращает панику обработчика в codes.Internal и пишет её в лог со стеком.
+// Клиент получает только общее сообщение: текст паники может содержать данные запроса
+func RecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ctx, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor - RecoveryInterceptor для потоковых методов
+func RecoveryStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recoverPanic(ss.Context(), r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(ctx context.Context, r interface{}) error {
+	logging.FromContext(ctx).Error("Panic in gRPC handler",
+		"panic", fmt.Sprint(r),
+		"stack", string(debug.Stack()))
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"auth_test/internal/apierror"
+	"auth_test/internal/mail"
+	"auth_test/internal/service"
+	"auth_test/internal/store"
+	"auth_test/pkg/pb"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Регистрация через REST gateway, вход после подтверждения адреса из письма
+func TestAuthService_Register(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender := mail.NewMemorySender()
+	userService := service.NewUserService(store.NewInMemoryStore(), "test-secret", service.WithEmailVerification(sender, service.VerificationConfig{
+		LinkBaseURL:    "https://app.example.com/verify",
+		TokenTTL:       time.Hour,
+		ResendInterval: time.Minute,
+	}))
+	handler := NewGRPCHandler(userService)
+
+	gateway, err := NewGatewayHandler(ctx, startTestGRPCServer(t, userService), nil)
+	require.NoError(t, err)
+
+	register := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/register", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		gateway.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := register(`{"username":"bob","password":"bob-password","email":"bob@example.com"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = register(`{"username":"bob","password":"bob-password","email":"other@example.com"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Занятый адрес не раскрывается: ответ такой же, как при успешной регистрации
+	rec = register(`{"username":"bobby","password":"bob-password","email":"bob@example.com"}`)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = register(`{"username":"eve","password":"short","email":"eve@example.com"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, apierror.ReasonWeakPassword, problem.Reason)
+
+	login := &pb.LoginRequest{Username: "bob", Password: "bob-password"}
+	_, err = handler.Login(ctx, login)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, apierror.ReasonUserNotVerified, apierror.Reason(err))
+
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "bob@example.com", messages[0].To)
+	link, err := url.Parse(regexp.MustCompile(`https://\S+`).FindString(messages[0].Body))
+	require.NoError(t, err)
+
+	_, err = handler.VerifyEmail(ctx, &pb.VerifyEmailRequest{Token: link.Query().Get("token")})
+	require.NoError(t, err)
+
+	resp, err := handler.Login(ctx, login)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+}
+
+func TestAuthService_RegisterVerificationDisabled(t *testing.T) {
+	handler := NewGRPCHandler(service.NewUserService(store.NewInMemoryStore(), "test-secret"))
+
+	_, err := handler.Register(context.Background(), &pb.RegisterRequest{Username: "bob", Password: "bob-password", Email: "bob@example.com"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Equal(t, apierror.ReasonVerificationDisabled, apierror.Reason(err))
+}
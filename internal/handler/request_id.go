@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"auth_test/internal/logging"
+	"auth_test/internal/service"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// RequestIDHeader - заголовок (и ключ метаданных gRPC) с ID запроса
+const RequestIDHeader = "x-request-id"
+
+const maxRequestIDLength = 128
+
+// RequestIDInterceptor берёт ID запроса из метаданных (или создаёт новый), возвращает его клиенту
+// в заголовке ответа и кладёт в контекст логгер с request_id и method, а также адрес клиента для аудита
+func RequestIDInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, requestID := requestContext(ctx, logger, info.FullMethod)
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+		return handler(ctx, req)
+	}
+}
+
+func RequestIDStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, requestID := requestContext(ss.Context(), logger, info.FullMethod)
+		ss.SetHeader(metadata.Pairs(RequestIDHeader, requestID))
+
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func requestContext(ctx context.Context, logger *slog.Logger, fullMethod string) (context.Context, string) {
+	requestID := incomingRequestID(ctx)
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+
+	// trace_id связывает записи лога с трассировкой запроса
+	logger = logger.With("method", extractMethodName(fullMethod))
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		logger = logger.With("trace_id", span.SpanContext().TraceID().String())
+		span.SetAttributes(attribute.String("request.id", requestID))
+	}
+
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = service.WithClientInfo(ctx, clientInfo(ctx))
+	return logging.WithRequestID(ctx, requestID), requestID
+}
+
+// clientInfo определяет адрес и user agent клиента. Для запросов через REST gateway (подтверждённых
+// секретом gateway) адрес берётся из последнего значения x-forwarded-for, которое добавляет сам gateway
+func clientInfo(ctx context.Context) service.ClientInfo {
+	var info service.ClientInfo
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(info.IP); err == nil {
+			info.IP = host
+		}
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return info
+	}
+
+	if fromGateway(md) {
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			forwarded := strings.Split(values[len(values)-1], ",")
+			if last := strings.TrimSpace(forwarded[len(forwarded)-1]); net.ParseIP(last) != nil {
+				info.IP = last
+			}
+		}
+	}
+
+	if values := md.Get("grpcgateway-user-agent"); len(values) > 0 {
+		info.UserAgent = values[0]
+	} else if values := md.Get("user-agent"); len(values) > 0 {
+		info.UserAgent = values[0]
+	}
+	return info
+}
+
+// incomingRequestID принимает ID клиента, только если он безопасен для записи в лог
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(RequestIDHeader)
+	if len(values) == 0 || !isValidRequestID(values[0]) {
+		return ""
+	}
+	return values[0]
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -13,7 +13,3 @@ type VerifyResponse struct {
 	RefreshToken string `json:"refresh_token"`
 	TokenType    string `json:"token_type"`
 }
-
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"auth_test/internal/apierror"
 	"auth_test/internal/service"
 	"net/http"
 	"strings"
+
+	"google.golang.org/grpc/codes"
 )
 
 type VerifyHandler struct {
@@ -21,30 +24,30 @@ func (h *VerifyHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		JSONError(w, "Missing Authorization header", http.StatusUnauthorized)
+		missingToken(w, r, "Missing Authorization header")
 		return
 	}
 
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 {
-		JSONError(w, "Invalid Authorization format: expected 'Bearer <token>'", http.StatusUnauthorized)
+		missingToken(w, r, "Invalid Authorization format: expected 'Bearer <token>'")
 		return
 	}
 
 	if !strings.EqualFold(parts[0], "Bearer") {
-		JSONError(w, "Invalid Authorization scheme: expected Bearer", http.StatusUnauthorized)
+		missingToken(w, r, "Invalid Authorization scheme: expected Bearer")
 		return
 	}
 
 	token := strings.TrimSpace(parts[1])
 	if token == "" {
-		JSONError(w, "Authorization token is empty", http.StatusUnauthorized)
+		missingToken(w, r, "Authorization token is empty")
 		return
 	}
 
 	newAccessToken, err := h.userService.RefreshToken(ctx, token)
 	if err != nil {
-		JSONError(w, "Invalid or expired token", http.StatusUnauthorized)
+		apierror.WriteProblem(w, r, tokenError(err, "invalid or expired token"))
 		return
 	}
 
@@ -55,5 +58,8 @@ func (h *VerifyHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	JSONSuccess(w, response, http.StatusOK)
+}
 
+func missingToken(w http.ResponseWriter, r *http.Request, message string) {
+	apierror.WriteProblem(w, r, apierror.New(codes.Unauthenticated, apierror.ReasonMissingToken, message))
 }
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"auth_test/internal/service"
+	"auth_test/pkg/pb"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name         string
+		token        string
+		mockErr      error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "successful verification",
+			token:        "verification-token",
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "expired token",
+			token:        "expired-token",
+			mockErr:      service.ErrExpiredToken,
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "verification disabled",
+			token:        "verification-token",
+			mockErr:      service.ErrVerificationDisabled,
+			expectedCode: codes.Unimplemented,
+		},
+		{
+			name:         "server error",
+			token:        "verification-token",
+			mockErr:      errors.New("database connection failed"),
+			expectedCode: codes.Internal,
+		},
+		{
+			name:         "empty token",
+			token:        "",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := service.NewMockUserService(ctrl)
+			if tt.token != "" {
+				mockService.EXPECT().VerifyEmail(gomock.Any(), tt.token).Return(tt.mockErr)
+			}
+
+			handler := NewGRPCHandler(mockService)
+			resp, err := handler.VerifyEmail(context.Background(), &pb.VerifyEmailRequest{Token: tt.token})
+
+			if tt.expectedCode == codes.OK {
+				require.NoError(t, err)
+				assert.Equal(t, "Email verified", resp.Message)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
+
+func TestAuthService_ResendVerification(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		mockErr      error
+		expectedCode codes.Code
+	}{
+		{
+			name:         "email sent",
+			email:        "user@example.com",
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "rate limited",
+			email:        "user@example.com",
+			mockErr:      service.ErrTooManyRequests,
+			expectedCode: codes.ResourceExhausted,
+		},
+		{
+			name:         "empty email",
+			email:        "",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := service.NewMockUserService(ctrl)
+			if tt.email != "" {
+				mockService.EXPECT().ResendVerification(gomock.Any(), tt.email).Return(tt.mockErr)
+			}
+
+			handler := NewGRPCHandler(mockService)
+			_, err := handler.ResendVerification(context.Background(), &pb.ResendVerificationRequest{Email: tt.email})
+
+			if tt.expectedCode == codes.OK {
+				require.NoError(t, err)
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCode, st.Code())
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"auth_test/internal/apierror"
 	"auth_test/internal/service"
 	"auth_test/pkg/pb"
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestAuthService_VerifyToken(t *testing.T) {
@@ -103,3 +107,16 @@ func TestAuthService_VerifyToken(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthService_VerifyTokenUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUserService(ctrl)
+	mockService.EXPECT().RefreshToken(gomock.Any(), "refresh-token").Return("", fmt.Errorf("%w: connection refused", service.ErrUnavailable))
+
+	// Сбой хранилища не выдаётся за недействительный токен
+	_, err := NewGRPCHandler(mockService).VerifyToken(context.Background(), &pb.VerifyTokenRequest{Token: "refresh-token"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, apierror.ReasonUnavailable, apierror.Reason(err))
+}
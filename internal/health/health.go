@@ -0,0 +1,193 @@
+// Package health отвечает на проверки живости и готовности: стандартный сервис grpc.health.v1
+// и HTTP эндпоинты /healthz и /readyz для Kubernetes и балансировщиков
+package health
+
+import (
+	"auth_test/internal/logging"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// Сервис останавливается и не принимает новые запросы
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc проверяет одну зависимость; nil - зависимость готова
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+type Checker struct {
+	timeout  time.Duration
+	checks   []check
+	services []string
+	grpc     *health.Server
+
+	shuttingDown atomic.Bool
+}
+
+// New создаёт проверку готовности. services - полные имена gRPC сервисов, статус которых
+// публикуется в grpc.health.v1 вместе с общим статусом сервера (пустое имя)
+func New(timeout time.Duration, services ...string) *Checker {
+	c := &Checker{
+		timeout:  timeout,
+		services: append([]string{""}, services...),
+		grpc:     health.NewServer(),
+	}
+	// До первой проверки сервер не готов
+	c.setServing(false)
+	return c
+}
+
+// Add регистрирует проверку готовности
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// GRPC возвращает реализацию grpc.health.v1 для регистрации на gRPC сервере
+func (c *Checker) GRPC() healthpb.HealthServer {
+	return c.grpc
+}
+
+// Check выполняет все проверки параллельно, каждую не дольше timeout
+func (c *Checker) Check(ctx context.Context) *Report {
+	if c.shuttingDown.Load() {
+		return &Report{Status: StatusShuttingDown, Checks: []CheckResult{}}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := &Report{Status: StatusOK, Checks: make([]CheckResult, len(c.checks))}
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			result := CheckResult{Name: chk.name, Status: StatusOK}
+			if err := chk.fn(ctx); err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+			result.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+			report.Checks[i] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Run периодически выполняет проверки и обновляет статус grpc.health.v1. Блокируется до отмены ctx
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last string
+	for {
+		// Статус и лог меняются только при переходе между ok и fail
+		if report := c.Check(ctx); report.Status != last && report.Status != StatusShuttingDown {
+			last = report.Status
+			c.setServing(report.Status == StatusOK)
+			logReadiness(ctx, report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown переводит сервис в NOT_SERVING до конца работы процесса. Вызывается в начале
+// остановки, чтобы балансировщики перестали направлять новые запросы
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+	c.grpc.Shutdown()
+}
+
+func (c *Checker) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	// После Shutdown health.Server игнорирует изменения статуса
+	for _, service := range c.services {
+		c.grpc.SetServingStatus(service, status)
+	}
+}
+
+// LivenessHandler (/healthz) отвечает 200, пока процесс способен обрабатывать HTTP запросы.
+// Зависимости не проверяются: их недоступность не лечится перезапуском
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadinessHandler (/readyz) отвечает 200 только если все зависимости готовы и сервис
+// не останавливается, иначе 503 с результатами проверок
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Check(r.Context())
+
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+func logReadiness(ctx context.Context, report *Report) {
+	logger := logging.FromContext(ctx)
+	if report.Status == StatusOK {
+		logger.Info("Service is ready")
+		return
+	}
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			logger.Warn("Readiness check failed", "check", result.Name, "error", result.Error)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestChecker_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		dbErr      error
+		shutdown   bool
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "ready", wantCode: http.StatusOK, wantStatus: StatusOK},
+		{name: "database down", dbErr: errors.New("connection refused"), wantCode: http.StatusServiceUnavailable, wantStatus: StatusFail},
+		{name: "shutting down", shutdown: true, wantCode: http.StatusServiceUnavailable, wantStatus: StatusShuttingDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := New(time.Second)
+			checker.Add("database", func(context.Context) error { return tt.dbErr })
+			checker.Add("signing_key", func(context.Context) error { return nil })
+			if tt.shutdown {
+				checker.Shutdown()
+			}
+
+			rec := httptest.NewRecorder()
+			checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.wantCode, rec.Code)
+
+			var report Report
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+			assert.Equal(t, tt.wantStatus, report.Status)
+			if tt.dbErr != nil {
+				assert.Equal(t, CheckResult{Name: "database", Status: StatusFail, Error: tt.dbErr.Error()}, withoutDuration(report.Checks[0]))
+				assert.Equal(t, StatusOK, report.Checks[1].Status)
+			}
+
+			// Живость не зависит от готовности
+			rec = httptest.NewRecorder()
+			checker.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestChecker_CheckTimeout(t *testing.T) {
+	checker := New(10 * time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Check(context.Background())
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestChecker_GRPCStatus(t *testing.T) {
+	const service = "auth.AuthService"
+
+	var dbErr error
+	checker := New(time.Second, service)
+	checker.Add("database", func(context.Context) error { return dbErr })
+
+	grpcStatus := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := checker.GRPC().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// До первой проверки сервер не готов
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx, time.Hour)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		return grpcStatus("") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(service))
+	cancel()
+	<-done
+
+	// Неизвестный сервис
+	_, err := checker.GRPC().Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown.Service"})
+	assert.Error(t, err)
+
+	dbErr = errors.New("connection refused")
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	checker.Run(ctx, time.Hour)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(service))
+
+	// После Shutdown статус не возвращается в SERVING
+	dbErr = nil
+	checker.Shutdown()
+	checker.Run(ctx, time.Hour)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(service))
+}
+
+func withoutDuration(result CheckResult) CheckResult {
+	result.DurationMS = 0
+	return result
+}
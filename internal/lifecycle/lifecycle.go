@@ -0,0 +1,191 @@
+// Package lifecycle запускает серверы и фоновые задачи сервиса под одним контекстом и
+// останавливает их в нужном порядке: сначала перестают приниматься запросы и дорабатывают
+// начатые, затем завершаются фоновые задачи и только потом закрываются ресурсы (база и т.п.)
+package lifecycle
+
+import (
+	"auth_test/internal/logging"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type server struct {
+	name     string
+	serve    func() error
+	shutdown func(ctx context.Context) error
+}
+
+type job struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type Manager struct {
+	drainTimeout time.Duration
+
+	servers []server
+	jobs    []job
+	// Вызываются в начале остановки, до того как серверы перестанут принимать запросы
+	stopping []func()
+	// Вызываются после остановки серверов и задач, в обратном порядке регистрации
+	hooks []hook
+}
+
+// New создаёт менеджер. drainTimeout ограничивает время, за которое серверы должны
+// завершить начатые запросы; после него соединения закрываются принудительно
+func New(drainTimeout time.Duration) *Manager {
+	return &Manager{drainTimeout: drainTimeout}
+}
+
+// AddServer регистрирует сервер. serve блокируется до остановки; http.ErrServerClosed
+// считается штатным завершением. shutdown должен дождаться начатых запросов или отмены ctx
+func (m *Manager) AddServer(name string, serve func() error, shutdown func(ctx context.Context) error) {
+	m.servers = append(m.servers, server{name: name, serve: serve, shutdown: shutdown})
+}
+
+// Go регистрирует фоновую задачу. Её контекст отменяется при остановке, и менеджер
+// дожидается её завершения перед вызовом OnShutdown
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.jobs = append(m.jobs, job{name: name, run: run})
+}
+
+// OnStopping регистрирует функцию, вызываемую сразу после сигнала остановки
+func (m *Manager) OnStopping(fn func()) {
+	m.stopping = append(m.stopping, fn)
+}
+
+// OnShutdown регистрирует освобождение ресурса. Функции вызываются в обратном порядке:
+// то, что зарегистрировано первым (например, пул соединений с базой), закрывается последним
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Run запускает всё зарегистрированное и блокируется до отмены ctx (сигнал остановки) или
+// до ошибки любого из серверов, после чего останавливает сервис. Возвращает ошибку сервера,
+// из-за которой началась остановка, и ошибки самой остановки
+func (m *Manager) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var jobs sync.WaitGroup
+	for _, j := range m.jobs {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			j.run(jobCtx)
+		}()
+	}
+
+	serveErrs := make(chan error, len(m.servers))
+	for _, s := range m.servers {
+		go func() {
+			err := s.serve()
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			if err != nil {
+				err = fmt.Errorf("%s: %w", s.name, err)
+			}
+			serveErrs <- err
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutdown signal received, draining", "drain_timeout", m.drainTimeout.String())
+	case runErr = <-serveErrs:
+		if runErr == nil {
+			runErr = errors.New("server stopped unexpectedly")
+		}
+		logger.Error("Server failed, shutting down", logging.Err(runErr))
+	}
+
+	for _, fn := range m.stopping {
+		fn()
+	}
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancelDrain()
+
+	errs := []error{runErr}
+	errs = append(errs, m.shutdownServers(drainCtx)...)
+
+	cancelJobs()
+	if !waitGroup(drainCtx, &jobs) {
+		errs = append(errs, errors.New("background jobs did not stop before the drain timeout"))
+	}
+
+	// Ресурсам даётся отдельный таймаут: они должны закрыться, даже если серверы
+	// израсходовали весь drainTimeout
+	hookCtx, cancelHooks := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancelHooks()
+	for i := len(m.hooks) - 1; i >= 0; i-- {
+		if err := m.hooks[i].fn(hookCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.hooks[i].name, err))
+		}
+	}
+
+	logger.Info("Shutdown complete")
+	return errors.Join(errs...)
+}
+
+func (m *Manager) shutdownServers(ctx context.Context) []error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for _, s := range m.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.shutdown(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s shutdown: %w", s.name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// waitGroup ждёт wg, но не дольше ctx. false - если ctx истёк раньше
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ShutdownHTTP останавливает http.Server: новые соединения не принимаются, начатые запросы
+// дорабатывают до истечения ctx, после чего оставшиеся соединения закрываются
+func ShutdownHTTP(srv *http.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := srv.Shutdown(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			srv.Close()
+		}
+		return err
+	}
+}
@@ -0,0 +1,145 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startSlowServer регистрирует HTTP сервер, обработчик которого ждёт release
+func startSlowServer(t *testing.T, m *Manager, release <-chan struct{}) (string, <-chan struct{}) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{}, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	})}
+	m.AddServer("slow", func() error { return server.Serve(listener) }, ShutdownHTTP(server))
+
+	return "http://" + listener.Addr().String(), started
+}
+
+func TestManager_GracefulShutdown(t *testing.T) {
+	m := New(5 * time.Second)
+	release := make(chan struct{})
+	url, started := startSlowServer(t, m, release)
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	m.Go("job", func(ctx context.Context) {
+		<-ctx.Done()
+		record("job")
+	})
+	m.OnStopping(func() { record("stopping") })
+	m.OnShutdown("database", func(context.Context) error {
+		record("database")
+		return nil
+	})
+	m.OnShutdown("tracing", func(context.Context) error {
+		record("tracing")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- m.Run(ctx) }()
+
+	// Запрос, начатый до сигнала, должен завершиться успешно
+	respErr := make(chan error, 1)
+	var body []byte
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			body, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		respErr <- err
+	}()
+	<-started
+
+	cancel()
+	select {
+	case <-runErr:
+		t.Fatal("Run returned before the in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-respErr)
+	assert.Equal(t, "done", string(body))
+	require.NoError(t, <-runErr)
+
+	assert.Equal(t, []string{"stopping", "job", "tracing", "database"}, order,
+		"jobs stop after the servers, resources close in reverse order")
+}
+
+func TestManager_DrainTimeout(t *testing.T) {
+	m := New(100 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	url, started := startSlowServer(t, m, release)
+
+	closed := false
+	m.OnShutdown("database", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- m.Run(ctx) }()
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	err := <-runErr
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, closed, "resources are released even after the drain timeout")
+}
+
+func TestManager_ServerFailure(t *testing.T) {
+	m := New(time.Second)
+
+	stopped := make(chan struct{})
+	m.AddServer("healthy", func() error {
+		<-stopped
+		return http.ErrServerClosed
+	}, func(context.Context) error {
+		close(stopped)
+		return nil
+	})
+	m.AddServer("broken", func() error {
+		return errors.New("address already in use")
+	}, func(context.Context) error { return nil })
+
+	err := m.Run(context.Background())
+	assert.ErrorContains(t, err, "broken: address already in use")
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("other servers must be stopped when one fails")
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// JWT (header.payload.signature), значения заголовка Authorization и пароль в строке подключения к базе
+	jwtPattern      = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern   = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	dsnPassPattern  = regexp.MustCompile(`(?i)\bpassword=\S+`)
+	urlUserinfoPass = regexp.MustCompile(`(://[^:/@\s]+):[^@/\s]+@`)
+)
+
+// Ключи, значения которых не выводятся никогда
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"token":         true,
+	"jwt":           true,
+}
+
+// Ключи с идентификаторами пользователей, которые псевдонимизируются при RedactUsernames
+var identityKeys = map[string]bool{
+	"username": true,
+	"user":     true,
+	"subject":  true,
+	"email":    true,
+	"actor":    true,
+}
+
+// redactingHandler убирает секреты из сообщений и атрибутов перед передачей во вложенный обработчик.
+// Пароли и токены редактируются всегда; имена пользователей и адреса - если включено redactUsernames
+type redactingHandler struct {
+	next           slog.Handler
+	redactUsername bool
+}
+
+func newRedactingHandler(next slog.Handler, redactUsernames bool) *redactingHandler {
+	return &redactingHandler{next: next, redactUsername: redactUsernames}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		clean = append(clean, h.redact(a))
+	}
+	return &redactingHandler{next: h.next.WithAttrs(clean), redactUsername: h.redactUsername}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), redactUsername: h.redactUsername}
+}
+
+func (h *redactingHandler) redact(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		clean := make([]slog.Attr, 0, len(group))
+		for _, ga := range group {
+			clean = append(clean, h.redact(ga))
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+	}
+
+	key := strings.ToLower(a.Key)
+	if isSensitiveKey(key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		value := a.Value.String()
+		if h.redactUsername && identityKeys[key] {
+			return slog.String(a.Key, pseudonym(key, value))
+		}
+		return slog.String(a.Key, scrub(value))
+	case slog.KindAny:
+		// Ошибки и прочие значения выводятся строкой, в которой тоже может оказаться токен
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, scrub(err.Error()))
+		}
+	}
+	return a
+}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[key] ||
+		strings.Contains(key, "password") ||
+		strings.Contains(key, "secret") ||
+		strings.HasSuffix(key, "_token")
+}
+
+// scrub вырезает из текста JWT, учётные данные заголовка Authorization и пароли строк подключения
+func scrub(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = dsnPassPattern.ReplaceAllString(s, "password="+redacted)
+	return urlUserinfoPass.ReplaceAllString(s, "$1:"+redacted+"@")
+}
+
+// pseudonym позволяет связывать записи одного пользователя, не раскрывая имя.
+// Префикс actor вида "user:" или "mtls:" сохраняется
+func pseudonym(key, value string) string {
+	if value == "" {
+		return value
+	}
+	prefix := ""
+	if i := strings.IndexByte(value, ':'); key == "actor" && i > 0 {
+		prefix, value = value[:i+1], value[i+1:]
+	}
+	sum := sha256.Sum256([]byte(value))
+	return prefix + "u_" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender сохраняет каждое письмо отдельным .eml файлом, для локальной разработки
+type FileSender struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail file dir is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail dir: %w", err)
+	}
+
+	return &FileSender{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := formatMessage(s.from, msg)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), s.seq.Add(1))
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidMessage = errors.New("invalid mail message")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письма. Реализации: SMTP для production, файл и память для разработки и тестов
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Config struct {
+	// smtp, file или memory
+	Driver string
+	From   string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	FileDir string
+}
+
+func NewSender(cfg Config) (Sender, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return NewFileSender(cfg.FileDir, cfg.From)
+	case "memory":
+		return NewMemorySender(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySender(t *testing.T) {
+	sender := NewMemorySender()
+
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "body"}
+	require.NoError(t, sender.Send(context.Background(), msg))
+
+	assert.Equal(t, []Message{msg}, sender.Messages())
+}
+
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+
+	sender, err := NewFileSender(dir, "noreply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(context.Background(), Message{To: "user@example.com", Subject: "Verify", Body: "link"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: user@example.com\r\n")
+	assert.Contains(t, string(data), "Subject: Verify\r\n")
+	assert.Contains(t, string(data), "\r\n\r\nlink")
+}
+
+func TestFormatMessage(t *testing.T) {
+	data, err := formatMessage("noreply@example.com", Message{To: "user@example.com", Subject: "Подтверждение адреса", Body: "Ссылка"})
+	require.NoError(t, err)
+
+	header, body, ok := strings.Cut(string(data), "\r\n\r\n")
+	require.True(t, ok)
+	assert.Equal(t, "Ссылка", body)
+	for _, r := range header {
+		require.Less(t, r, rune(128), "headers must be ASCII")
+	}
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Подтверждение адреса", subject)
+
+	for _, bad := range []Message{
+		{To: "user@example.com\r\nBcc: victim@example.com", Subject: "Hello"},
+		{To: "user@example.com", Subject: "Hello\nBcc: victim@example.com"},
+	} {
+		_, err := formatMessage("noreply@example.com", bad)
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "memory", cfg: Config{Driver: "memory"}},
+		{name: "smtp", cfg: Config{Driver: "smtp", SMTPHost: "localhost", SMTPPort: "25"}},
+		{name: "file without dir", cfg: Config{Driver: "file"}, wantErr: true},
+		{name: "unknown driver", cfg: Config{Driver: "pigeon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := NewSender(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, sender)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package migrate применяет встроенные миграции схемы.
+// Версия хранится в таблице schema_migrations в формате golang-migrate,
+// поэтому базы, размеченные внешней утилитой migrate, подхватываются без изменений
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrSchemaBehind = errors.New("database schema is behind")
+	ErrDirty        = errors.New("database schema is dirty")
+	ErrNoMigration  = errors.New("migration file not found")
+)
+
+var migrationName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration - одна версия схемы и скрипты перехода к ней и обратно
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+// Status - текущее состояние схемы относительно встроенных миграций
+type Status struct {
+	// 0, если ни одна миграция не применена
+	Current uint
+	Latest  uint
+	Dirty   bool
+	Pending []Migration
+}
+
+// driver выполняет миграции в конкретной СУБД
+type driver interface {
+	// lock не даёт нескольким репликам мигрировать одновременно. Возвращает драйвер, работающий
+	// под блокировкой, и функцию её снятия; сам драйвер не меняется, поэтому Ready можно вызывать параллельно
+	lock(ctx context.Context) (driver, func(), error)
+	ensureVersionTable(ctx context.Context) error
+	// version не изменяет схему: отсутствие таблицы версий означает версию 0. Status и Ready
+	// вызываются с правами приложения, у которого может не быть права CREATE
+	version(ctx context.Context) (uint, bool, error)
+	// apply выполняет скрипт и записывает новую версию в одной транзакции
+	apply(ctx context.Context, script string, version uint) error
+}
+
+type Migrator struct {
+	driver     driver
+	migrations []Migration
+	loadErr    error
+}
+
+func newMigrator(source fs.FS, drv driver) *Migrator {
+	migrations, err := loadMigrations(source)
+	return &Migrator{
+		driver:     drv,
+		migrations: migrations,
+		loadErr:    err,
+	}
+}
+
+// Up применяет все недостающие миграции
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(locked driver, current uint) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if err := locked.apply(ctx, migration.up, migration.Version); err != nil {
+				return fmt.Errorf("apply %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(locked driver, current uint) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if migration.down == "" {
+				return fmt.Errorf("%w: %d_%s.down.sql", ErrNoMigration, migration.Version, migration.Name)
+			}
+
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := locked.apply(ctx, migration.down, previous); err != nil {
+				return fmt.Errorf("revert %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("Reverted migration %d_%s", migration.Version, migration.Name)
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status только читает версию; таблица schema_migrations создаётся в Up и Down
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	if m.loadErr != nil {
+		return nil, m.loadErr
+	}
+
+	current, dirty, err := m.driver.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Current: current,
+		Dirty:   dirty,
+	}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > current {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Check возвращает ErrSchemaBehind, если в базе применены не все встроенные миграции
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, status.Current)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaBehind, status.Current, status.Latest)
+	}
+	if status.Current > status.Latest {
+		log.Printf("Database schema version %d is newer than the latest known migration %d", status.Current, status.Latest)
+	}
+	return nil
+}
+
+// Ready - проверка готовности: схема не должна отставать от встроенных миграций или быть dirty.
+// Схема новее известной (во время выкатки новой версии) допустима
+func (m *Migrator) Ready(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, status.Current)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaBehind, status.Current, status.Latest)
+	}
+	return nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(locked driver, current uint) error) error {
+	if m.loadErr != nil {
+		return m.loadErr
+	}
+
+	locked, unlock, err := m.driver.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer unlock()
+
+	if err := locked.ensureVersionTable(ctx); err != nil {
+		return err
+	}
+
+	// Версию читаем под блокировкой: другая реплика могла успеть применить миграции
+	current, dirty, err := locked.version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d, fix it manually", ErrDirty, current)
+	}
+
+	return fn(locked, current)
+}
+
+func loadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		script, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("conflicting names for migration %d: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" {
+			return nil, fmt.Errorf("%w: %d_%s.up.sql", ErrNoMigration, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testSource() fstest.MapFS {
+	return fstest.MapFS{
+		"001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+		"001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"002_add_name.up.sql":       {Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;\nCREATE INDEX idx_items_name ON items(name);")},
+		"002_add_name.down.sql":     {Data: []byte("DROP INDEX idx_items_name;\nALTER TABLE items DROP COLUMN name;")},
+		"README.md":                 {Data: []byte("not a migration")},
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(testSource(), &sqliteDriver{db: db})
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), status.Current)
+	assert.Equal(t, uint(2), status.Latest)
+	assert.Len(t, status.Pending, 2)
+	assert.ErrorIs(t, m.Check(ctx), ErrSchemaBehind)
+	assert.ErrorIs(t, m.Ready(ctx), ErrSchemaBehind)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	require.NoError(t, m.Check(ctx))
+	require.NoError(t, m.Ready(ctx))
+
+	_, err = db.Exec("INSERT INTO items (id, name) VALUES (1, 'a')")
+	require.NoError(t, err)
+
+	// Повторный запуск ничего не делает
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, uint(2), reverted[0].Version)
+
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Current)
+	assert.ErrorIs(t, m.Check(ctx), ErrSchemaBehind)
+
+	reverted, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), status.Current)
+}
+
+// lockCheckingDriver проверяет, что миграции применяются только через драйвер, полученный из lock
+type lockCheckingDriver struct {
+	*sqliteDriver
+	t      *testing.T
+	locked bool
+}
+
+func (d *lockCheckingDriver) lock(ctx context.Context) (driver, func(), error) {
+	return &lockCheckingDriver{sqliteDriver: d.sqliteDriver, t: d.t, locked: true}, func() {}, nil
+}
+
+func (d *lockCheckingDriver) apply(ctx context.Context, script string, version uint) error {
+	if !d.locked {
+		d.t.Errorf("migration %d applied outside the lock", version)
+	}
+	return d.sqliteDriver.apply(ctx, script, version)
+}
+
+// Проверка готовности идёт параллельно с миграциями и не должна использовать их соединение
+func TestMigrator_ReadyDuringUp(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(testSource(), &lockCheckingDriver{sqliteDriver: &sqliteDriver{db: db}, t: t})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				m.Ready(ctx)
+			}
+		}
+	}()
+
+	applied, err := m.Up(ctx)
+	close(done)
+	wg.Wait()
+
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.NoError(t, m.Ready(ctx))
+}
+
+// Проверка схемы выполняется с правами приложения и не должна создавать таблицы
+func TestMigrator_StatusIsReadOnly(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(testSource(), &sqliteDriver{db: db})
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), status.Current)
+	assert.ErrorIs(t, m.Check(ctx), ErrSchemaBehind)
+	assert.ErrorIs(t, m.Ready(ctx), ErrSchemaBehind)
+
+	var tables int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables))
+	assert.Zero(t, tables)
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	source := testSource()
+	source["002_add_name.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;\nSELECT * FROM missing_table;")}
+	m := newMigrator(source, &sqliteDriver{db: db})
+
+	_, err := m.Up(ctx)
+	require.Error(t, err)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Current)
+	assert.False(t, status.Dirty)
+
+	// Частично выполненный скрипт не оставил следов
+	_, err = db.Exec("SELECT name FROM items")
+	assert.Error(t, err)
+}
+
+func TestMigrator_DirtySchema(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := newMigrator(testSource(), &sqliteDriver{db: db})
+
+	_, err := m.Up(ctx)
+	require.NoError(t, err)
+
+	// Так оставляет базу golang-migrate после сбоя
+	_, err = db.Exec("UPDATE schema_migrations SET dirty = 1")
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrDirty)
+	assert.ErrorIs(t, m.Check(ctx), ErrDirty)
+	assert.ErrorIs(t, m.Ready(ctx), ErrDirty)
+}
+
+func TestLoadMigrations_MissingUp(t *testing.T) {
+	_, err := loadMigrations(fstest.MapFS{
+		"001_init.down.sql": {Data: []byte("DROP TABLE items;")},
+	})
+	assert.ErrorIs(t, err, ErrNoMigration)
+}
+
+func TestEmbeddedSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	m := NewSQLite(db)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+	require.NoError(t, m.Check(ctx))
+
+	// Каждая миграция должна откатываться
+	reverted, err := m.Down(ctx, len(applied))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(applied))
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+}
+
+func TestEmbeddedMigrationsMatch(t *testing.T) {
+	pg := NewPostgres(nil)
+	sqlite := NewSQLite(nil)
+	require.NoError(t, pg.loadErr)
+	require.NoError(t, sqlite.loadErr)
+
+	require.Equal(t, len(pg.migrations), len(sqlite.migrations), "every Postgres migration needs a SQLite counterpart")
+	for i := range pg.migrations {
+		assert.Equal(t, pg.migrations[i].Version, sqlite.migrations[i].Version)
+		assert.Equal(t, pg.migrations[i].Name, sqlite.migrations[i].Name)
+	}
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"auth_test/migrations"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockID - ключ pg_advisory_lock, общий для всех реплик сервиса
+const advisoryLockID int64 = 0x61757468_6d696772 // "authmigr"
+
+type pgExecutor interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type postgresDriver struct {
+	pool *pgxpool.Pool
+	// соединение, удерживающее advisory lock. Задано только у драйвера, возвращённого lock:
+	// миграции выполняются через него, а Status и Ready общего драйвера - через пул
+	conn *pgxpool.Conn
+}
+
+// NewPostgres создаёт мигратор для встроенных миграций PostgreSQL
+func NewPostgres(pool *pgxpool.Pool) *Migrator {
+	return newMigrator(migrations.Postgres, &postgresDriver{pool: pool})
+}
+
+func (d *postgresDriver) db() pgExecutor {
+	if d.conn != nil {
+		return d.conn
+	}
+	return d.pool
+}
+
+func (d *postgresDriver) lock(ctx context.Context) (driver, func(), error) {
+	conn, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Блокировка сессионная: другие реплики ждут, пока миграции не закончатся
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		conn.Release()
+		return nil, nil, err
+	}
+
+	return &postgresDriver{pool: d.pool, conn: conn}, func() {
+		// Контекст вызова может быть уже отменён, а блокировку нужно снять в любом случае
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID); err != nil {
+			// Соединение с зависшей блокировкой нельзя возвращать в пул
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, nil
+}
+
+func (d *postgresDriver) ensureVersionTable(ctx context.Context) error {
+	_, err := d.db().Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    dirty BOOLEAN NOT NULL
+)`)
+	return err
+}
+
+func (d *postgresDriver) version(ctx context.Context) (uint, bool, error) {
+	// Таблица ищется по search_path, как и в запросах ниже
+	var exists bool
+	if err := d.db().QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var version int64
+	var dirty bool
+	err := d.db().QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+func (d *postgresDriver) apply(ctx context.Context, script string, version uint) error {
+	tx, err := d.db().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Без аргументов pgx использует simple protocol, поэтому скрипт может содержать несколько команд
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", int64(version)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
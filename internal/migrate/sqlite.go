@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"auth_test/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+)
+
+type sqliteDriver struct {
+	db *sql.DB
+}
+
+// NewSQLite создаёт мигратор для встроенных миграций SQLite
+func NewSQLite(db *sql.DB) *Migrator {
+	source, err := fs.Sub(migrations.SQLite, "sqlite")
+	if err != nil {
+		return &Migrator{loadErr: err}
+	}
+	return newMigrator(source, &sqliteDriver{db: db})
+}
+
+// lock ничего не делает: SQLite сериализует запись, а файл базы принадлежит одному процессу
+func (d *sqliteDriver) lock(ctx context.Context) (driver, func(), error) {
+	return d, func() {}, nil
+}
+
+func (d *sqliteDriver) ensureVersionTable(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER NOT NULL PRIMARY KEY,
+    dirty INTEGER NOT NULL
+)`)
+	return err
+}
+
+func (d *sqliteDriver) version(ctx context.Context) (uint, bool, error) {
+	var exists bool
+	err := d.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')").Scan(&exists)
+	if err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var version int64
+	var dirty bool
+	err = d.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
+func (d *sqliteDriver) apply(ctx context.Context, script string, version uint) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES (?, 0)", int64(version)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
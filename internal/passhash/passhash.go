@@ -0,0 +1,295 @@
+// Package passhash проверяет пароли по хешам в форматах, которые встречаются при переносе
+// пользователей из других систем. Новые хеши всегда bcrypt; устаревшие форматы (PBKDF2, SHA)
+// нужно заменить bcrypt хешем после первого успешного входа (см. NeedsUpgrade)
+package passhash
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Algorithm string
+
+const (
+	Bcrypt   Algorithm = "bcrypt"
+	Argon2id Algorithm = "argon2id"
+	Argon2i  Algorithm = "argon2i"
+	// Django: pbkdf2_sha256$<iterations>$<salt>$<base64 hash>
+	PBKDF2SHA1   Algorithm = "pbkdf2_sha1"
+	PBKDF2SHA256 Algorithm = "pbkdf2_sha256"
+	// passlib: $pbkdf2-sha512$<iterations>$<ab64 salt>$<ab64 hash>
+	PBKDF2SHA512 Algorithm = "pbkdf2_sha512"
+	// LDAP (RFC 2307): {SHA}, {SSHA}, {SSHA256}, {SSHA512} - base64(digest + salt)
+	SHA1    Algorithm = "sha1"
+	SSHA1   Algorithm = "ssha1"
+	SHA256  Algorithm = "sha256"
+	SSHA256 Algorithm = "ssha256"
+	SHA512  Algorithm = "sha512"
+	SSHA512 Algorithm = "ssha512"
+)
+
+// Ограничения защищают вход от хешей с заведомо дорогими параметрами
+const (
+	maxPBKDF2Iterations = 10_000_000
+	maxArgon2Memory     = 512 * 1024 // KiB
+	maxArgon2Time       = 16
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown password hash format")
+	ErrInvalidHash   = errors.New("invalid password hash")
+)
+
+// Hash возвращает bcrypt хеш пароля
+func Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// Identify определяет алгоритм и проверяет, что хеш разбирается
+func Identify(encoded string) (Algorithm, error) {
+	h, err := parse(encoded)
+	if err != nil {
+		return "", err
+	}
+	return h.algorithm, nil
+}
+
+// Verify сравнивает пароль с хешем любого поддерживаемого формата
+func Verify(encoded, password string) (bool, error) {
+	h, err := parse(encoded)
+	if err != nil {
+		return false, err
+	}
+	return h.verify(password)
+}
+
+// NeedsUpgrade сообщает, что хеш устаревшего формата нужно заменить bcrypt хешем
+func NeedsUpgrade(encoded string) bool {
+	algorithm, err := Identify(encoded)
+	if err != nil {
+		return false
+	}
+	return algorithm != Bcrypt && algorithm != Argon2id && algorithm != Argon2i
+}
+
+type parsedHash struct {
+	algorithm Algorithm
+	verify    func(password string) (bool, error)
+}
+
+func parse(encoded string) (parsedHash, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return parseBcrypt(encoded)
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return parseArgon2(Argon2id, encoded)
+	case strings.HasPrefix(encoded, "$argon2i$"):
+		return parseArgon2(Argon2i, encoded)
+	case strings.HasPrefix(encoded, "pbkdf2_sha1$"):
+		return parseDjangoPBKDF2(PBKDF2SHA1, sha1.New, encoded)
+	case strings.HasPrefix(encoded, "pbkdf2_sha256$"):
+		return parseDjangoPBKDF2(PBKDF2SHA256, sha256.New, encoded)
+	case strings.HasPrefix(encoded, "$pbkdf2$"):
+		return parsePasslibPBKDF2(PBKDF2SHA1, sha1.New, encoded)
+	case strings.HasPrefix(encoded, "$pbkdf2-sha256$"):
+		return parsePasslibPBKDF2(PBKDF2SHA256, sha256.New, encoded)
+	case strings.HasPrefix(encoded, "$pbkdf2-sha512$"):
+		return parsePasslibPBKDF2(PBKDF2SHA512, sha512.New, encoded)
+	case strings.HasPrefix(encoded, "{"):
+		return parseLDAP(encoded)
+	}
+	return parsedHash{}, ErrUnknownFormat
+}
+
+func parseBcrypt(encoded string) (parsedHash, error) {
+	if _, err := bcrypt.Cost([]byte(encoded)); err != nil {
+		return parsedHash{}, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+
+	return parsedHash{
+		algorithm: Bcrypt,
+		verify: func(password string) (bool, error) {
+			err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, nil
+			}
+			return err == nil, err
+		},
+	}, nil
+}
+
+// parseArgon2 разбирает формат PHC: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+func parseArgon2(algorithm Algorithm, encoded string) (parsedHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return parsedHash{}, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return parsedHash{}, fmt.Errorf("%w: unsupported argon2 version", ErrInvalidHash)
+	}
+
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return parsedHash{}, fmt.Errorf("%w: argon2 parameters", ErrInvalidHash)
+	}
+	if memory == 0 || memory > maxArgon2Memory || iterations == 0 || iterations > maxArgon2Time || threads == 0 {
+		return parsedHash{}, fmt.Errorf("%w: argon2 parameters out of range", ErrInvalidHash)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return parsedHash{}, fmt.Errorf("%w: argon2 salt", ErrInvalidHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return parsedHash{}, fmt.Errorf("%w: argon2 hash", ErrInvalidHash)
+	}
+
+	return parsedHash{
+		algorithm: algorithm,
+		verify: func(password string) (bool, error) {
+			var computed []byte
+			if algorithm == Argon2id {
+				computed = argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+			} else {
+				computed = argon2.Key([]byte(password), salt, iterations, memory, threads, uint32(len(key)))
+			}
+			return subtle.ConstantTimeCompare(computed, key) == 1, nil
+		},
+	}, nil
+}
+
+// parseDjangoPBKDF2: соль используется как есть, хеш в стандартном base64
+func parseDjangoPBKDF2(algorithm Algorithm, h func() hash.Hash, encoded string) (parsedHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return parsedHash{}, ErrInvalidHash
+	}
+
+	iterations, err := parseIterations(parts[1])
+	if err != nil {
+		return parsedHash{}, err
+	}
+	key, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return parsedHash{}, fmt.Errorf("%w: pbkdf2 hash", ErrInvalidHash)
+	}
+
+	return pbkdf2Hash(algorithm, h, []byte(parts[2]), key, iterations), nil
+}
+
+// parsePasslibPBKDF2: соль и хеш в "adapted base64" passlib ('.' вместо '+', без выравнивания)
+func parsePasslibPBKDF2(algorithm Algorithm, h func() hash.Hash, encoded string) (parsedHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return parsedHash{}, ErrInvalidHash
+	}
+
+	iterations, err := parseIterations(parts[2])
+	if err != nil {
+		return parsedHash{}, err
+	}
+	salt, err := decodeAB64(parts[3])
+	if err != nil {
+		return parsedHash{}, fmt.Errorf("%w: pbkdf2 salt", ErrInvalidHash)
+	}
+	key, err := decodeAB64(parts[4])
+	if err != nil || len(key) == 0 {
+		return parsedHash{}, fmt.Errorf("%w: pbkdf2 hash", ErrInvalidHash)
+	}
+
+	return pbkdf2Hash(algorithm, h, salt, key, iterations), nil
+}
+
+func pbkdf2Hash(algorithm Algorithm, h func() hash.Hash, salt, key []byte, iterations int) parsedHash {
+	return parsedHash{
+		algorithm: algorithm,
+		verify: func(password string) (bool, error) {
+			computed, err := pbkdf2.Key(h, password, salt, iterations, len(key))
+			if err != nil {
+				return false, err
+			}
+			return subtle.ConstantTimeCompare(computed, key) == 1, nil
+		},
+	}
+}
+
+func parseIterations(value string) (int, error) {
+	iterations, err := strconv.Atoi(value)
+	if err != nil || iterations <= 0 || iterations > maxPBKDF2Iterations {
+		return 0, fmt.Errorf("%w: pbkdf2 iterations", ErrInvalidHash)
+	}
+	return iterations, nil
+}
+
+func decodeAB64(value string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(value, ".", "+"))
+}
+
+type ldapScheme struct {
+	algorithm Algorithm
+	newHash   func() hash.Hash
+	salted    bool
+}
+
+var ldapSchemes = map[string]ldapScheme{
+	"{SHA}":     {SHA1, sha1.New, false},
+	"{SSHA}":    {SSHA1, sha1.New, true},
+	"{SHA256}":  {SHA256, sha256.New, false},
+	"{SSHA256}": {SSHA256, sha256.New, true},
+	"{SHA512}":  {SHA512, sha512.New, false},
+	"{SSHA512}": {SSHA512, sha512.New, true},
+}
+
+// parseLDAP: {SSHA}base64(sha1(password + salt) + salt)
+func parseLDAP(encoded string) (parsedHash, error) {
+	end := strings.IndexByte(encoded, '}')
+	if end < 0 {
+		return parsedHash{}, ErrUnknownFormat
+	}
+
+	scheme, ok := ldapSchemes[strings.ToUpper(encoded[:end+1])]
+	if !ok {
+		return parsedHash{}, ErrUnknownFormat
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded[end+1:])
+	if err != nil {
+		return parsedHash{}, fmt.Errorf("%w: %s", ErrInvalidHash, scheme.algorithm)
+	}
+
+	size := scheme.newHash().Size()
+	if len(raw) < size || (!scheme.salted && len(raw) != size) {
+		return parsedHash{}, fmt.Errorf("%w: %s digest length", ErrInvalidHash, scheme.algorithm)
+	}
+	digest, salt := raw[:size], raw[size:]
+
+	return parsedHash{
+		algorithm: scheme.algorithm,
+		verify: func(password string) (bool, error) {
+			h := scheme.newHash()
+			h.Write([]byte(password))
+			h.Write(salt)
+			return subtle.ConstantTimeCompare(h.Sum(nil), digest) == 1, nil
+		},
+	}, nil
+}
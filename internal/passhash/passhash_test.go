@@ -0,0 +1,103 @@
+package passhash
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "secret123"
+
+func argon2Hash(variant string) string {
+	salt := []byte("somesalt")
+	var key []byte
+	if variant == "argon2id" {
+		key = argon2.IDKey([]byte(testPassword), salt, 1, 64, 1, 32)
+	} else {
+		key = argon2.Key([]byte(testPassword), salt, 1, 64, 1, 32)
+	}
+	return fmt.Sprintf("$%s$v=19$m=64,t=1,p=1$%s$%s", variant,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestVerify(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		hash         string
+		algorithm    Algorithm
+		needsUpgrade bool
+	}{
+		{"bcrypt", string(bcryptHash), Bcrypt, false},
+		{"argon2id", argon2Hash("argon2id"), Argon2id, false},
+		{"argon2i", argon2Hash("argon2i"), Argon2i, false},
+		{"django pbkdf2 sha256", "pbkdf2_sha256$1000$seasalt$Uxc9q1exjd73R/bOV1+XCRfBdA80RZQWCPtidXIAyIE=", PBKDF2SHA256, true},
+		{"django pbkdf2 sha1", "pbkdf2_sha1$1000$seasalt$ZkxUXyNXuIeJUXzxjb6vpDCcWr0=", PBKDF2SHA1, true},
+		{"passlib pbkdf2 sha512", "$pbkdf2-sha512$1000$AQIDBAUGBwj7/A$vhfjRaBJQFYxiX4w.JojqWhrhiC1dhwOiVlKeQbAQkmE35E97mslYHGV9m0yb71YIhQC1MW1qctiiq2RMj/4tQ", PBKDF2SHA512, true},
+		{"ldap sha", "{SHA}8rFPaOuZX6yzocNSh7d41b14VRE=", SHA1, true},
+		{"ldap ssha", "{SSHA}JLOOnBk7GsGWEiCCq0n/Z1y3TkhzYWx0", SSHA1, true},
+		{"ldap ssha512", "{SSHA512}nUz8MMDl5HPWZS7mbai66YEMYhQyEYij4Hj4syRS+OnksrfvRKmU/IZwPsd0A7dUgTDM44SoandQVEx0dp2c+XNhbHQ=", SSHA512, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm, err := Identify(tt.hash)
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, algorithm)
+			assert.Equal(t, tt.needsUpgrade, NeedsUpgrade(tt.hash))
+
+			ok, err := Verify(tt.hash, testPassword)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = Verify(tt.hash, "wrong-password")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestIdentify_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr error
+	}{
+		{"plaintext", "secret123", ErrUnknownFormat},
+		{"empty", "", ErrUnknownFormat},
+		{"unknown ldap scheme", "{MD5}Xr4ilOzQ4PCOq3aQ0qbuaQ==", ErrUnknownFormat},
+		{"truncated bcrypt", "$2a$10$abc", ErrInvalidHash},
+		{"bad pbkdf2 iterations", "pbkdf2_sha256$abc$salt$Uxc9q1exjd73R/bOV1+XCRfBdA80RZQWCPtidXIAyIE=", ErrInvalidHash},
+		{"too many pbkdf2 iterations", "pbkdf2_sha256$999999999$salt$Uxc9q1exjd73R/bOV1+XCRfBdA80RZQWCPtidXIAyIE=", ErrInvalidHash},
+		{"argon2 memory too large", "$argon2id$v=19$m=4194304,t=1,p=1$c29tZXNhbHQ$aGFzaA", ErrInvalidHash},
+		{"short sha digest", "{SHA}c2hvcnQ=", ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Identify(tt.hash)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, NeedsUpgrade(tt.hash))
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	hashed, err := Hash(testPassword)
+	require.NoError(t, err)
+
+	algorithm, err := Identify(hashed)
+	require.NoError(t, err)
+	assert.Equal(t, Bcrypt, algorithm)
+
+	ok, err := Verify(hashed, testPassword)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
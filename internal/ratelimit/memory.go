@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Как часто (в вызовах Allow) удалять бакеты, которые полностью восстановились
+const sweepEvery = 1024
+
+// MemoryBackend хранит бакеты в памяти процесса: у каждого экземпляра сервиса свой лимит.
+// Бакет описывается одним временем (GCRA), как это делается и в общих хранилищах вроде Redis
+type MemoryBackend struct {
+	mu sync.Mutex
+	// Теоретическое время прихода следующего запроса. В прошлом - бакет полон
+	tat   map[string]time.Time
+	calls int
+	now   func() time.Time
+}
+
+var _ Backend = (*MemoryBackend)(nil)
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{tat: make(map[string]time.Time), now: time.Now}
+}
+
+func (b *MemoryBackend) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	interval := time.Duration(float64(time.Second) / limit.Rate)
+	window := time.Duration(limit.Burst) * interval
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.sweep(now)
+
+	tat := b.tat[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+
+	if allowAt := next.Add(-window); now.Before(allowAt) {
+		return Result{RetryAfter: allowAt.Sub(now)}, nil
+	}
+
+	b.tat[key] = next
+	return Result{Allowed: true, Remaining: int((window - next.Sub(now)) / interval)}, nil
+}
+
+func (b *MemoryBackend) sweep(now time.Time) {
+	b.calls++
+	if b.calls%sweepEvery != 0 {
+		return
+	}
+	for key, tat := range b.tat {
+		if tat.Before(now) {
+			delete(b.tat, key)
+		}
+	}
+}
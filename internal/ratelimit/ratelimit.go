@@ -0,0 +1,217 @@
+// Package ratelimit ограничивает частоту вызовов методов по политикам token bucket.
+// Состояние бакетов хранится в Backend: в памяти процесса или в общем хранилище,
+// если лимит должен действовать на все экземпляры сервиса
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Виды ключей, по которым считается лимит
+const (
+	// Адрес клиента
+	KeyIP = "ip"
+	// CommonName клиентского сертификата; клиенты без сертификата считаются по адресу
+	KeyClient = "client"
+	// Имя пользователя из запроса; к запросам без имени политика не применяется
+	KeyUsername = "username"
+)
+
+// DefaultPolicies - политики по умолчанию (RATE_LIMIT_POLICIES). Методы, проверяющие пароль,
+// ограничиваются одинаково строго, иначе перебор пароля уходит на менее защищённый метод
+var DefaultPolicies = []string{
+	"/auth.AuthService/Login:ip:10/m:10",
+	"/auth.AuthService/Login:username:5/m:5",
+	"/auth.AuthService/ChangePassword:ip:10/m:10",
+	"/auth.AuthService/ChangePassword:username:5/m:5",
+	"/auth.AuthService/Register:ip:5/m:5",
+	"/auth.AuthService/ResendVerification:ip:5/m:3",
+	"/auth.AuthService/VerifyToken:ip:100/s:200",
+	"/auth.AdminService/:client:20/s:40",
+	"/:ip:20/s:40",
+}
+
+// Limit - token bucket: Rate токенов в секунду, не больше Burst запросов подряд
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result - решение бэкенда по одному бакету
+type Result struct {
+	Allowed bool
+	// Сколько запросов ещё можно сделать без ожидания
+	Remaining int
+	// Через сколько освободится токен, если запрос отклонён
+	RetryAfter time.Duration
+}
+
+// Backend хранит состояние бакетов. Проверка и списание токена должны быть атомарны для
+// одного ключа: экземпляры сервиса с общим бэкендом делят один лимит
+type Backend interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Policy ограничивает методы, полное имя которых начинается с Method
+type Policy struct {
+	Method string
+	Key    string
+	Limit  Limit
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%s:%s:%g/s:%d", p.Method, p.Key, p.Limit.Rate, p.Limit.Burst)
+}
+
+// Identity - кто делает запрос; пустые поля означают, что значение неизвестно
+type Identity struct {
+	IP       string
+	Client   string
+	Username string
+}
+
+// LimitError возвращается Limiter.Allow, если запрос превысил лимит политики
+type LimitError struct {
+	Policy     Policy
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit %s exceeded, retry after %s", e.Policy.Key, e.RetryAfter)
+}
+
+type Limiter struct {
+	backend  Backend
+	policies []Policy
+}
+
+func New(backend Backend, policies []Policy) *Limiter {
+	return &Limiter{backend: backend, policies: policies}
+}
+
+// Policies возвращает политики метода. Применяются политики с самым длинным совпавшим
+// префиксом: так строгий лимит на Login и мягкий на VerifyToken заменяют общий лимит сервиса
+func (l *Limiter) Policies(fullMethod string) []Policy {
+	var (
+		matched []Policy
+		longest = -1
+	)
+	for _, policy := range l.policies {
+		if !strings.HasPrefix(fullMethod, policy.Method) {
+			continue
+		}
+		switch {
+		case len(policy.Method) > longest:
+			longest = len(policy.Method)
+			matched = []Policy{policy}
+		case len(policy.Method) == longest:
+			matched = append(matched, policy)
+		}
+	}
+	return matched
+}
+
+// Allow списывает токен в каждой политике метода. Возвращает *LimitError, если лимит
+// исчерпан, или ошибку бэкенда
+func (l *Limiter) Allow(ctx context.Context, fullMethod string, identity Identity) error {
+	for _, policy := range l.Policies(fullMethod) {
+		value := identityValue(policy.Key, identity)
+		if value == "" {
+			continue
+		}
+
+		result, err := l.backend.Allow(ctx, bucketKey(policy, value), policy.Limit)
+		if err != nil {
+			return fmt.Errorf("rate limit backend: %w", err)
+		}
+		if !result.Allowed {
+			return &LimitError{Policy: policy, RetryAfter: result.RetryAfter}
+		}
+	}
+	return nil
+}
+
+func identityValue(key string, identity Identity) string {
+	switch key {
+	case KeyIP:
+		return identity.IP
+	case KeyClient:
+		if identity.Client != "" {
+			return "cn:" + identity.Client
+		}
+		return "ip:" + identity.IP
+	case KeyUsername:
+		return identity.Username
+	default:
+		return ""
+	}
+}
+
+// bucketKey включает метод политики: у разных политик независимые бакеты
+func bucketKey(policy Policy, value string) string {
+	return "ratelimit:" + policy.Method + ":" + policy.Key + ":" + value
+}
+
+// ParsePolicies разбирает политики вида "префикс:ключ:частота/единица:burst", например
+// "/auth.AuthService/Login:ip:10/m:5" - 10 запросов в минуту с одного адреса, до 5 подряд.
+// Единица - s, m или h
+func ParsePolicies(entries []string) ([]Policy, error) {
+	var policies []Policy
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		policy, err := parsePolicy(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit policy %q: %w", entry, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func parsePolicy(entry string) (Policy, error) {
+	parts := strings.Split(entry, ":")
+	if len(parts) != 4 {
+		return Policy{}, fmt.Errorf("expected method:key:rate/unit:burst")
+	}
+
+	policy := Policy{Method: parts[0], Key: parts[1]}
+	if !strings.HasPrefix(policy.Method, "/") {
+		return Policy{}, fmt.Errorf("method prefix must start with /")
+	}
+
+	switch policy.Key {
+	case KeyIP, KeyClient, KeyUsername:
+	default:
+		return Policy{}, fmt.Errorf("unknown key %q, expected ip, client or username", policy.Key)
+	}
+
+	count, unit, ok := strings.Cut(parts[2], "/")
+	n, err := strconv.ParseFloat(count, 64)
+	if !ok || err != nil || n <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate %q", parts[2])
+	}
+	switch unit {
+	case "s":
+		policy.Limit.Rate = n
+	case "m":
+		policy.Limit.Rate = n / time.Minute.Seconds()
+	case "h":
+		policy.Limit.Rate = n / time.Hour.Seconds()
+	default:
+		return Policy{}, fmt.Errorf("unknown rate unit %q, expected s, m or h", unit)
+	}
+
+	policy.Limit.Burst, err = strconv.Atoi(parts[3])
+	if err != nil || policy.Limit.Burst <= 0 {
+		return Policy{}, fmt.Errorf("invalid burst %q", parts[3])
+	}
+	return policy, nil
+}
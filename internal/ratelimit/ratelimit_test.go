@@ -0,0 +1,170 @@
+package ratelimit
+
+import (
+	"auth_test/pkg/pb"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies([]string{
+		"/auth.AuthService/Login:username:6/m:3",
+		" /:ip:2/s:4 ",
+		"",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Policy{
+		{Method: "/auth.AuthService/Login", Key: KeyUsername, Limit: Limit{Rate: 0.1, Burst: 3}},
+		{Method: "/", Key: KeyIP, Limit: Limit{Rate: 2, Burst: 4}},
+	}, policies)
+
+	for _, entry := range []string{
+		"Login:ip:1/s:1",
+		"/auth.AuthService/Login:session:1/s:1",
+		"/auth.AuthService/Login:ip:0/s:1",
+		"/auth.AuthService/Login:ip:1/d:1",
+		"/auth.AuthService/Login:ip:1/s:0",
+		"/auth.AuthService/Login:ip:1/s",
+	} {
+		_, err := ParsePolicies([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestLimiter_Policies(t *testing.T) {
+	policies, err := ParsePolicies([]string{
+		"/:ip:20/s:40",
+		"/auth.AuthService/Login:ip:10/m:10",
+		"/auth.AuthService/Login:username:5/m:5",
+		"/auth.AuthService/VerifyToken:ip:100/s:200",
+	})
+	require.NoError(t, err)
+	limiter := New(NewMemoryBackend(), policies)
+
+	tests := []struct {
+		method string
+		want   []Policy
+	}{
+		{method: "/auth.AuthService/Login", want: policies[1:3]},
+		{method: "/auth.AuthService/VerifyToken", want: policies[3:4]},
+		{method: "/auth.AdminService/ListUsers", want: policies[0:1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			assert.Equal(t, tt.want, limiter.Policies(tt.method))
+		})
+	}
+}
+
+func TestDefaultPolicies(t *testing.T) {
+	policies, err := ParsePolicies(DefaultPolicies)
+	require.NoError(t, err)
+	limiter := New(NewMemoryBackend(), policies)
+
+	// Политика на несуществующий метод никогда не применяется
+	methods := map[string]bool{}
+	for _, desc := range []grpc.ServiceDesc{pb.AuthService_ServiceDesc, pb.AdminService_ServiceDesc} {
+		for _, method := range desc.Methods {
+			methods["/"+desc.ServiceName+"/"+method.MethodName] = true
+		}
+	}
+	for _, policy := range policies {
+		if strings.HasSuffix(policy.Method, "/") {
+			continue
+		}
+		assert.True(t, methods[policy.Method], "policy %s names an unknown method", policy)
+	}
+
+	limits := func(method string) map[string]Limit {
+		byKey := map[string]Limit{}
+		for _, policy := range limiter.Policies(method) {
+			byKey[policy.Key] = policy.Limit
+		}
+		return byKey
+	}
+	login := limits(pb.AuthService_Login_FullMethodName)
+	assert.Contains(t, login, KeyIP)
+	assert.Contains(t, login, KeyUsername)
+	assert.Equal(t, login, limits(pb.AuthService_ChangePassword_FullMethodName))
+}
+
+func TestMemoryBackend_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		result, err := backend.Allow(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := backend.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// У другого ключа свой бакет
+	result, err = backend.Allow(ctx, "b", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Токены восстанавливаются со скоростью Rate
+	now = now.Add(1500 * time.Millisecond)
+	result, err = backend.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = backend.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+}
+
+type failingBackend struct{}
+
+func (failingBackend) Allow(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	policies, err := ParsePolicies([]string{
+		"/auth.AuthService/Login:username:1/m:1",
+		"/auth.AuthService/Login:client:10/m:2",
+	})
+	require.NoError(t, err)
+	limiter := New(NewMemoryBackend(), policies)
+
+	require.NoError(t, limiter.Allow(ctx, "/auth.AuthService/Login", Identity{IP: "10.0.0.1", Username: "alice"}))
+
+	// Лимит на пользователя действует с любого адреса
+	err = limiter.Allow(ctx, "/auth.AuthService/Login", Identity{IP: "10.0.0.2", Username: "alice"})
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, KeyUsername, limitErr.Policy.Key)
+	assert.Greater(t, limitErr.RetryAfter, time.Duration(0))
+
+	// Без имени пользователя считается только лимит клиента, клиент без сертификата - по адресу
+	require.NoError(t, limiter.Allow(ctx, "/auth.AuthService/Login", Identity{IP: "10.0.0.1"}))
+	err = limiter.Allow(ctx, "/auth.AuthService/Login", Identity{IP: "10.0.0.1"})
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, KeyClient, limitErr.Policy.Key)
+	require.NoError(t, limiter.Allow(ctx, "/auth.AuthService/Login", Identity{IP: "10.0.0.1", Client: "ops"}))
+
+	// Методы без политик не ограничиваются
+	require.NoError(t, limiter.Allow(ctx, "/auth.AuthService/VerifyToken", Identity{IP: "10.0.0.1"}))
+
+	err = New(failingBackend{}, policies).Allow(ctx, "/auth.AuthService/Login", Identity{IP: "10.0.0.1"})
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &limitErr))
+}
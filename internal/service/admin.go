@@ -0,0 +1,467 @@
+//go:generate mockgen -source=admin.go -destination=mock_admin.go -package=service -typed
+package service
+
+import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/logging"
+	"auth_test/internal/store"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrInvalidStatus    = errors.New("invalid status")
+)
+
+type AdminService interface {
+	ListUsers(ctx context.Context, filter UserFilter) ([]UserInfo, string, error)
+	GetUser(ctx context.Context, username string) (*UserInfo, error)
+	CreateUser(ctx context.Context, username, password, role string) error
+	DisableUser(ctx context.Context, username, reason string) error
+	EnableUser(ctx context.Context, username, reason string) error
+	DeleteUser(ctx context.Context, username, reason string) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// PurgeUnverifiedUsers удаляет регистрации, не подтверждённые до registeredBefore
+	PurgeUnverifiedUsers(ctx context.Context, registeredBefore time.Time) (int64, error)
+	// ResetPassword задаёт новый пароль и отзывает все выданные пользователю токены
+	ResetPassword(ctx context.Context, username, password string) error
+	SetRole(ctx context.Context, username, role string) error
+	// RevokeSessions делает недействительными все access и refresh токены пользователя
+	RevokeSessions(ctx context.Context, username string) error
+	// ImportUsers загружает пользователей с готовыми хешами паролей из CSV или JSONL.
+	// Ошибки отдельных строк возвращаются в ImportResult; error - только если импорт прерван
+	ImportUsers(ctx context.Context, r io.Reader, format string) (*ImportResult, error)
+	ExportUsers(ctx context.Context, w io.Writer, format string) (int, error)
+	// ListAuditEvents возвращает события журнала аудита, новые первыми
+	ListAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, string, error)
+	// PurgeAuditEvents удаляет события старше occurredBefore, предварительно записав в цепочку
+	// событие audit.purge с хешем последнего удаляемого события
+	PurgeAuditEvents(ctx context.Context, occurredBefore time.Time) (int64, error)
+	// VerifyAuditChain проверяет цепочку хешей журнала аудита тенанта
+	VerifyAuditChain(ctx context.Context) (*auditchain.Report, error)
+	// ExportAuditEvents выгружает подписанные пакеты событий с seq > afterSeq в sink
+	ExportAuditEvents(ctx context.Context, sink auditchain.Sink, signer *auditchain.Signer, opts ExportAuditOptions) (*ExportAuditResult, error)
+}
+
+// UserInfo - представление пользователя для административного API (без хеша пароля)
+type UserInfo struct {
+	ID              int32
+	Username        string
+	Role            string
+	Status          string
+	StatusReason    string
+	StatusChangedAt time.Time
+	// nil, если пользователь не удалён
+	DeletedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type UserFilter struct {
+	Username string
+	Role     string
+	// Пустой статус - все пользователи, кроме удалённых
+	Status    string
+	PageSize  int
+	PageToken string
+}
+
+type adminService struct {
+	store store.Store
+	audit *auditLog
+}
+
+type AdminServiceOption func(*adminService)
+
+// WithAdminAuditTenant задаёт цепочку аудита, в которую пишет и которую читает сервис
+func WithAdminAuditTenant(tenant string) AdminServiceOption {
+	return func(s *adminService) {
+		s.audit.tenant = tenant
+	}
+}
+
+func NewAdminService(store store.Store, opts ...AdminServiceOption) AdminService {
+	s := &adminService{
+		store: store,
+		audit: newAuditLog(store),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *adminService) ListUsers(ctx context.Context, filter UserFilter) ([]UserInfo, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	afterID, err := decodePageToken(filter.PageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	params := store.ListUsersParams{
+		AfterID:   afterID,
+		PageLimit: int32(pageSize) + 1,
+	}
+	if filter.Username != "" {
+		params.UsernameFilter = pgtype.Text{String: filter.Username, Valid: true}
+	}
+	if filter.Role != "" {
+		params.Role = pgtype.Text{String: filter.Role, Valid: true}
+	}
+	if filter.Status != "" {
+		if !isValidStatus(filter.Status) {
+			return nil, "", ErrInvalidStatus
+		}
+		params.Status = pgtype.Text{String: filter.Status, Valid: true}
+	}
+
+	rows, err := s.store.ListUsers(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	var nextPageToken string
+	if len(rows) > pageSize {
+		rows = rows[:pageSize]
+		nextPageToken = encodePageToken(rows[len(rows)-1].ID)
+	}
+
+	users := make([]UserInfo, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, UserInfo{
+			ID:              row.ID,
+			Username:        row.Username,
+			Role:            row.Role,
+			Status:          row.Status,
+			StatusReason:    row.StatusReason,
+			StatusChangedAt: row.StatusChangedAt.Time,
+			DeletedAt:       timestampPtr(row.DeletedAt),
+			CreatedAt:       row.CreatedAt.Time,
+			UpdatedAt:       row.UpdatedAt.Time,
+		})
+	}
+
+	return users, nextPageToken, nil
+}
+
+func (s *adminService) GetUser(ctx context.Context, username string) (*UserInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return nil, lookupError(err, ErrUserNotFound)
+	}
+
+	return &UserInfo{
+		ID:              user.ID,
+		Username:        user.Username,
+		Role:            user.Role,
+		Status:          user.Status,
+		StatusReason:    user.StatusReason,
+		StatusChangedAt: user.StatusChangedAt.Time,
+		DeletedAt:       timestampPtr(user.DeletedAt),
+		CreatedAt:       user.CreatedAt.Time,
+		UpdatedAt:       user.UpdatedAt.Time,
+	}, nil
+}
+
+func (s *adminService) CreateUser(ctx context.Context, username, password, role string) (err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{
+			EventType: AuditAdminUserCreate,
+			Subject:   username,
+			Err:       err,
+			Metadata:  map[string]string{"role": role},
+		})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if username == "" || password == "" {
+		return ErrInvalidArgument
+	}
+	if err := checkPasswordStrength(password); err != nil {
+		return err
+	}
+
+	if role == "" {
+		role = RoleUser
+	}
+	if role != RoleUser && role != RoleAdmin {
+		return ErrInvalidRole
+	}
+
+	exists, err := s.store.UserExists(ctx, username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUserAlreadyExists
+	}
+
+	hashedPassword, err := hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.store.CreateUser(ctx, store.CreateUserParams{
+		Username:     username,
+		PasswordHash: string(hashedPassword),
+		Role:         role,
+		Status:       StatusActive,
+	})
+	if err != nil {
+		return mapUniqueViolation(err)
+	}
+
+	logging.FromContext(ctx).Info("User created by admin", "username", username, "role", role)
+	return nil
+}
+
+func (s *adminService) DisableUser(ctx context.Context, username, reason string) error {
+	return s.setStatus(ctx, username, StatusDisabled, reason)
+}
+
+// EnableUser активирует отключённого, ожидающего подтверждения или мягко удалённого пользователя
+func (s *adminService) EnableUser(ctx context.Context, username, reason string) error {
+	return s.setStatus(ctx, username, StatusActive, reason)
+}
+
+// DeleteUser помечает пользователя удалённым; запись удаляется окончательно в PurgeDeletedUsers
+func (s *adminService) DeleteUser(ctx context.Context, username, reason string) error {
+	return s.setStatus(ctx, username, StatusDeleted, reason)
+}
+
+func (s *adminService) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (purged int64, err error) {
+	defer func() {
+		// Пустые запуски задачи хранения не засоряют журнал
+		if err == nil && purged == 0 {
+			return
+		}
+		s.audit.record(ctx, auditEntry{
+			EventType: AuditAdminUsersPurge,
+			Err:       err,
+			Metadata:  map[string]string{"purged": strconv.FormatInt(purged, 10)},
+		})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return s.store.PurgeDeletedUsers(ctx, pgtype.Timestamp{Time: deletedBefore, Valid: true})
+}
+
+func (s *adminService) PurgeUnverifiedUsers(ctx context.Context, registeredBefore time.Time) (purged int64, err error) {
+	defer func() {
+		if err == nil && purged == 0 {
+			return
+		}
+		s.audit.record(ctx, auditEntry{
+			EventType: AuditAdminUnverifiedPurge,
+			Err:       err,
+			Metadata:  map[string]string{"purged": strconv.FormatInt(purged, 10)},
+		})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return s.store.PurgeUnverifiedUsers(ctx, pgtype.Timestamp{Time: registeredBefore, Valid: true})
+}
+
+func (s *adminService) ResetPassword(ctx context.Context, username, password string) (err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{EventType: AuditAdminPasswordReset, Subject: username, Err: err})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if password == "" {
+		return ErrInvalidArgument
+	}
+	if err := checkPasswordStrength(password); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(ctx, password)
+	if err != nil {
+		return err
+	}
+
+	affected, err := s.store.SetUserPassword(ctx, store.SetUserPasswordParams{
+		Username:     username,
+		PasswordHash: string(hashedPassword),
+	})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	logging.FromContext(ctx).Info("Password reset", "username", username)
+	return nil
+}
+
+func (s *adminService) SetRole(ctx context.Context, username, role string) (err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{
+			EventType: AuditAdminRoleChange,
+			Subject:   username,
+			Err:       err,
+			Metadata:  map[string]string{"role": role},
+		})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if role != RoleUser && role != RoleAdmin {
+		return ErrInvalidRole
+	}
+
+	affected, err := s.store.SetUserRole(ctx, store.SetUserRoleParams{
+		Username: username,
+		Role:     role,
+	})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	logging.FromContext(ctx).Info("User role changed", "username", username, "role", role)
+	return nil
+}
+
+func (s *adminService) RevokeSessions(ctx context.Context, username string) (err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{EventType: AuditAdminSessionRevoke, Subject: username, Err: err})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	affected, err := s.store.RevokeUserSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	logging.FromContext(ctx).Info("Sessions revoked", "username", username)
+	return nil
+}
+
+func (s *adminService) setStatus(ctx context.Context, username, status, reason string) (err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{
+			EventType: statusAuditEvents[status],
+			Subject:   username,
+			Err:       err,
+			Metadata:  map[string]string{"status_reason": reason},
+		})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	affected, err := s.store.SetUserStatus(ctx, store.SetUserStatusParams{
+		Username:     username,
+		Status:       status,
+		StatusReason: reason,
+	})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	logging.FromContext(ctx).Info("User status changed", "username", username, "status", status)
+	return nil
+}
+
+var statusAuditEvents = map[string]string{
+	StatusActive:   AuditAdminUserEnable,
+	StatusDisabled: AuditAdminUserDisable,
+	StatusDeleted:  AuditAdminUserDelete,
+}
+
+func isValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusDisabled, StatusPendingVerification, StatusDeleted:
+		return true
+	default:
+		return false
+	}
+}
+
+func timestampPtr(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
+
+func encodePageToken(lastID int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(int(lastID))))
+}
+
+func decodePageToken(token string) (int32, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 32)
+	if err != nil || id < 0 {
+		return 0, ErrInvalidPageToken
+	}
+
+	return int32(id), nil
+}
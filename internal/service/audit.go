@@ -0,0 +1,341 @@
+package service
+
+import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/logging"
+	"auth_test/internal/store"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Типы событий журнала аудита
+const (
+	AuditUserLogin            = "user.login"
+	AuditUserRegister         = "user.register"
+	AuditTokenRefresh         = "token.refresh"
+	AuditPasswordChange       = "user.password_change"
+	AuditAdminUserCreate      = "admin.user_create"
+	AuditAdminUserDisable     = "admin.user_disable"
+	AuditAdminUserEnable      = "admin.user_enable"
+	AuditAdminUserDelete      = "admin.user_delete"
+	AuditAdminPasswordReset   = "admin.password_reset"
+	AuditAdminRoleChange      = "admin.role_change"
+	AuditAdminSessionRevoke   = "admin.sessions_revoke"
+	AuditAdminUsersImport     = "admin.users_import"
+	AuditAdminUsersExport     = "admin.users_export"
+	AuditAdminUsersPurge      = "admin.users_purge"
+	AuditAdminUnverifiedPurge = "admin.unverified_purge"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Actor для вызовов без аутентификации и для фоновых задач
+const (
+	ActorAnonymous = "anonymous"
+	ActorRetention = "system:retention"
+)
+
+const (
+	// Не длиннее столбцов VARCHAR(255) в audit_events
+	maxAuditFieldLength = 255
+	auditWriteTimeout   = 5 * time.Second
+)
+
+var ErrInvalidOutcome = errors.New("invalid outcome")
+
+// AuditEvent - запись журнала аудита
+type AuditEvent struct {
+	ID         int64
+	OccurredAt time.Time
+	EventType  string
+	Outcome    string
+	// Кто выполнил действие: "user:<name>", "mtls:<cn>", "cli", "anonymous"
+	Actor string
+	// Над кем выполнено действие (имя пользователя)
+	Subject   string
+	IP        string
+	UserAgent string
+	RequestID string
+	Metadata  map[string]string
+	// Место события в цепочке хешей тенанта
+	Tenant   string
+	Seq      int64
+	PrevHash string
+	Hash     string
+}
+
+type AuditFilter struct {
+	EventType string
+	Subject   string
+	Actor     string
+	Outcome   string
+	// Нулевое время - без ограничения
+	Since     time.Time
+	Until     time.Time
+	PageSize  int
+	PageToken string
+}
+
+// ClientInfo - адрес и user agent клиента, записываемые в аудит
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type actorKey struct{}
+
+type clientInfoKey struct{}
+
+// WithActor сохраняет в контексте, от чьего имени выполняется вызов
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+func clientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
+
+// auditEntry - событие до записи; пустой Actor берётся из контекста
+type auditEntry struct {
+	EventType string
+	Subject   string
+	Actor     string
+	Err       error
+	Metadata  map[string]string
+}
+
+// auditLog добавляет события в цепочку аудита тенанта
+type auditLog struct {
+	store  store.AuditAppender
+	tenant string
+	now    func() time.Time
+}
+
+func newAuditLog(store store.AuditAppender) *auditLog {
+	return &auditLog{
+		store:  store,
+		tenant: auditchain.DefaultTenant,
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// record записывает событие; сбой записи логируется, но не прерывает операцию
+func (a *auditLog) record(ctx context.Context, entry auditEntry) {
+	if err := a.append(ctx, entry); err != nil {
+		logging.FromContext(ctx).Error("Failed to write audit event",
+			"event_type", entry.EventType, "subject", entry.Subject, logging.Err(err))
+	}
+}
+
+func (a *auditLog) append(ctx context.Context, entry auditEntry) error {
+	actor := entry.Actor
+	if actor == "" {
+		actor = ActorFromContext(ctx)
+	}
+	if actor == "" {
+		actor = ActorAnonymous
+	}
+
+	outcome := OutcomeSuccess
+	metadata := entry.Metadata
+	if entry.Err != nil {
+		outcome = OutcomeFailure
+		if metadata == nil {
+			metadata = make(map[string]string, 1)
+		}
+		metadata["reason"] = auditReason(entry.Err)
+	}
+
+	rawMetadata := []byte("{}")
+	if len(metadata) > 0 {
+		var err error
+		if rawMetadata, err = json.Marshal(metadata); err != nil {
+			rawMetadata = []byte("{}")
+		}
+	}
+
+	client := clientInfoFromContext(ctx)
+
+	// Событие записывается, даже если клиент уже отключился
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+
+	_, err := a.store.AppendAuditEvent(writeCtx, store.InsertAuditEventParams{
+		Tenant:     a.tenant,
+		OccurredAt: pgtype.Timestamp{Time: a.now(), Valid: true},
+		EventType:  entry.EventType,
+		Outcome:    outcome,
+		Actor:      truncate(actor, maxAuditFieldLength),
+		Subject:    truncate(entry.Subject, maxAuditFieldLength),
+		Ip:         truncate(client.IP, maxAuditFieldLength),
+		UserAgent:  truncate(client.UserAgent, maxAuditFieldLength),
+		RequestID:  logging.RequestID(ctx),
+		Metadata:   rawMetadata,
+	})
+	return err
+}
+
+// Причины отказа, которые можно записать в журнал как есть
+var auditReasons = []error{
+	ErrInvalidCredentials,
+	ErrInvalidToken,
+	ErrExpiredToken,
+	ErrUserNotFound,
+	ErrInvalidTypeToken,
+	ErrUserAlreadyExists,
+	ErrUserDisabled,
+	ErrUserNotVerified,
+	ErrEmailAlreadyInUse,
+	ErrPasswordChangeRequired,
+	ErrWeakPassword,
+	ErrInvalidRole,
+	ErrInvalidArgument,
+	ErrInvalidStatus,
+	ErrInvalidFormat,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// auditReason не пропускает в журнал текст внутренних ошибок (SQL, адреса базы и т.п.)
+func auditReason(err error) string {
+	for _, known := range auditReasons {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return "internal error"
+}
+
+// truncate обрезает строку до limit байт, не разрывая символы UTF-8
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+func (s *adminService) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	beforeID, err := decodeAuditPageToken(filter.PageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	params := store.ListAuditEventsParams{
+		Tenant:    s.audit.tenant,
+		BeforeID:  beforeID,
+		EventType: optionalText(filter.EventType),
+		Subject:   optionalText(filter.Subject),
+		Actor:     optionalText(filter.Actor),
+		PageLimit: int32(pageSize) + 1,
+	}
+	if filter.Outcome != "" {
+		if filter.Outcome != OutcomeSuccess && filter.Outcome != OutcomeFailure {
+			return nil, "", ErrInvalidOutcome
+		}
+		params.Outcome = optionalText(filter.Outcome)
+	}
+	if !filter.Since.IsZero() {
+		params.Since = pgtype.Timestamp{Time: filter.Since.UTC(), Valid: true}
+	}
+	if !filter.Until.IsZero() {
+		params.Until = pgtype.Timestamp{Time: filter.Until.UTC(), Valid: true}
+	}
+
+	rows, err := s.store.ListAuditEvents(ctx, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var nextPageToken string
+	if len(rows) > pageSize {
+		rows = rows[:pageSize]
+		nextPageToken = encodeAuditPageToken(rows[len(rows)-1].ID)
+	}
+
+	events := make([]AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		event := AuditEvent{
+			ID:         row.ID,
+			OccurredAt: row.OccurredAt.Time,
+			EventType:  row.EventType,
+			Outcome:    row.Outcome,
+			Actor:      row.Actor,
+			Subject:    row.Subject,
+			IP:         row.Ip,
+			UserAgent:  row.UserAgent,
+			RequestID:  row.RequestID,
+			Tenant:     row.Tenant,
+			Seq:        row.Seq,
+			PrevHash:   row.PrevHash,
+			Hash:       row.Hash,
+		}
+		// Метаданные пишутся только этим сервисом; повреждённые не мешают выдаче остальных полей
+		if err := json.Unmarshal(row.Metadata, &event.Metadata); err != nil {
+			logging.FromContext(ctx).Warn("Invalid audit event metadata", "id", row.ID, logging.Err(err))
+		}
+		events = append(events, event)
+	}
+
+	return events, nextPageToken, nil
+}
+
+func optionalText(value string) pgtype.Text {
+	return pgtype.Text{String: value, Valid: value != ""}
+}
+
+func encodeAuditPageToken(lastID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastID, 10)))
+}
+
+func decodeAuditPageToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidPageToken
+	}
+
+	return id, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// События обслуживания самого журнала аудита
+const (
+	AuditChainPurge  = auditchain.PurgeEventType
+	AuditChainExport = "audit.export"
+)
+
+const (
+	// Размер страницы при чтении цепочки
+	auditChainPageSize = 1000
+	// Событий в одном выгружаемом пакете по умолчанию
+	DefaultExportBatchSize = 10000
+)
+
+type ExportAuditOptions struct {
+	// Выгружаются события с seq > AfterSeq (номер последнего события прошлой выгрузки)
+	AfterSeq  int64
+	BatchSize int
+}
+
+type ExportAuditResult struct {
+	// Имена пакетов без расширения, в порядке выгрузки
+	Batches  []string
+	Exported int
+	// seq последнего выгруженного события; AfterSeq для следующей выгрузки
+	LastSeq int64
+}
+
+// PurgeAuditEvents удаляет начало цепочки до последнего события старше occurredBefore.
+// Событие audit.purge с seq и хешем этого события пишется до удаления: если удаление не
+// выполнится, цепочка останется целой, а после удаления её начало подтверждено якорем
+func (s *adminService) PurgeAuditEvents(ctx context.Context, occurredBefore time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	anchor, err := s.store.GetAuditPurgeAnchor(ctx, store.GetAuditPurgeAnchorParams{
+		Tenant:     s.audit.tenant,
+		OccurredAt: pgtype.Timestamp{Time: occurredBefore.UTC(), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Без записанного якоря удалять нельзя: проверка цепочки сочла бы удаление подделкой
+	if err := s.audit.append(ctx, auditEntry{
+		EventType: AuditChainPurge,
+		Metadata: map[string]string{
+			auditchain.AnchorSeqKey:  strconv.FormatInt(anchor.Seq, 10),
+			auditchain.AnchorHashKey: anchor.Hash,
+			"cutoff":                 occurredBefore.UTC().Format(time.RFC3339),
+		},
+	}); err != nil {
+		return 0, fmt.Errorf("record purge anchor: %w", err)
+	}
+
+	return s.store.PurgeAuditEvents(ctx, store.PurgeAuditEventsParams{
+		Tenant: s.audit.tenant,
+		MaxSeq: anchor.Seq,
+	})
+}
+
+func (s *adminService) VerifyAuditChain(ctx context.Context) (*auditchain.Report, error) {
+	verifier := auditchain.NewVerifier(s.audit.tenant)
+	if err := s.scanAuditChain(ctx, 0, func(record auditchain.Record) error {
+		verifier.Add(record)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var head *auditchain.Head
+	row, err := s.store.GetAuditChainHead(ctx, s.audit.tenant)
+	switch {
+	case err == nil:
+		head = &auditchain.Head{Seq: row.Seq, Hash: row.Hash}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	return verifier.Finish(head), nil
+}
+
+func (s *adminService) ExportAuditEvents(ctx context.Context, sink auditchain.Sink, signer *auditchain.Signer, opts ExportAuditOptions) (result *ExportAuditResult, err error) {
+	if sink == nil || signer == nil || opts.AfterSeq < 0 {
+		return nil, ErrInvalidArgument
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultExportBatchSize
+	}
+
+	result = &ExportAuditResult{LastSeq: opts.AfterSeq}
+	defer func() {
+		metadata := map[string]string{
+			"after_seq": strconv.FormatInt(opts.AfterSeq, 10),
+			"exported":  strconv.Itoa(result.Exported),
+			"last_seq":  strconv.FormatInt(result.LastSeq, 10),
+		}
+		s.audit.record(ctx, auditEntry{EventType: AuditChainExport, Err: err, Metadata: metadata})
+	}()
+
+	batch := make([]auditchain.Record, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		name, err := s.putAuditBatch(ctx, sink, signer, batch)
+		if err != nil {
+			return err
+		}
+		result.Batches = append(result.Batches, name)
+		result.Exported += len(batch)
+		result.LastSeq = batch[len(batch)-1].Seq
+		batch = batch[:0]
+		return nil
+	}
+
+	err = s.scanAuditChain(ctx, opts.AfterSeq, func(record auditchain.Record) error {
+		// События до включения цепочки не подписываются: их нельзя проверить
+		if record.Hash == "" {
+			return nil
+		}
+		batch = append(batch, record)
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	return result, err
+}
+
+// putAuditBatch сохраняет пакет и затем его манифест: манифест в хранилище означает,
+// что пакет записан полностью
+func (s *adminService) putAuditBatch(ctx context.Context, sink auditchain.Sink, signer *auditchain.Signer, batch []auditchain.Record) (string, error) {
+	data, manifest, err := signer.SignBatch(batch, s.audit.now())
+	if err != nil {
+		return "", err
+	}
+	rawManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	name := auditchain.BatchName(manifest.Tenant, manifest.FirstSeq, manifest.LastSeq)
+	if err := sink.Put(ctx, name+".jsonl", data, "application/x-ndjson"); err != nil {
+		return "", fmt.Errorf("put batch %s: %w", name, err)
+	}
+	if err := sink.Put(ctx, name+".manifest.json", rawManifest, "application/json"); err != nil {
+		return "", fmt.Errorf("put manifest %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// scanAuditChain передаёт fn события цепочки тенанта с seq > afterSeq по возрастанию seq
+func (s *adminService) scanAuditChain(ctx context.Context, afterSeq int64, fn func(auditchain.Record) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rows, err := s.store.ListAuditChain(ctx, store.ListAuditChainParams{
+			Tenant:    s.audit.tenant,
+			AfterSeq:  afterSeq,
+			PageLimit: auditChainPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := fn(store.AuditRecord(row)); err != nil {
+				return err
+			}
+			afterSeq = row.Seq
+		}
+		if len(rows) < auditChainPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/store"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSink struct{}
+
+func (failingSink) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	return errors.New("bucket unavailable")
+}
+
+func TestAdminService_ExportAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret, WithAuditTenant("acme"))
+	admin := NewAdminService(userStore, WithAdminAuditTenant("acme"))
+
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret123", ""))
+	for i := 0; i < 4; i++ {
+		_, err := svc.ValidateCredentials(ctx, "alice", "wrong-password")
+		require.Error(t, err)
+	}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	signer := auditchain.NewSigner(key)
+	dir := t.TempDir()
+
+	result, err := admin.ExportAuditEvents(ctx, auditchain.NewFileSink(dir), signer, ExportAuditOptions{BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Exported)
+	assert.Equal(t, int64(5), result.LastSeq)
+	require.Len(t, result.Batches, 3)
+	assert.Equal(t, auditchain.BatchName("acme", 1, 2), result.Batches[0])
+
+	var prevHash string
+	for _, name := range result.Batches {
+		data, err := os.ReadFile(filepath.Join(dir, name+".jsonl"))
+		require.NoError(t, err)
+		rawManifest, err := os.ReadFile(filepath.Join(dir, name+".manifest.json"))
+		require.NoError(t, err)
+
+		var manifest auditchain.Manifest
+		require.NoError(t, json.Unmarshal(rawManifest, &manifest))
+		_, err = auditchain.VerifyBatch(data, &manifest, signer.PublicKey())
+		require.NoError(t, err)
+		assert.Equal(t, prevHash, manifest.PrevHash, "batches continue each other")
+		prevHash = manifest.LastHash
+	}
+
+	// Следующая выгрузка начинается после последней и включает событие о прошлой выгрузке
+	next, err := admin.ExportAuditEvents(ctx, auditchain.NewFileSink(dir), signer, ExportAuditOptions{AfterSeq: result.LastSeq})
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.Exported)
+
+	events := listAllAuditEvents(t, admin, AuditFilter{EventType: AuditChainExport})
+	require.Len(t, events, 2)
+	assert.Equal(t, "5", events[1].Metadata["exported"])
+	assert.Equal(t, "acme", events[1].Tenant)
+
+	_, err = admin.ExportAuditEvents(ctx, failingSink{}, signer, ExportAuditOptions{})
+	require.Error(t, err)
+	failed := listAllAuditEvents(t, admin, AuditFilter{EventType: AuditChainExport, Outcome: OutcomeFailure})
+	require.Len(t, failed, 1)
+	assert.Equal(t, "internal error", failed[0].Metadata["reason"])
+
+	_, err = admin.ExportAuditEvents(ctx, auditchain.NewFileSink(dir), nil, ExportAuditOptions{})
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
+
+func TestAdminService_VerifyAuditChain(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+	admin := NewAdminService(userStore)
+
+	report, err := admin.VerifyAuditChain(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Zero(t, report.Checked)
+
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret123", ""))
+	_, err = svc.ValidateCredentials(ctx, "alice", "secret123")
+	require.NoError(t, err)
+
+	// Цепочки тенантов независимы
+	other := NewAdminService(userStore, WithAdminAuditTenant("other"))
+	require.NoError(t, other.CreateUser(ctx, "bob", "secret123", RoleUser))
+
+	report, err = admin.VerifyAuditChain(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.OK(), "%+v", report.Breaks)
+	assert.Equal(t, int64(2), report.Checked)
+	assert.Equal(t, auditchain.DefaultTenant, report.Tenant)
+
+	// Запись, вставленная в обход цепочки, обнаруживается
+	_, err = userStore.InsertAuditEvent(ctx, store.InsertAuditEventParams{
+		Tenant: auditchain.DefaultTenant, Seq: 3, PrevHash: report.LastHash, Hash: "forged",
+		EventType: AuditUserLogin, Outcome: OutcomeSuccess, Metadata: []byte("{}"),
+	})
+	require.NoError(t, err)
+
+	report, err = admin.VerifyAuditChain(ctx)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	assert.Equal(t, int64(3), report.Breaks[0].Seq)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/logging"
+	"auth_test/internal/store"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listAllAuditEvents(t *testing.T, admin AdminService, filter AuditFilter) []AuditEvent {
+	t.Helper()
+
+	events, _, err := admin.ListAuditEvents(context.Background(), filter)
+	require.NoError(t, err)
+	return events
+}
+
+func TestAudit_UserEvents(t *testing.T) {
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+	admin := NewAdminService(userStore)
+
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: "203.0.113.7", UserAgent: "curl/8.0"})
+	ctx = logging.WithRequestID(ctx, "req-1")
+
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret123", ""))
+	_, err := svc.ValidateCredentials(ctx, "alice", "wrong-password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.ValidateCredentials(ctx, "alice", "secret123")
+	require.NoError(t, err)
+
+	refreshToken, err := svc.GenerateToken(ctx, "alice", TokenTypeRefresh)
+	require.NoError(t, err)
+	_, err = svc.RefreshToken(ctx, refreshToken)
+	require.NoError(t, err)
+	_, err = svc.RefreshToken(ctx, "not-a-token")
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	require.NoError(t, svc.ChangePassword(ctx, "alice", "secret123", "new-secret123"))
+
+	events := listAllAuditEvents(t, admin, AuditFilter{})
+	require.Len(t, events, 6)
+
+	tests := []struct {
+		eventType string
+		outcome   string
+		actor     string
+		subject   string
+		reason    string
+	}{
+		{AuditPasswordChange, OutcomeSuccess, "user:alice", "alice", ""},
+		{AuditTokenRefresh, OutcomeFailure, ActorAnonymous, "", "invalid token"},
+		{AuditTokenRefresh, OutcomeSuccess, "user:alice", "alice", ""},
+		{AuditUserLogin, OutcomeSuccess, "user:alice", "alice", ""},
+		{AuditUserLogin, OutcomeFailure, ActorAnonymous, "alice", "invalid credentials"},
+		{AuditUserRegister, OutcomeSuccess, ActorAnonymous, "alice", ""},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d_%s_%s", i, tt.eventType, tt.outcome), func(t *testing.T) {
+			event := events[i]
+			assert.Equal(t, tt.eventType, event.EventType)
+			assert.Equal(t, tt.outcome, event.Outcome)
+			assert.Equal(t, tt.actor, event.Actor)
+			assert.Equal(t, tt.subject, event.Subject)
+			assert.Equal(t, tt.reason, event.Metadata["reason"])
+			assert.Equal(t, "203.0.113.7", event.IP)
+			assert.Equal(t, "curl/8.0", event.UserAgent)
+			assert.Equal(t, "req-1", event.RequestID)
+			assert.WithinDuration(t, time.Now(), event.OccurredAt, time.Minute)
+		})
+	}
+}
+
+func TestAudit_AdminEvents(t *testing.T) {
+	admin := NewAdminService(store.NewInMemoryStore())
+	ctx := WithActor(context.Background(), "user:root")
+
+	require.NoError(t, admin.CreateUser(ctx, "bob", "secret123", ""))
+	require.NoError(t, admin.DisableUser(ctx, "bob", "suspicious activity"))
+	require.NoError(t, admin.EnableUser(ctx, "bob", ""))
+	require.NoError(t, admin.SetRole(ctx, "bob", RoleAdmin))
+	require.NoError(t, admin.ResetPassword(ctx, "bob", "other-secret"))
+	require.NoError(t, admin.RevokeSessions(ctx, "bob"))
+	require.NoError(t, admin.DeleteUser(ctx, "bob", ""))
+	assert.ErrorIs(t, admin.DisableUser(ctx, "ghost", ""), ErrUserNotFound)
+
+	// Пустой запуск задачи хранения не пишется в журнал
+	_, err := admin.PurgeDeletedUsers(WithActor(context.Background(), ActorRetention), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	purged, err := admin.PurgeDeletedUsers(WithActor(context.Background(), ActorRetention), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+
+	events := listAllAuditEvents(t, admin, AuditFilter{})
+	require.Len(t, events, 9)
+
+	var types []string
+	for _, event := range events {
+		types = append(types, event.EventType)
+	}
+	assert.Equal(t, []string{
+		AuditAdminUsersPurge,
+		AuditAdminUserDisable,
+		AuditAdminUserDelete,
+		AuditAdminSessionRevoke,
+		AuditAdminPasswordReset,
+		AuditAdminRoleChange,
+		AuditAdminUserEnable,
+		AuditAdminUserDisable,
+		AuditAdminUserCreate,
+	}, types)
+
+	assert.Equal(t, ActorRetention, events[0].Actor)
+	assert.Equal(t, "1", events[0].Metadata["purged"])
+	assert.Equal(t, OutcomeFailure, events[1].Outcome)
+	assert.Equal(t, "user not found", events[1].Metadata["reason"])
+	assert.Equal(t, "user:root", events[7].Actor)
+	assert.Equal(t, "suspicious activity", events[7].Metadata["status_reason"])
+	assert.Equal(t, RoleUser, events[8].Metadata["role"])
+}
+
+func TestAudit_InternalErrorsAreNotRecorded(t *testing.T) {
+	assert.Equal(t, "internal error", auditReason(errors.New("dial tcp 10.0.0.5:5432: connection refused")))
+	assert.Equal(t, "user disabled", auditReason(fmt.Errorf("login: %w", ErrUserDisabled)))
+	assert.Equal(t, "ab", truncate("abп", 3), "multi-byte characters are not split")
+}
+
+func TestAdminService_ListAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+	admin := NewAdminService(userStore)
+
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret123", ""))
+	for i := 0; i < 5; i++ {
+		_, err := svc.ValidateCredentials(ctx, "alice", "wrong-password")
+		require.Error(t, err)
+	}
+	_, err := svc.ValidateCredentials(ctx, "bob", "secret123")
+	require.Error(t, err)
+
+	t.Run("pagination", func(t *testing.T) {
+		filter := AuditFilter{EventType: AuditUserLogin, PageSize: 4}
+
+		first, token, err := admin.ListAuditEvents(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, first, 4)
+		require.NotEmpty(t, token)
+
+		filter.PageToken = token
+		second, token, err := admin.ListAuditEvents(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, second, 2)
+		assert.Empty(t, token)
+		assert.Greater(t, first[3].ID, second[0].ID)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			filter   AuditFilter
+			expected int
+		}{
+			{"subject", AuditFilter{Subject: "bob"}, 1},
+			{"outcome", AuditFilter{Outcome: OutcomeSuccess}, 1},
+			{"event type and subject", AuditFilter{EventType: AuditUserLogin, Subject: "alice"}, 5},
+			{"actor", AuditFilter{Actor: ActorAnonymous}, 7},
+			{"since", AuditFilter{Since: time.Now().Add(time.Minute)}, 0},
+			{"until", AuditFilter{Until: time.Now().Add(time.Minute)}, 7},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Len(t, listAllAuditEvents(t, admin, tt.filter), tt.expected)
+			})
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, _, err := admin.ListAuditEvents(ctx, AuditFilter{Outcome: "maybe"})
+		assert.ErrorIs(t, err, ErrInvalidOutcome)
+
+		_, _, err = admin.ListAuditEvents(ctx, AuditFilter{PageToken: "!!!"})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		purged, err := admin.PurgeAuditEvents(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), purged)
+
+		// Остаётся только событие audit.purge с якорем удалённой части цепочки
+		rest := listAllAuditEvents(t, admin, AuditFilter{})
+		require.Len(t, rest, 1)
+		assert.Equal(t, AuditChainPurge, rest[0].EventType)
+		assert.Equal(t, "7", rest[0].Metadata[auditchain.AnchorSeqKey])
+
+		report, err := admin.VerifyAuditChain(ctx)
+		require.NoError(t, err)
+		assert.True(t, report.OK(), "%+v", report.Breaks)
+
+		purged, err = admin.PurgeAuditEvents(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+	})
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"auth_test/internal/logging"
+	"auth_test/internal/passhash"
+	"auth_test/internal/store"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SeedUser - пользователь, создаваемый при старте, если его ещё нет.
+// Пароль задаётся хешем (bcrypt или другой формат из passhash) или файлом с паролем (например, docker secret)
+type SeedUser struct {
+	Username           string `yaml:"username"`
+	Role               string `yaml:"role"`
+	Email              string `yaml:"email"`
+	PasswordHash       string `yaml:"password_hash"`
+	PasswordFile       string `yaml:"password_file"`
+	MustChangePassword bool   `yaml:"must_change_password"`
+}
+
+// legacyDefaultPasswords - пароли, с которыми прежние версии сервиса создавали пользователей при старте
+var legacyDefaultPasswords = map[string]string{
+	"admin": "admin123",
+	"user":  "user123",
+}
+
+type BootstrapConfig struct {
+	Users []SeedUser
+	// Если задано и в базе нет ни одного администратора, он создаётся со сгенерированным паролем
+	GeneratedAdminUsername string
+	// Куда один раз выводится сгенерированный пароль
+	PasswordOutput io.Writer
+}
+
+// LoadSeedUsers читает список пользователей из YAML (или JSON) файла вида users: [...]
+func LoadSeedUsers(path string) ([]SeedUser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read seed users: %w", err)
+	}
+
+	var file struct {
+		Users []SeedUser `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse seed users %s: %w", path, err)
+	}
+	return file.Users, nil
+}
+
+// Bootstrap создаёт недостающих пользователей из конфигурации. Существующие пользователи не изменяются,
+// поэтому вызов безопасен при каждом старте и при одновременном запуске нескольких реплик
+func Bootstrap(ctx context.Context, s store.Querier, cfg BootstrapConfig) error {
+	params := make([]store.CreateUserParams, 0, len(cfg.Users))
+	for _, user := range cfg.Users {
+		p, err := seedUserParams(user)
+		if err != nil {
+			return fmt.Errorf("seed user %q: %w", user.Username, err)
+		}
+		params = append(params, p)
+	}
+
+	for _, p := range params {
+		created, err := createSeedUser(ctx, s, p)
+		if err != nil {
+			return fmt.Errorf("seed user %q: %w", p.Username, err)
+		}
+		if created {
+			logging.FromContext(ctx).Info("Seed user created", "username", p.Username, "role", p.Role)
+		}
+	}
+
+	if cfg.GeneratedAdminUsername == "" {
+		return nil
+	}
+
+	exists, err := s.AdminExists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	return createGeneratedAdmin(ctx, s, cfg.GeneratedAdminUsername, cfg.PasswordOutput)
+}
+
+// SecureLegacyUsers требует сменить пароль у пользователей, оставшихся от прежних версий
+// с публично известным паролем по умолчанию, и отзывает их токены. Вызывается при каждом старте,
+// в том числе в production, где Bootstrap отключён
+func SecureLegacyUsers(ctx context.Context, s store.Querier) error {
+	for username, password := range legacyDefaultPasswords {
+		user, err := s.GetUser(ctx, username)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("check legacy user %q: %w", username, err)
+		}
+		if user.PasswordChangeRequired || user.Status == StatusDeleted {
+			continue
+		}
+
+		ok, err := passhash.Verify(user.PasswordHash, password)
+		if err != nil || !ok {
+			continue
+		}
+
+		if _, err := s.SetUserPassword(ctx, store.SetUserPasswordParams{
+			PasswordHash:           user.PasswordHash,
+			PasswordChangeRequired: true,
+			Username:               username,
+		}); err != nil {
+			return fmt.Errorf("secure legacy user %q: %w", username, err)
+		}
+		logging.FromContext(ctx).Warn("Legacy user with default password must change it", "username", username)
+	}
+	return nil
+}
+
+func seedUserParams(user SeedUser) (store.CreateUserParams, error) {
+	if user.Username == "" {
+		return store.CreateUserParams{}, fmt.Errorf("%w: username is required", ErrInvalidArgument)
+	}
+
+	role := user.Role
+	if role == "" {
+		role = RoleUser
+	}
+	if role != RoleUser && role != RoleAdmin {
+		return store.CreateUserParams{}, ErrInvalidRole
+	}
+
+	var hash string
+	switch {
+	case user.PasswordHash != "" && user.PasswordFile != "":
+		return store.CreateUserParams{}, fmt.Errorf("%w: password_hash and password_file are mutually exclusive", ErrInvalidArgument)
+	case user.PasswordHash != "":
+		// Проверяем, что это действительно хеш, а не пароль в открытом виде
+		if _, err := passhash.Identify(user.PasswordHash); err != nil {
+			return store.CreateUserParams{}, fmt.Errorf("%w: password_hash: %v", ErrInvalidArgument, err)
+		}
+		hash = user.PasswordHash
+	case user.PasswordFile != "":
+		data, err := os.ReadFile(user.PasswordFile)
+		if err != nil {
+			return store.CreateUserParams{}, fmt.Errorf("read password file: %w", err)
+		}
+		password := strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return store.CreateUserParams{}, fmt.Errorf("%w: password file %s is empty", ErrInvalidArgument, user.PasswordFile)
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return store.CreateUserParams{}, err
+		}
+		hash = string(hashed)
+	default:
+		return store.CreateUserParams{}, fmt.Errorf("%w: password_hash or password_file is required", ErrInvalidArgument)
+	}
+
+	params := store.CreateUserParams{
+		Username:               user.Username,
+		PasswordHash:           hash,
+		Role:                   role,
+		Status:                 StatusActive,
+		PasswordChangeRequired: user.MustChangePassword,
+	}
+	if user.Email != "" {
+		params.Email = pgtype.Text{String: user.Email, Valid: true}
+	}
+	return params, nil
+}
+
+// createSeedUser возвращает false, если пользователь уже существует
+func createSeedUser(ctx context.Context, s store.Querier, params store.CreateUserParams) (bool, error) {
+	exists, err := s.UserExists(ctx, params.Username)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := s.CreateUser(ctx, params); err != nil {
+		// Другая реплика успела создать пользователя первой
+		if errors.Is(mapUniqueViolation(err), ErrUserAlreadyExists) {
+			return false, nil
+		}
+		return false, mapUniqueViolation(err)
+	}
+	return true, nil
+}
+
+func createGeneratedAdmin(ctx context.Context, s store.Querier, username string, out io.Writer) error {
+	password, err := generatePassword()
+	if err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	created, err := createSeedUser(ctx, s, store.CreateUserParams{
+		Username:               username,
+		PasswordHash:           string(hashed),
+		Role:                   RoleAdmin,
+		Status:                 StatusActive,
+		PasswordChangeRequired: true,
+	})
+	if err != nil {
+		return fmt.Errorf("create bootstrap admin: %w", err)
+	}
+	if !created {
+		// Имя занято. Если это не администратор, созданный параллельно другой репликой,
+		// сервис остался бы без администратора без единого сообщения
+		exists, err := s.AdminExists(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: no admin exists and bootstrap admin username %q belongs to a non-admin user; "+
+				"set BOOTSTRAP_ADMIN_USERNAME to a free name or grant the role with \"user set-role\"", ErrUserAlreadyExists, username)
+		}
+		return nil
+	}
+
+	if out == nil {
+		out = os.Stderr
+	}
+	// Пароль не пишется в лог: он выводится один раз и должен быть сменён при первом входе
+	fmt.Fprintf(out, "\n"+
+		"=================================================================\n"+
+		" Bootstrap admin created: %s\n"+
+		" One-time password:       %s\n"+
+		" The password must be changed on first login.\n"+
+		"=================================================================\n\n", username, password)
+	logging.FromContext(ctx).Info("Bootstrap admin created", "username", username)
+	return nil
+}
+
+func generatePassword() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"auth_test/internal/store"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBootstrap_GeneratedAdmin(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+
+	var out bytes.Buffer
+	cfg := BootstrapConfig{GeneratedAdminUsername: "admin", PasswordOutput: &out}
+	require.NoError(t, Bootstrap(ctx, userStore, cfg))
+
+	match := regexp.MustCompile(`One-time password:\s+(\S+)`).FindStringSubmatch(out.String())
+	require.Len(t, match, 2)
+	password := match[1]
+
+	// Пароль выводится только при первом запуске
+	out.Reset()
+	require.NoError(t, Bootstrap(ctx, userStore, cfg))
+	assert.Empty(t, out.String())
+
+	_, err := svc.ValidateCredentials(ctx, "admin", password)
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "admin", password, "short"), ErrWeakPassword)
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "admin", "wrong", "new-password"), ErrInvalidCredentials)
+	require.NoError(t, svc.ChangePassword(ctx, "admin", password, "new-password"))
+
+	ok, err := svc.ValidateCredentials(ctx, "admin", "new-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestBootstrap_SeedUsers(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "admin_password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("file-secret\n"), 0o600))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("hash-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	usersFile := filepath.Join(dir, "users.yaml")
+	require.NoError(t, os.WriteFile(usersFile, []byte(`
+users:
+  - username: root
+    role: admin
+    password_file: `+passwordFile+`
+  - username: alice
+    email: alice@example.com
+    password_hash: "`+string(hash)+`"
+    must_change_password: true
+`), 0o600))
+
+	users, err := LoadSeedUsers(usersFile)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	var out bytes.Buffer
+	require.NoError(t, Bootstrap(ctx, userStore, BootstrapConfig{
+		Users:                  users,
+		GeneratedAdminUsername: "admin",
+		PasswordOutput:         &out,
+	}))
+	assert.Empty(t, out.String(), "no password is generated when an admin is configured")
+
+	exists, err := userStore.UserExists(ctx, "admin")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	ok, err := svc.ValidateCredentials(ctx, "root", "file-secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = svc.ValidateCredentials(ctx, "alice", "hash-secret")
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	// Существующие пользователи не перезаписываются
+	require.NoError(t, os.WriteFile(passwordFile, []byte("changed"), 0o600))
+	require.NoError(t, Bootstrap(ctx, userStore, BootstrapConfig{Users: users}))
+	ok, err = svc.ValidateCredentials(ctx, "root", "file-secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestBootstrap_InvalidSeedUser(t *testing.T) {
+	tests := []struct {
+		name string
+		user SeedUser
+	}{
+		{name: "plain text hash", user: SeedUser{Username: "bob", PasswordHash: "password"}},
+		{name: "no password", user: SeedUser{Username: "bob"}},
+		{name: "both sources", user: SeedUser{Username: "bob", PasswordHash: "$2a$10$x", PasswordFile: "/tmp/x"}},
+		{name: "unknown role", user: SeedUser{Username: "bob", Role: "root", PasswordFile: "/tmp/x"}},
+		{name: "missing file", user: SeedUser{Username: "bob", PasswordFile: "/nonexistent/password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userStore := store.NewInMemoryStore()
+			err := Bootstrap(context.Background(), userStore, BootstrapConfig{Users: []SeedUser{tt.user}})
+			require.Error(t, err)
+
+			exists, err := userStore.UserExists(context.Background(), "bob")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestSecureLegacyUsers(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+
+	createUser := func(username, password, role string) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		require.NoError(t, err)
+		_, err = userStore.CreateUser(ctx, store.CreateUserParams{
+			Username:     username,
+			PasswordHash: string(hash),
+			Role:         role,
+			Status:       StatusActive,
+		})
+		require.NoError(t, err)
+	}
+	// Пользователи, созданные прежними версиями сервиса; admin уже сменил пароль
+	createUser("admin", "changed-password", RoleAdmin)
+	createUser("user", "user123", RoleUser)
+
+	require.NoError(t, SecureLegacyUsers(ctx, userStore))
+	// Повторный вызов ничего не меняет
+	require.NoError(t, SecureLegacyUsers(ctx, userStore))
+
+	ok, err := svc.ValidateCredentials(ctx, "admin", "changed-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = svc.ValidateCredentials(ctx, "user", "user123")
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	require.NoError(t, svc.ChangePassword(ctx, "user", "user123", "new-password"))
+	ok, err = svc.ValidateCredentials(ctx, "user", "new-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// После обновления прежний пользователь admin остаётся с ролью user: администратор не создаётся
+// и старт должен завершиться понятной ошибкой, а не молча
+func TestBootstrap_GeneratedAdminNameTaken(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("legacy-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	_, err = userStore.CreateUser(ctx, store.CreateUserParams{
+		Username:     "admin",
+		PasswordHash: string(hash),
+		Role:         RoleUser,
+		Status:       StatusActive,
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = Bootstrap(ctx, userStore, BootstrapConfig{GeneratedAdminUsername: "admin", PasswordOutput: &out})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"admin"`)
+	assert.Empty(t, out.String())
+
+	exists, err := userStore.AdminExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"auth_test/internal/logging"
+	"auth_test/internal/passhash"
+	"auth_test/internal/store"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// importBatchSize - число строк в одном COPY
+const importBatchSize = 1000
+
+// maxImportLineSize ограничивает длину строки JSONL
+const maxImportLineSize = 1 << 20
+
+var ErrInvalidFormat = errors.New("invalid import format")
+
+// csvColumns - колонки CSV в порядке экспорта; при импорте обязательны username и password_hash
+var csvColumns = []string{"username", "email", "role", "status", "password_hash", "must_change_password"}
+
+// UserRecord - строка файла импорта и экспорта
+type UserRecord struct {
+	Username           string `json:"username"`
+	Email              string `json:"email,omitempty"`
+	Role               string `json:"role,omitempty"`
+	Status             string `json:"status,omitempty"`
+	PasswordHash       string `json:"password_hash"`
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+}
+
+// ImportRowError - ошибка одной строки; Line считается с 1, для CSV включая заголовок
+type ImportRowError struct {
+	Line     int
+	Username string
+	Err      error
+}
+
+func (e ImportRowError) Error() string {
+	if e.Username == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d (%s): %v", e.Line, e.Username, e.Err)
+}
+
+func (e ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+type ImportResult struct {
+	Total    int
+	Imported int
+	Errors   []ImportRowError
+}
+
+type importRow struct {
+	line   int
+	params store.ImportUsersParams
+}
+
+// userImporter проверяет строки и записывает их пачками
+type userImporter struct {
+	store   store.Querier
+	result  *ImportResult
+	pending []importRow
+	// Дубликаты внутри файла; адреса в нижнем регистре
+	usernames map[string]bool
+	emails    map[string]bool
+}
+
+func (s *adminService) ImportUsers(ctx context.Context, r io.Reader, format string) (result *ImportResult, err error) {
+	// Одно событие на весь импорт; ошибки отдельных строк - в ответе вызывающему
+	defer func() {
+		metadata := map[string]string{"format": format}
+		if result != nil {
+			metadata["total"] = strconv.Itoa(result.Total)
+			metadata["imported"] = strconv.Itoa(result.Imported)
+			metadata["failed"] = strconv.Itoa(len(result.Errors))
+		}
+		s.audit.record(ctx, auditEntry{EventType: AuditAdminUsersImport, Err: err, Metadata: metadata})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	importer := &userImporter{
+		store:     s.store,
+		result:    &ImportResult{},
+		usernames: make(map[string]bool),
+		emails:    make(map[string]bool),
+	}
+
+	switch format {
+	case FormatCSV:
+		err = readCSVRecords(r, func(line int, record UserRecord, parseErr error) error {
+			return importer.add(ctx, line, record, parseErr)
+		})
+	case FormatJSONL:
+		err = readJSONLRecords(r, func(line int, record UserRecord, parseErr error) error {
+			return importer.add(ctx, line, record, parseErr)
+		})
+	default:
+		return nil, fmt.Errorf("%w: %q, expected csv or jsonl", ErrInvalidFormat, format)
+	}
+	if err == nil {
+		err = importer.flush(ctx)
+	}
+	if err != nil {
+		return importer.result, err
+	}
+
+	logging.FromContext(ctx).Info("Users imported",
+		"imported", importer.result.Imported, "total", importer.result.Total, "failed", len(importer.result.Errors))
+	return importer.result, nil
+}
+
+func (imp *userImporter) add(ctx context.Context, line int, record UserRecord, parseErr error) error {
+	imp.result.Total++
+	if parseErr != nil {
+		imp.fail(line, record.Username, parseErr)
+		return nil
+	}
+
+	params, err := importParams(record)
+	if err != nil {
+		imp.fail(line, record.Username, err)
+		return nil
+	}
+
+	if imp.usernames[params.Username] {
+		imp.fail(line, params.Username, fmt.Errorf("%w: duplicate username in file", ErrUserAlreadyExists))
+		return nil
+	}
+	if params.Email.Valid && imp.emails[strings.ToLower(params.Email.String)] {
+		imp.fail(line, params.Username, fmt.Errorf("%w: duplicate email in file", ErrEmailAlreadyInUse))
+		return nil
+	}
+	imp.usernames[params.Username] = true
+	if params.Email.Valid {
+		imp.emails[strings.ToLower(params.Email.String)] = true
+	}
+
+	imp.pending = append(imp.pending, importRow{line: line, params: params})
+	if len(imp.pending) >= importBatchSize {
+		return imp.flush(ctx)
+	}
+	return nil
+}
+
+// flush отбрасывает строки, конфликтующие с базой, и записывает остальные одним COPY.
+// Если между проверкой и записью кто-то создал такого же пользователя, пачка пишется построчно
+func (imp *userImporter) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+	batch := imp.pending
+	imp.pending = nil
+
+	usernames := make([]string, 0, len(batch))
+	var emails []string
+	for _, row := range batch {
+		usernames = append(usernames, row.params.Username)
+		if row.params.Email.Valid {
+			emails = append(emails, strings.ToLower(row.params.Email.String))
+		}
+	}
+
+	existingUsernames, err := imp.store.ExistingUsernames(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	var existingEmails []string
+	if len(emails) > 0 {
+		if existingEmails, err = imp.store.ExistingEmails(ctx, emails); err != nil {
+			return err
+		}
+	}
+	takenUsernames := toSet(existingUsernames)
+	takenEmails := toSet(existingEmails)
+
+	rows := make([]importRow, 0, len(batch))
+	for _, row := range batch {
+		switch {
+		case takenUsernames[row.params.Username]:
+			imp.fail(row.line, row.params.Username, ErrUserAlreadyExists)
+		case row.params.Email.Valid && takenEmails[strings.ToLower(row.params.Email.String)]:
+			imp.fail(row.line, row.params.Username, ErrEmailAlreadyInUse)
+		default:
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	params := make([]store.ImportUsersParams, 0, len(rows))
+	for _, row := range rows {
+		params = append(params, row.params)
+	}
+
+	imported, err := imp.store.ImportUsers(ctx, params)
+	if err == nil {
+		imp.result.Imported += int(imported)
+		return nil
+	}
+
+	mapped := mapUniqueViolation(err)
+	if !errors.Is(mapped, ErrUserAlreadyExists) && !errors.Is(mapped, ErrEmailAlreadyInUse) {
+		return err
+	}
+
+	for _, row := range rows {
+		if _, err := imp.store.CreateUser(ctx, store.CreateUserParams(row.params)); err != nil {
+			mapped := mapUniqueViolation(err)
+			if !errors.Is(mapped, ErrUserAlreadyExists) && !errors.Is(mapped, ErrEmailAlreadyInUse) {
+				return err
+			}
+			imp.fail(row.line, row.params.Username, mapped)
+			continue
+		}
+		imp.result.Imported++
+	}
+	return nil
+}
+
+func (imp *userImporter) fail(line int, username string, err error) {
+	imp.result.Errors = append(imp.result.Errors, ImportRowError{Line: line, Username: username, Err: err})
+}
+
+func importParams(record UserRecord) (store.ImportUsersParams, error) {
+	username := strings.TrimSpace(record.Username)
+	if username == "" {
+		return store.ImportUsersParams{}, fmt.Errorf("%w: username is required", ErrInvalidArgument)
+	}
+
+	role := record.Role
+	if role == "" {
+		role = RoleUser
+	}
+	if role != RoleUser && role != RoleAdmin {
+		return store.ImportUsersParams{}, ErrInvalidRole
+	}
+
+	status := record.Status
+	if status == "" {
+		status = StatusActive
+	}
+	if !isValidStatus(status) || status == StatusDeleted {
+		return store.ImportUsersParams{}, ErrInvalidStatus
+	}
+
+	// Пароли в открытом виде не принимаются
+	if _, err := passhash.Identify(record.PasswordHash); err != nil {
+		return store.ImportUsersParams{}, fmt.Errorf("%w: password_hash: %v", ErrInvalidArgument, err)
+	}
+
+	params := store.ImportUsersParams{
+		Username:               username,
+		PasswordHash:           record.PasswordHash,
+		Role:                   role,
+		Status:                 status,
+		PasswordChangeRequired: record.MustChangePassword,
+	}
+	if email := strings.TrimSpace(record.Email); email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			return store.ImportUsersParams{}, fmt.Errorf("%w: invalid email", ErrInvalidArgument)
+		}
+		params.Email = pgtype.Text{String: email, Valid: true}
+	}
+	return params, nil
+}
+
+// readCSVRecords читает CSV с заголовком; порядок колонок произвольный
+func readCSVRecords(r io.Reader, fn func(line int, record UserRecord, err error) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%w: read csv header: %v", ErrInvalidFormat, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(csvColumns, name) {
+			return fmt.Errorf("%w: unknown csv column %q", ErrInvalidFormat, name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"username", "password_hash"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("%w: csv column %q is required", ErrInvalidFormat, required)
+		}
+	}
+
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(parseErr.StartLine, UserRecord{}, fmt.Errorf("%w: %v", ErrInvalidArgument, parseErr.Err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		record := UserRecord{
+			Username:     field("username"),
+			Email:        field("email"),
+			Role:         field("role"),
+			Status:       field("status"),
+			PasswordHash: field("password_hash"),
+		}
+		var recordErr error
+		if value := field("must_change_password"); value != "" {
+			if record.MustChangePassword, err = strconv.ParseBool(value); err != nil {
+				recordErr = fmt.Errorf("%w: must_change_password must be true or false", ErrInvalidArgument)
+			}
+		}
+
+		if err := fn(line, record, recordErr); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSONLRecords читает по одному JSON объекту на строку, пустые строки пропускаются
+func readJSONLRecords(r io.Reader, fn func(line int, record UserRecord, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var record UserRecord
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+
+		var recordErr error
+		if err := decoder.Decode(&record); err != nil {
+			recordErr = fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+		}
+		if err := fn(line, record, recordErr); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: line %d: %v", ErrInvalidFormat, line+1, err)
+	}
+	return nil
+}
+
+// ExportUsers выгружает всех пользователей, кроме удалённых, вместе с хешами паролей
+// в формате, который принимает ImportUsers
+func (s *adminService) ExportUsers(ctx context.Context, w io.Writer, format string) (exported int, err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{
+			EventType: AuditAdminUsersExport,
+			Err:       err,
+			Metadata:  map[string]string{"format": format, "exported": strconv.Itoa(exported)},
+		})
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var write func(record UserRecord) error
+	var flush func() error
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return 0, err
+		}
+		write = func(record UserRecord) error {
+			return writer.Write([]string{
+				record.Username,
+				record.Email,
+				record.Role,
+				record.Status,
+				record.PasswordHash,
+				strconv.FormatBool(record.MustChangePassword),
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buffered)
+		encoder.SetEscapeHTML(false)
+		write = func(record UserRecord) error {
+			return encoder.Encode(record)
+		}
+		flush = buffered.Flush
+	default:
+		return 0, fmt.Errorf("%w: %q, expected csv or jsonl", ErrInvalidFormat, format)
+	}
+
+	var afterID int32
+	for {
+		rows, err := s.store.ExportUsers(ctx, store.ExportUsersParams{
+			AfterID:   afterID,
+			PageLimit: importBatchSize,
+		})
+		if err != nil {
+			return exported, err
+		}
+
+		for _, row := range rows {
+			if err := write(UserRecord{
+				Username:           row.Username,
+				Email:              row.Email.String,
+				Role:               row.Role,
+				Status:             row.Status,
+				PasswordHash:       row.PasswordHash,
+				MustChangePassword: row.PasswordChangeRequired,
+			}); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if len(rows) < importBatchSize {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+
+	if err := flush(); err != nil {
+		return exported, err
+	}
+
+	logging.FromContext(ctx).Info("Users exported", "exported", exported)
+	return exported, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
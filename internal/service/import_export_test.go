@@ -0,0 +1,240 @@
+package service
+
+import (
+	"auth_test/internal/passhash"
+	"auth_test/internal/store"
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Хеши пароля "secret123"
+const (
+	testPBKDF2Hash = "pbkdf2_sha256$1000$seasalt$Uxc9q1exjd73R/bOV1+XCRfBdA80RZQWCPtidXIAyIE="
+	testSSHAHash   = "{SSHA}JLOOnBk7GsGWEiCCq0n/Z1y3TkhzYWx0"
+)
+
+func importErrorLines(result *ImportResult) map[int]error {
+	lines := make(map[int]error, len(result.Errors))
+	for _, rowErr := range result.Errors {
+		lines[rowErr.Line] = rowErr.Err
+	}
+	return lines
+}
+
+func TestAdminService_ImportUsersCSV(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	admin := NewAdminService(userStore)
+	require.NoError(t, admin.CreateUser(ctx, "existing", "password", RoleUser))
+
+	input := strings.Join([]string{
+		"username,password_hash,email,role,status,must_change_password",
+		"alice," + testPBKDF2Hash + ",alice@example.com,admin,active,false",
+		"bob,\"" + testSSHAHash + "\",,,disabled,",
+		"existing," + testPBKDF2Hash + ",,,,",
+		"alice," + testPBKDF2Hash + ",,,,",
+		"carol,plaintext-password,,,,",
+		"dave," + testPBKDF2Hash + ",ALICE@example.com,,,",
+		"erin," + testPBKDF2Hash + ",,superuser,,",
+		"frank," + testPBKDF2Hash + ",,,deleted,",
+		"grace," + testPBKDF2Hash + ",,,,maybe",
+		"heidi,too,many,columns,here,now,!",
+	}, "\n")
+
+	result, err := admin.ImportUsers(ctx, strings.NewReader(input), FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Total)
+	assert.Equal(t, 2, result.Imported)
+
+	lines := importErrorLines(result)
+	require.Len(t, lines, 8)
+	assert.ErrorIs(t, lines[4], ErrUserAlreadyExists)
+	assert.ErrorIs(t, lines[5], ErrUserAlreadyExists)
+	assert.ErrorIs(t, lines[6], ErrInvalidArgument)
+	assert.ErrorIs(t, lines[7], ErrEmailAlreadyInUse)
+	assert.ErrorIs(t, lines[8], ErrInvalidRole)
+	assert.ErrorIs(t, lines[9], ErrInvalidStatus)
+	assert.ErrorIs(t, lines[10], ErrInvalidArgument)
+	assert.ErrorIs(t, lines[11], ErrInvalidArgument)
+
+	alice, err := userStore.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, alice.Role)
+	assert.Equal(t, "alice@example.com", alice.Email.String)
+
+	bob, err := userStore.GetUser(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, StatusDisabled, bob.Status)
+	assert.Equal(t, RoleUser, bob.Role)
+}
+
+func TestAdminService_ImportUsersInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	admin := NewAdminService(store.NewInMemoryStore())
+
+	_, err := admin.ImportUsers(ctx, strings.NewReader("{}"), "xml")
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = admin.ImportUsers(ctx, strings.NewReader("username,password\n"), FormatCSV)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = admin.ImportUsers(ctx, strings.NewReader("username,email\n"), FormatCSV)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestAdminService_ImportUsersJSONL(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	admin := NewAdminService(userStore)
+
+	input := `{"username":"alice","password_hash":"` + testPBKDF2Hash + `","must_change_password":true}
+
+{"username":"bob","password_hash":"` + testSSHAHash + `","unknown":1}
+not json
+`
+	result, err := admin.ImportUsers(ctx, strings.NewReader(input), FormatJSONL)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 1, result.Imported)
+
+	lines := importErrorLines(result)
+	require.Len(t, lines, 2)
+	assert.ErrorIs(t, lines[3], ErrInvalidArgument)
+	assert.ErrorIs(t, lines[4], ErrInvalidArgument)
+
+	alice, err := userStore.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, alice.PasswordChangeRequired)
+}
+
+func TestAdminService_ImportUsersBatches(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	admin := NewAdminService(userStore)
+
+	var input strings.Builder
+	input.WriteString("username,password_hash\n")
+	total := importBatchSize*2 + 10
+	for i := 0; i < total; i++ {
+		input.WriteString("user" + strconv.Itoa(i) + "," + testSSHAHash + "\n")
+	}
+
+	result, err := admin.ImportUsers(ctx, strings.NewReader(input.String()), FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, total, result.Imported)
+	assert.Empty(t, result.Errors)
+}
+
+func TestUserService_LegacyHashUpgradedOnLogin(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	admin := NewAdminService(userStore)
+	svc := NewUserService(userStore, testJWTSecret)
+
+	result, err := admin.ImportUsers(ctx, strings.NewReader(
+		"username,password_hash\nalice,"+testPBKDF2Hash+"\n"), FormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Imported)
+
+	_, err = svc.ValidateCredentials(ctx, "alice", "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	user, err := userStore.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, testPBKDF2Hash, user.PasswordHash, "failed login does not upgrade the hash")
+
+	ok, err := svc.ValidateCredentials(ctx, "alice", "secret123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	user, err = userStore.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	algorithm, err := passhash.Identify(user.PasswordHash)
+	require.NoError(t, err)
+	assert.Equal(t, passhash.Bcrypt, algorithm)
+	assert.Equal(t, int32(1), user.SessionVersion)
+
+	ok, err = svc.ValidateCredentials(ctx, "alice", "secret123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// Верный пароль не меняет хеш, если войти всё равно нельзя
+func TestUserService_LegacyHashNotUpgradedWithoutLogin(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	admin := NewAdminService(userStore)
+	svc := NewUserService(userStore, testJWTSecret)
+
+	result, err := admin.ImportUsers(ctx, strings.NewReader(
+		"username,password_hash,must_change_password\n"+
+			"bob,"+testPBKDF2Hash+",false\n"+
+			"carol,"+testPBKDF2Hash+",true\n"), FormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Imported)
+	require.NoError(t, admin.DisableUser(ctx, "bob", ""))
+
+	_, err = svc.ValidateCredentials(ctx, "bob", "secret123")
+	assert.ErrorIs(t, err, ErrUserDisabled)
+	_, err = svc.ValidateCredentials(ctx, "carol", "secret123")
+	assert.ErrorIs(t, err, ErrPasswordChangeRequired)
+
+	for _, username := range []string{"bob", "carol"} {
+		user, err := userStore.GetUser(ctx, username)
+		require.NoError(t, err)
+		assert.Equal(t, testPBKDF2Hash, user.PasswordHash, username)
+	}
+}
+
+func TestAdminService_ExportUsers(t *testing.T) {
+	ctx := context.Background()
+	source := store.NewInMemoryStore()
+	admin := NewAdminService(source)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	input := "username,email,role,password_hash,must_change_password\n" +
+		"alice,alice@example.com,admin," + string(bcryptHash) + ",true\n" +
+		"bob,,user," + testSSHAHash + ",false\n" +
+		"carol,,user," + testPBKDF2Hash + ",false\n"
+	_, err = admin.ImportUsers(ctx, strings.NewReader(input), FormatCSV)
+	require.NoError(t, err)
+	require.NoError(t, admin.DeleteUser(ctx, "carol", ""))
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			exported, err := admin.ExportUsers(ctx, &out, format)
+			require.NoError(t, err)
+			assert.Equal(t, 2, exported, "deleted users are not exported")
+
+			// Выгрузка загружается обратно без потерь
+			target := store.NewInMemoryStore()
+			result, err := NewAdminService(target).ImportUsers(ctx, &out, format)
+			require.NoError(t, err)
+			assert.Equal(t, 2, result.Imported)
+			assert.Empty(t, result.Errors)
+
+			alice, err := target.GetUser(ctx, "alice")
+			require.NoError(t, err)
+			assert.Equal(t, string(bcryptHash), alice.PasswordHash)
+			assert.Equal(t, RoleAdmin, alice.Role)
+			assert.Equal(t, "alice@example.com", alice.Email.String)
+			assert.True(t, alice.PasswordChangeRequired)
+
+			bob, err := target.GetUser(ctx, "bob")
+			require.NoError(t, err)
+			assert.Equal(t, testSSHAHash, bob.PasswordHash)
+		})
+	}
+
+	_, err = admin.ExportUsers(ctx, &bytes.Buffer{}, "xml")
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
@@ -0,0 +1,702 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: admin.go
+//
+// Generated by this command:
+//
+//	mockgen -source=admin.go -destination=mock_admin.go -package=service -typed
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	auditchain "auth_test/internal/auditchain"
+	context "context"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAdminService is a mock of AdminService interface.
+type MockAdminService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAdminServiceMockRecorder is the mock recorder for MockAdminService.
+type MockAdminServiceMockRecorder struct {
+	mock *MockAdminService
+}
+
+// NewMockAdminService creates a new mock instance.
+func NewMockAdminService(ctrl *gomock.Controller) *MockAdminService {
+	mock := &MockAdminService{ctrl: ctrl}
+	mock.recorder = &MockAdminServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminService) EXPECT() *MockAdminServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockAdminService) CreateUser(ctx context.Context, username, password, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, username, password, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAdminServiceMockRecorder) CreateUser(ctx, username, password, role any) *MockAdminServiceCreateUserCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAdminService)(nil).CreateUser), ctx, username, password, role)
+	return &MockAdminServiceCreateUserCall{Call: call}
+}
+
+// MockAdminServiceCreateUserCall wrap *gomock.Call
+type MockAdminServiceCreateUserCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceCreateUserCall) Return(arg0 error) *MockAdminServiceCreateUserCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceCreateUserCall) Do(f func(context.Context, string, string, string) error) *MockAdminServiceCreateUserCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceCreateUserCall) DoAndReturn(f func(context.Context, string, string, string) error) *MockAdminServiceCreateUserCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DeleteUser mocks base method.
+func (m *MockAdminService) DeleteUser(ctx context.Context, username, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, username, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockAdminServiceMockRecorder) DeleteUser(ctx, username, reason any) *MockAdminServiceDeleteUserCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockAdminService)(nil).DeleteUser), ctx, username, reason)
+	return &MockAdminServiceDeleteUserCall{Call: call}
+}
+
+// MockAdminServiceDeleteUserCall wrap *gomock.Call
+type MockAdminServiceDeleteUserCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceDeleteUserCall) Return(arg0 error) *MockAdminServiceDeleteUserCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceDeleteUserCall) Do(f func(context.Context, string, string) error) *MockAdminServiceDeleteUserCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceDeleteUserCall) DoAndReturn(f func(context.Context, string, string) error) *MockAdminServiceDeleteUserCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DisableUser mocks base method.
+func (m *MockAdminService) DisableUser(ctx context.Context, username, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", ctx, username, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockAdminServiceMockRecorder) DisableUser(ctx, username, reason any) *MockAdminServiceDisableUserCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockAdminService)(nil).DisableUser), ctx, username, reason)
+	return &MockAdminServiceDisableUserCall{Call: call}
+}
+
+// MockAdminServiceDisableUserCall wrap *gomock.Call
+type MockAdminServiceDisableUserCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceDisableUserCall) Return(arg0 error) *MockAdminServiceDisableUserCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceDisableUserCall) Do(f func(context.Context, string, string) error) *MockAdminServiceDisableUserCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceDisableUserCall) DoAndReturn(f func(context.Context, string, string) error) *MockAdminServiceDisableUserCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// EnableUser mocks base method.
+func (m *MockAdminService) EnableUser(ctx context.Context, username, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUser", ctx, username, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUser indicates an expected call of EnableUser.
+func (mr *MockAdminServiceMockRecorder) EnableUser(ctx, username, reason any) *MockAdminServiceEnableUserCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUser", reflect.TypeOf((*MockAdminService)(nil).EnableUser), ctx, username, reason)
+	return &MockAdminServiceEnableUserCall{Call: call}
+}
+
+// MockAdminServiceEnableUserCall wrap *gomock.Call
+type MockAdminServiceEnableUserCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceEnableUserCall) Return(arg0 error) *MockAdminServiceEnableUserCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceEnableUserCall) Do(f func(context.Context, string, string) error) *MockAdminServiceEnableUserCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceEnableUserCall) DoAndReturn(f func(context.Context, string, string) error) *MockAdminServiceEnableUserCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ExportAuditEvents mocks base method.
+func (m *MockAdminService) ExportAuditEvents(ctx context.Context, sink auditchain.Sink, signer *auditchain.Signer, opts ExportAuditOptions) (*ExportAuditResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportAuditEvents", ctx, sink, signer, opts)
+	ret0, _ := ret[0].(*ExportAuditResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportAuditEvents indicates an expected call of ExportAuditEvents.
+func (mr *MockAdminServiceMockRecorder) ExportAuditEvents(ctx, sink, signer, opts any) *MockAdminServiceExportAuditEventsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportAuditEvents", reflect.TypeOf((*MockAdminService)(nil).ExportAuditEvents), ctx, sink, signer, opts)
+	return &MockAdminServiceExportAuditEventsCall{Call: call}
+}
+
+// MockAdminServiceExportAuditEventsCall wrap *gomock.Call
+type MockAdminServiceExportAuditEventsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceExportAuditEventsCall) Return(arg0 *ExportAuditResult, arg1 error) *MockAdminServiceExportAuditEventsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceExportAuditEventsCall) Do(f func(context.Context, auditchain.Sink, *auditchain.Signer, ExportAuditOptions) (*ExportAuditResult, error)) *MockAdminServiceExportAuditEventsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceExportAuditEventsCall) DoAndReturn(f func(context.Context, auditchain.Sink, *auditchain.Signer, ExportAuditOptions) (*ExportAuditResult, error)) *MockAdminServiceExportAuditEventsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ExportUsers mocks base method.
+func (m *MockAdminService) ExportUsers(ctx context.Context, w io.Writer, format string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", ctx, w, format)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockAdminServiceMockRecorder) ExportUsers(ctx, w, format any) *MockAdminServiceExportUsersCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockAdminService)(nil).ExportUsers), ctx, w, format)
+	return &MockAdminServiceExportUsersCall{Call: call}
+}
+
+// MockAdminServiceExportUsersCall wrap *gomock.Call
+type MockAdminServiceExportUsersCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceExportUsersCall) Return(arg0 int, arg1 error) *MockAdminServiceExportUsersCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceExportUsersCall) Do(f func(context.Context, io.Writer, string) (int, error)) *MockAdminServiceExportUsersCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceExportUsersCall) DoAndReturn(f func(context.Context, io.Writer, string) (int, error)) *MockAdminServiceExportUsersCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// GetUser mocks base method.
+func (m *MockAdminService) GetUser(ctx context.Context, username string) (*UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(*UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAdminServiceMockRecorder) GetUser(ctx, username any) *MockAdminServiceGetUserCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAdminService)(nil).GetUser), ctx, username)
+	return &MockAdminServiceGetUserCall{Call: call}
+}
+
+// MockAdminServiceGetUserCall wrap *gomock.Call
+type MockAdminServiceGetUserCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceGetUserCall) Return(arg0 *UserInfo, arg1 error) *MockAdminServiceGetUserCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceGetUserCall) Do(f func(context.Context, string) (*UserInfo, error)) *MockAdminServiceGetUserCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceGetUserCall) DoAndReturn(f func(context.Context, string) (*UserInfo, error)) *MockAdminServiceGetUserCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ImportUsers mocks base method.
+func (m *MockAdminService) ImportUsers(ctx context.Context, r io.Reader, format string) (*ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", ctx, r, format)
+	ret0, _ := ret[0].(*ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockAdminServiceMockRecorder) ImportUsers(ctx, r, format any) *MockAdminServiceImportUsersCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockAdminService)(nil).ImportUsers), ctx, r, format)
+	return &MockAdminServiceImportUsersCall{Call: call}
+}
+
+// MockAdminServiceImportUsersCall wrap *gomock.Call
+type MockAdminServiceImportUsersCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceImportUsersCall) Return(arg0 *ImportResult, arg1 error) *MockAdminServiceImportUsersCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceImportUsersCall) Do(f func(context.Context, io.Reader, string) (*ImportResult, error)) *MockAdminServiceImportUsersCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceImportUsersCall) DoAndReturn(f func(context.Context, io.Reader, string) (*ImportResult, error)) *MockAdminServiceImportUsersCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAdminService) ListAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, filter)
+	ret0, _ := ret[0].([]AuditEvent)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAdminServiceMockRecorder) ListAuditEvents(ctx, filter any) *MockAdminServiceListAuditEventsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAdminService)(nil).ListAuditEvents), ctx, filter)
+	return &MockAdminServiceListAuditEventsCall{Call: call}
+}
+
+// MockAdminServiceListAuditEventsCall wrap *gomock.Call
+type MockAdminServiceListAuditEventsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceListAuditEventsCall) Return(arg0 []AuditEvent, arg1 string, arg2 error) *MockAdminServiceListAuditEventsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceListAuditEventsCall) Do(f func(context.Context, AuditFilter) ([]AuditEvent, string, error)) *MockAdminServiceListAuditEventsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceListAuditEventsCall) DoAndReturn(f func(context.Context, AuditFilter) ([]AuditEvent, string, error)) *MockAdminServiceListAuditEventsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListUsers mocks base method.
+func (m *MockAdminService) ListUsers(ctx context.Context, filter UserFilter) ([]UserInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]UserInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAdminServiceMockRecorder) ListUsers(ctx, filter any) *MockAdminServiceListUsersCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAdminService)(nil).ListUsers), ctx, filter)
+	return &MockAdminServiceListUsersCall{Call: call}
+}
+
+// MockAdminServiceListUsersCall wrap *gomock.Call
+type MockAdminServiceListUsersCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceListUsersCall) Return(arg0 []UserInfo, arg1 string, arg2 error) *MockAdminServiceListUsersCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceListUsersCall) Do(f func(context.Context, UserFilter) ([]UserInfo, string, error)) *MockAdminServiceListUsersCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceListUsersCall) DoAndReturn(f func(context.Context, UserFilter) ([]UserInfo, string, error)) *MockAdminServiceListUsersCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PurgeAuditEvents mocks base method.
+func (m *MockAdminService) PurgeAuditEvents(ctx context.Context, occurredBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeAuditEvents", ctx, occurredBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeAuditEvents indicates an expected call of PurgeAuditEvents.
+func (mr *MockAdminServiceMockRecorder) PurgeAuditEvents(ctx, occurredBefore any) *MockAdminServicePurgeAuditEventsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeAuditEvents", reflect.TypeOf((*MockAdminService)(nil).PurgeAuditEvents), ctx, occurredBefore)
+	return &MockAdminServicePurgeAuditEventsCall{Call: call}
+}
+
+// MockAdminServicePurgeAuditEventsCall wrap *gomock.Call
+type MockAdminServicePurgeAuditEventsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServicePurgeAuditEventsCall) Return(arg0 int64, arg1 error) *MockAdminServicePurgeAuditEventsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServicePurgeAuditEventsCall) Do(f func(context.Context, time.Time) (int64, error)) *MockAdminServicePurgeAuditEventsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServicePurgeAuditEventsCall) DoAndReturn(f func(context.Context, time.Time) (int64, error)) *MockAdminServicePurgeAuditEventsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockAdminService) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", ctx, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockAdminServiceMockRecorder) PurgeDeletedUsers(ctx, deletedBefore any) *MockAdminServicePurgeDeletedUsersCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockAdminService)(nil).PurgeDeletedUsers), ctx, deletedBefore)
+	return &MockAdminServicePurgeDeletedUsersCall{Call: call}
+}
+
+// MockAdminServicePurgeDeletedUsersCall wrap *gomock.Call
+type MockAdminServicePurgeDeletedUsersCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServicePurgeDeletedUsersCall) Return(arg0 int64, arg1 error) *MockAdminServicePurgeDeletedUsersCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServicePurgeDeletedUsersCall) Do(f func(context.Context, time.Time) (int64, error)) *MockAdminServicePurgeDeletedUsersCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServicePurgeDeletedUsersCall) DoAndReturn(f func(context.Context, time.Time) (int64, error)) *MockAdminServicePurgeDeletedUsersCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PurgeUnverifiedUsers mocks base method.
+func (m *MockAdminService) PurgeUnverifiedUsers(ctx context.Context, registeredBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUnverifiedUsers", ctx, registeredBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeUnverifiedUsers indicates an expected call of PurgeUnverifiedUsers.
+func (mr *MockAdminServiceMockRecorder) PurgeUnverifiedUsers(ctx, registeredBefore any) *MockAdminServicePurgeUnverifiedUsersCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUnverifiedUsers", reflect.TypeOf((*MockAdminService)(nil).PurgeUnverifiedUsers), ctx, registeredBefore)
+	return &MockAdminServicePurgeUnverifiedUsersCall{Call: call}
+}
+
+// MockAdminServicePurgeUnverifiedUsersCall wrap *gomock.Call
+type MockAdminServicePurgeUnverifiedUsersCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServicePurgeUnverifiedUsersCall) Return(arg0 int64, arg1 error) *MockAdminServicePurgeUnverifiedUsersCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServicePurgeUnverifiedUsersCall) Do(f func(context.Context, time.Time) (int64, error)) *MockAdminServicePurgeUnverifiedUsersCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServicePurgeUnverifiedUsersCall) DoAndReturn(f func(context.Context, time.Time) (int64, error)) *MockAdminServicePurgeUnverifiedUsersCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ResetPassword mocks base method.
+func (m *MockAdminService) ResetPassword(ctx context.Context, username, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAdminServiceMockRecorder) ResetPassword(ctx, username, password any) *MockAdminServiceResetPasswordCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAdminService)(nil).ResetPassword), ctx, username, password)
+	return &MockAdminServiceResetPasswordCall{Call: call}
+}
+
+// MockAdminServiceResetPasswordCall wrap *gomock.Call
+type MockAdminServiceResetPasswordCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceResetPasswordCall) Return(arg0 error) *MockAdminServiceResetPasswordCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceResetPasswordCall) Do(f func(context.Context, string, string) error) *MockAdminServiceResetPasswordCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceResetPasswordCall) DoAndReturn(f func(context.Context, string, string) error) *MockAdminServiceResetPasswordCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// RevokeSessions mocks base method.
+func (m *MockAdminService) RevokeSessions(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSessions", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSessions indicates an expected call of RevokeSessions.
+func (mr *MockAdminServiceMockRecorder) RevokeSessions(ctx, username any) *MockAdminServiceRevokeSessionsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSessions", reflect.TypeOf((*MockAdminService)(nil).RevokeSessions), ctx, username)
+	return &MockAdminServiceRevokeSessionsCall{Call: call}
+}
+
+// MockAdminServiceRevokeSessionsCall wrap *gomock.Call
+type MockAdminServiceRevokeSessionsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceRevokeSessionsCall) Return(arg0 error) *MockAdminServiceRevokeSessionsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceRevokeSessionsCall) Do(f func(context.Context, string) error) *MockAdminServiceRevokeSessionsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceRevokeSessionsCall) DoAndReturn(f func(context.Context, string) error) *MockAdminServiceRevokeSessionsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetRole mocks base method.
+func (m *MockAdminService) SetRole(ctx context.Context, username, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, username, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockAdminServiceMockRecorder) SetRole(ctx, username, role any) *MockAdminServiceSetRoleCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockAdminService)(nil).SetRole), ctx, username, role)
+	return &MockAdminServiceSetRoleCall{Call: call}
+}
+
+// MockAdminServiceSetRoleCall wrap *gomock.Call
+type MockAdminServiceSetRoleCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceSetRoleCall) Return(arg0 error) *MockAdminServiceSetRoleCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceSetRoleCall) Do(f func(context.Context, string, string) error) *MockAdminServiceSetRoleCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceSetRoleCall) DoAndReturn(f func(context.Context, string, string) error) *MockAdminServiceSetRoleCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// VerifyAuditChain mocks base method.
+func (m *MockAdminService) VerifyAuditChain(ctx context.Context) (*auditchain.Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAuditChain", ctx)
+	ret0, _ := ret[0].(*auditchain.Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAuditChain indicates an expected call of VerifyAuditChain.
+func (mr *MockAdminServiceMockRecorder) VerifyAuditChain(ctx any) *MockAdminServiceVerifyAuditChainCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAuditChain", reflect.TypeOf((*MockAdminService)(nil).VerifyAuditChain), ctx)
+	return &MockAdminServiceVerifyAuditChainCall{Call: call}
+}
+
+// MockAdminServiceVerifyAuditChainCall wrap *gomock.Call
+type MockAdminServiceVerifyAuditChainCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAdminServiceVerifyAuditChainCall) Return(arg0 *auditchain.Report, arg1 error) *MockAdminServiceVerifyAuditChainCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAdminServiceVerifyAuditChainCall) Do(f func(context.Context) (*auditchain.Report, error)) *MockAdminServiceVerifyAuditChainCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAdminServiceVerifyAuditChainCall) DoAndReturn(f func(context.Context) (*auditchain.Report, error)) *MockAdminServiceVerifyAuditChainCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -40,18 +40,56 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUserService) ChangePassword(ctx context.Context, username, currentPassword, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, username, currentPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserServiceMockRecorder) ChangePassword(ctx, username, currentPassword, newPassword any) *MockUserServiceChangePasswordCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserService)(nil).ChangePassword), ctx, username, currentPassword, newPassword)
+	return &MockUserServiceChangePasswordCall{Call: call}
+}
+
+// MockUserServiceChangePasswordCall wrap *gomock.Call
+type MockUserServiceChangePasswordCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockUserServiceChangePasswordCall) Return(arg0 error) *MockUserServiceChangePasswordCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockUserServiceChangePasswordCall) Do(f func(context.Context, string, string, string) error) *MockUserServiceChangePasswordCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockUserServiceChangePasswordCall) DoAndReturn(f func(context.Context, string, string, string) error) *MockUserServiceChangePasswordCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CreateUser mocks base method.
-func (m *MockUserService) CreateUser(ctx context.Context, username, password string) error {
+func (m *MockUserService) CreateUser(ctx context.Context, username, password, email string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", ctx, username, password)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, username, password, email)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserServiceMockRecorder) CreateUser(ctx, username, password any) *MockUserServiceCreateUserCall {
+func (mr *MockUserServiceMockRecorder) CreateUser(ctx, username, password, email any) *MockUserServiceCreateUserCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), ctx, username, password)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), ctx, username, password, email)
 	return &MockUserServiceCreateUserCall{Call: call}
 }
 
@@ -67,13 +105,13 @@ func (c *MockUserServiceCreateUserCall) Return(arg0 error) *MockUserServiceCreat
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockUserServiceCreateUserCall) Do(f func(context.Context, string, string) error) *MockUserServiceCreateUserCall {
+func (c *MockUserServiceCreateUserCall) Do(f func(context.Context, string, string, string) error) *MockUserServiceCreateUserCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockUserServiceCreateUserCall) DoAndReturn(f func(context.Context, string, string) error) *MockUserServiceCreateUserCall {
+func (c *MockUserServiceCreateUserCall) DoAndReturn(f func(context.Context, string, string, string) error) *MockUserServiceCreateUserCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -117,6 +155,84 @@ func (c *MockUserServiceGenerateTokenCall) DoAndReturn(f func(context.Context, s
 	return c
 }
 
+// GetProfile mocks base method.
+func (m *MockUserService) GetProfile(ctx context.Context, username string) (*User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, username)
+	ret0, _ := ret[0].(*User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockUserServiceMockRecorder) GetProfile(ctx, username any) *MockUserServiceGetProfileCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockUserService)(nil).GetProfile), ctx, username)
+	return &MockUserServiceGetProfileCall{Call: call}
+}
+
+// MockUserServiceGetProfileCall wrap *gomock.Call
+type MockUserServiceGetProfileCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockUserServiceGetProfileCall) Return(arg0 *User, arg1 error) *MockUserServiceGetProfileCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockUserServiceGetProfileCall) Do(f func(context.Context, string) (*User, error)) *MockUserServiceGetProfileCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockUserServiceGetProfileCall) DoAndReturn(f func(context.Context, string) (*User, error)) *MockUserServiceGetProfileCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ParseAccessToken mocks base method.
+func (m *MockUserService) ParseAccessToken(ctx context.Context, token string) (*TokenClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseAccessToken", ctx, token)
+	ret0, _ := ret[0].(*TokenClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseAccessToken indicates an expected call of ParseAccessToken.
+func (mr *MockUserServiceMockRecorder) ParseAccessToken(ctx, token any) *MockUserServiceParseAccessTokenCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseAccessToken", reflect.TypeOf((*MockUserService)(nil).ParseAccessToken), ctx, token)
+	return &MockUserServiceParseAccessTokenCall{Call: call}
+}
+
+// MockUserServiceParseAccessTokenCall wrap *gomock.Call
+type MockUserServiceParseAccessTokenCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockUserServiceParseAccessTokenCall) Return(arg0 *TokenClaims, arg1 error) *MockUserServiceParseAccessTokenCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockUserServiceParseAccessTokenCall) Do(f func(context.Context, string) (*TokenClaims, error)) *MockUserServiceParseAccessTokenCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockUserServiceParseAccessTokenCall) DoAndReturn(f func(context.Context, string) (*TokenClaims, error)) *MockUserServiceParseAccessTokenCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // RefreshToken mocks base method.
 func (m *MockUserService) RefreshToken(ctx context.Context, token string) (string, error) {
 	m.ctrl.T.Helper()
@@ -156,142 +272,194 @@ func (c *MockUserServiceRefreshTokenCall) DoAndReturn(f func(context.Context, st
 	return c
 }
 
-// ValidateCredentials mocks base method.
-func (m *MockUserService) ValidateCredentials(ctx context.Context, username, password string) (bool, error) {
+// Register mocks base method.
+func (m *MockUserService) Register(ctx context.Context, username, password, email string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateCredentials", ctx, username, password)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Register", ctx, username, password, email)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ValidateCredentials indicates an expected call of ValidateCredentials.
-func (mr *MockUserServiceMockRecorder) ValidateCredentials(ctx, username, password any) *MockUserServiceValidateCredentialsCall {
+// Register indicates an expected call of Register.
+func (mr *MockUserServiceMockRecorder) Register(ctx, username, password, email any) *MockUserServiceRegisterCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCredentials", reflect.TypeOf((*MockUserService)(nil).ValidateCredentials), ctx, username, password)
-	return &MockUserServiceValidateCredentialsCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, username, password, email)
+	return &MockUserServiceRegisterCall{Call: call}
 }
 
-// MockUserServiceValidateCredentialsCall wrap *gomock.Call
-type MockUserServiceValidateCredentialsCall struct {
+// MockUserServiceRegisterCall wrap *gomock.Call
+type MockUserServiceRegisterCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockUserServiceValidateCredentialsCall) Return(arg0 bool, arg1 error) *MockUserServiceValidateCredentialsCall {
-	c.Call = c.Call.Return(arg0, arg1)
+func (c *MockUserServiceRegisterCall) Return(arg0 error) *MockUserServiceRegisterCall {
+	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockUserServiceValidateCredentialsCall) Do(f func(context.Context, string, string) (bool, error)) *MockUserServiceValidateCredentialsCall {
+func (c *MockUserServiceRegisterCall) Do(f func(context.Context, string, string, string) error) *MockUserServiceRegisterCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockUserServiceValidateCredentialsCall) DoAndReturn(f func(context.Context, string, string) (bool, error)) *MockUserServiceValidateCredentialsCall {
+func (c *MockUserServiceRegisterCall) DoAndReturn(f func(context.Context, string, string, string) error) *MockUserServiceRegisterCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// MockUserStore is a mock of UserStore interface.
-type MockUserStore struct {
-	ctrl     *gomock.Controller
-	recorder *MockUserStoreMockRecorder
-	isgomock struct{}
+// ResendVerification mocks base method.
+func (m *MockUserService) ResendVerification(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
-type MockUserStoreMockRecorder struct {
-	mock *MockUserStore
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockUserServiceMockRecorder) ResendVerification(ctx, email any) *MockUserServiceResendVerificationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockUserService)(nil).ResendVerification), ctx, email)
+	return &MockUserServiceResendVerificationCall{Call: call}
 }
 
-// NewMockUserStore creates a new mock instance.
-func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
-	mock := &MockUserStore{ctrl: ctrl}
-	mock.recorder = &MockUserStoreMockRecorder{mock}
-	return mock
+// MockUserServiceResendVerificationCall wrap *gomock.Call
+type MockUserServiceResendVerificationCall struct {
+	*gomock.Call
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
-	return m.recorder
+// Return rewrite *gomock.Call.Return
+func (c *MockUserServiceResendVerificationCall) Return(arg0 error) *MockUserServiceResendVerificationCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockUserServiceResendVerificationCall) Do(f func(context.Context, string) error) *MockUserServiceResendVerificationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockUserServiceResendVerificationCall) DoAndReturn(f func(context.Context, string) error) *MockUserServiceResendVerificationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
 }
 
-// Get mocks base method.
-func (m *MockUserStore) Get(ctx context.Context, username string) (*User, error) {
+// UpdateProfile mocks base method.
+func (m *MockUserService) UpdateProfile(ctx context.Context, username string, update ProfileUpdate) (*User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, username)
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, username, update)
 	ret0, _ := ret[0].(*User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Get indicates an expected call of Get.
-func (mr *MockUserStoreMockRecorder) Get(ctx, username any) *MockUserStoreGetCall {
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserServiceMockRecorder) UpdateProfile(ctx, username, update any) *MockUserServiceUpdateProfileCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), ctx, username, update)
+	return &MockUserServiceUpdateProfileCall{Call: call}
+}
+
+// MockUserServiceUpdateProfileCall wrap *gomock.Call
+type MockUserServiceUpdateProfileCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockUserServiceUpdateProfileCall) Return(arg0 *User, arg1 error) *MockUserServiceUpdateProfileCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockUserServiceUpdateProfileCall) Do(f func(context.Context, string, ProfileUpdate) (*User, error)) *MockUserServiceUpdateProfileCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockUserServiceUpdateProfileCall) DoAndReturn(f func(context.Context, string, ProfileUpdate) (*User, error)) *MockUserServiceUpdateProfileCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ValidateCredentials mocks base method.
+func (m *MockUserService) ValidateCredentials(ctx context.Context, username, password string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateCredentials", ctx, username, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateCredentials indicates an expected call of ValidateCredentials.
+func (mr *MockUserServiceMockRecorder) ValidateCredentials(ctx, username, password any) *MockUserServiceValidateCredentialsCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserStore)(nil).Get), ctx, username)
-	return &MockUserStoreGetCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCredentials", reflect.TypeOf((*MockUserService)(nil).ValidateCredentials), ctx, username, password)
+	return &MockUserServiceValidateCredentialsCall{Call: call}
 }
 
-// MockUserStoreGetCall wrap *gomock.Call
-type MockUserStoreGetCall struct {
+// MockUserServiceValidateCredentialsCall wrap *gomock.Call
+type MockUserServiceValidateCredentialsCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockUserStoreGetCall) Return(arg0 *User, arg1 error) *MockUserStoreGetCall {
+func (c *MockUserServiceValidateCredentialsCall) Return(arg0 bool, arg1 error) *MockUserServiceValidateCredentialsCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockUserStoreGetCall) Do(f func(context.Context, string) (*User, error)) *MockUserStoreGetCall {
+func (c *MockUserServiceValidateCredentialsCall) Do(f func(context.Context, string, string) (bool, error)) *MockUserServiceValidateCredentialsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockUserStoreGetCall) DoAndReturn(f func(context.Context, string) (*User, error)) *MockUserStoreGetCall {
+func (c *MockUserServiceValidateCredentialsCall) DoAndReturn(f func(context.Context, string, string) (bool, error)) *MockUserServiceValidateCredentialsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// Store mocks base method.
-func (m *MockUserStore) Store(ctx context.Context, user User) error {
+// VerifyEmail mocks base method.
+func (m *MockUserService) VerifyEmail(ctx context.Context, token string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Store", ctx, user)
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Store indicates an expected call of Store.
-func (mr *MockUserStoreMockRecorder) Store(ctx, user any) *MockUserStoreStoreCall {
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserServiceMockRecorder) VerifyEmail(ctx, token any) *MockUserServiceVerifyEmailCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockUserStore)(nil).Store), ctx, user)
-	return &MockUserStoreStoreCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserService)(nil).VerifyEmail), ctx, token)
+	return &MockUserServiceVerifyEmailCall{Call: call}
 }
 
-// MockUserStoreStoreCall wrap *gomock.Call
-type MockUserStoreStoreCall struct {
+// MockUserServiceVerifyEmailCall wrap *gomock.Call
+type MockUserServiceVerifyEmailCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockUserStoreStoreCall) Return(arg0 error) *MockUserStoreStoreCall {
+func (c *MockUserServiceVerifyEmailCall) Return(arg0 error) *MockUserServiceVerifyEmailCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockUserStoreStoreCall) Do(f func(context.Context, User) error) *MockUserStoreStoreCall {
+func (c *MockUserServiceVerifyEmailCall) Do(f func(context.Context, string) error) *MockUserServiceVerifyEmailCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockUserStoreStoreCall) DoAndReturn(f func(context.Context, User) error) *MockUserStoreStoreCall {
+func (c *MockUserServiceVerifyEmailCall) DoAndReturn(f func(context.Context, string) error) *MockUserServiceVerifyEmailCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"auth_test/internal/logging"
+	"auth_test/internal/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/language"
+)
+
+// Поля профиля, которые можно изменять через UpdateProfile и отображать в claims
+const (
+	ProfileFieldEmail       = "email"
+	ProfileFieldDisplayName = "display_name"
+	ProfileFieldLocale      = "locale"
+	ProfileFieldTimezone    = "timezone"
+	ProfileFieldAttributes  = "attributes"
+)
+
+const maxDisplayNameLength = 255
+
+var (
+	ErrVersionConflict = errors.New("profile was modified concurrently")
+	ErrInvalidProfile  = errors.New("invalid profile")
+)
+
+// reservedClaims нельзя переопределить полями профиля
+var reservedClaims = map[string]bool{
+	"sub": true, "exp": true, "iat": true, "nbf": true, "iss": true, "aud": true, "jti": true,
+	"type": true, "role": true, "sv": true,
+}
+
+// ProfileUpdate описывает изменение профиля. Применяются только поля из Mask
+type ProfileUpdate struct {
+	Mask            []string
+	Email           string
+	DisplayName     string
+	Locale          string
+	Timezone        string
+	Attributes      map[string]interface{}
+	ExpectedVersion int32
+}
+
+// WithProfileClaims добавляет в access токены поля профиля.
+// mapping: имя claim -> поле профиля (email, display_name, locale, timezone или attributes.<ключ>)
+func WithProfileClaims(mapping map[string]string) UserServiceOption {
+	return func(s *userService) {
+		s.profileClaims = mapping
+	}
+}
+
+// ParseClaimMapping разбирает записи вида "claim=field", например "name=display_name"
+func ParseClaimMapping(entries []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		claim, field, ok := strings.Cut(entry, "=")
+		claim, field = strings.TrimSpace(claim), strings.TrimSpace(field)
+		if !ok || claim == "" || field == "" {
+			return nil, fmt.Errorf("invalid claim mapping %q: expected claim=field", entry)
+		}
+
+		if reservedClaims[claim] {
+			return nil, fmt.Errorf("claim %q is reserved", claim)
+		}
+
+		switch {
+		case field == ProfileFieldEmail, field == ProfileFieldDisplayName,
+			field == ProfileFieldLocale, field == ProfileFieldTimezone:
+		case strings.HasPrefix(field, ProfileFieldAttributes+".") && len(field) > len(ProfileFieldAttributes)+1:
+		default:
+			return nil, fmt.Errorf("unknown profile field %q in claim mapping", field)
+		}
+
+		mapping[claim] = field
+	}
+
+	return mapping, nil
+}
+
+func (s *userService) GetProfile(ctx context.Context, username string) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	row, err := s.store.GetProfile(ctx, username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return profileFromRow(store.UpdateProfileRow(row))
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, username string, update ProfileUpdate) (*User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetProfile(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != update.ExpectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	next := *current
+	if err := applyProfileUpdate(&next, update); err != nil {
+		return nil, err
+	}
+
+	attributes, err := json.Marshal(next.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attributes: %v", ErrInvalidProfile, err)
+	}
+
+	params := store.UpdateProfileParams{
+		DisplayName:     next.DisplayName,
+		Locale:          next.Locale,
+		Timezone:        next.Timezone,
+		Attributes:      attributes,
+		Username:        username,
+		ExpectedVersion: update.ExpectedVersion,
+	}
+	if next.Email != "" {
+		params.Email = pgtype.Text{String: next.Email, Valid: true}
+	}
+
+	row, err := s.store.UpdateProfile(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Версия изменилась между чтением и записью
+			return nil, ErrVersionConflict
+		}
+		return nil, mapUniqueViolation(err)
+	}
+
+	updated, err := profileFromRow(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.verification != nil && updated.Email != "" && !strings.EqualFold(updated.Email, current.Email) {
+		if err := s.sendVerification(ctx, username, updated.Email); err != nil {
+			logging.FromContext(ctx).Error("Failed to send verification email", "username", username, logging.Err(err))
+		}
+	}
+
+	return updated, nil
+}
+
+func applyProfileUpdate(user *User, update ProfileUpdate) error {
+	if len(update.Mask) == 0 {
+		return fmt.Errorf("%w: update mask is empty", ErrInvalidProfile)
+	}
+
+	for _, field := range update.Mask {
+		switch field {
+		case ProfileFieldEmail:
+			if update.Email != "" {
+				if _, err := mail.ParseAddress(update.Email); err != nil {
+					return fmt.Errorf("%w: email", ErrInvalidProfile)
+				}
+			}
+			user.Email = update.Email
+		case ProfileFieldDisplayName:
+			if len(update.DisplayName) > maxDisplayNameLength {
+				return fmt.Errorf("%w: display_name is too long", ErrInvalidProfile)
+			}
+			user.DisplayName = update.DisplayName
+		case ProfileFieldLocale:
+			if update.Locale != "" {
+				if _, err := language.Parse(update.Locale); err != nil {
+					return fmt.Errorf("%w: locale", ErrInvalidProfile)
+				}
+			}
+			user.Locale = update.Locale
+		case ProfileFieldTimezone:
+			if update.Timezone != "" {
+				if _, err := time.LoadLocation(update.Timezone); err != nil {
+					return fmt.Errorf("%w: timezone", ErrInvalidProfile)
+				}
+			}
+			user.Timezone = update.Timezone
+		case ProfileFieldAttributes:
+			user.Attributes = update.Attributes
+			if user.Attributes == nil {
+				user.Attributes = map[string]interface{}{}
+			}
+		default:
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidProfile, field)
+		}
+	}
+
+	return nil
+}
+
+func (s *userService) addProfileClaims(ctx context.Context, username string, claims jwt.MapClaims) error {
+	profile, err := s.GetProfile(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	for claim, field := range s.profileClaims {
+		if value, ok := profileField(profile, field); ok {
+			claims[claim] = value
+		}
+	}
+
+	return nil
+}
+
+// profileField возвращает значение поля профиля; пустые значения не попадают в токен
+func profileField(profile *User, field string) (interface{}, bool) {
+	var value string
+	switch field {
+	case ProfileFieldEmail:
+		value = profile.Email
+	case ProfileFieldDisplayName:
+		value = profile.DisplayName
+	case ProfileFieldLocale:
+		value = profile.Locale
+	case ProfileFieldTimezone:
+		value = profile.Timezone
+	default:
+		key := strings.TrimPrefix(field, ProfileFieldAttributes+".")
+		attr, ok := profile.Attributes[key]
+		return attr, ok
+	}
+	return value, value != ""
+}
+
+func profileFromRow(row store.UpdateProfileRow) (*User, error) {
+	attributes := map[string]interface{}{}
+	if len(row.Attributes) > 0 {
+		if err := json.Unmarshal(row.Attributes, &attributes); err != nil {
+			return nil, fmt.Errorf("decode profile attributes: %w", err)
+		}
+	}
+
+	return &User{
+		ID:            row.ID,
+		Username:      row.Username,
+		Email:         row.Email.String,
+		EmailVerified: row.EmailVerifiedAt.Valid,
+		DisplayName:   row.DisplayName,
+		Locale:        row.Locale,
+		Timezone:      row.Timezone,
+		Attributes:    attributes,
+		Version:       row.Version,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClaimMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:    "valid mapping",
+			entries: []string{"name=display_name", " zoneinfo = timezone ", "dept=attributes.department", ""},
+			expected: map[string]string{
+				"name":     "display_name",
+				"zoneinfo": "timezone",
+				"dept":     "attributes.department",
+			},
+		},
+		{
+			name:    "reserved claim",
+			entries: []string{"role=display_name"},
+			wantErr: true,
+		},
+		{
+			name:    "session version claim",
+			entries: []string{"sv=attributes.sv"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			entries: []string{"pwd=password_hash"},
+			wantErr: true,
+		},
+		{
+			name:    "missing separator",
+			entries: []string{"display_name"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := ParseClaimMapping(tt.entries)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mapping)
+		})
+	}
+}
+
+func TestApplyProfileUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   ProfileUpdate
+		expected User
+		wantErr  bool
+	}{
+		{
+			name: "only masked fields change",
+			update: ProfileUpdate{
+				Mask:        []string{ProfileFieldDisplayName, ProfileFieldLocale},
+				DisplayName: "New Name",
+				Locale:      "en-US",
+				Timezone:    "Europe/Berlin",
+			},
+			expected: User{DisplayName: "New Name", Locale: "en-US", Timezone: "Europe/Moscow"},
+		},
+		{
+			name:     "clear attributes",
+			update:   ProfileUpdate{Mask: []string{ProfileFieldAttributes}},
+			expected: User{DisplayName: "Old", Locale: "ru-RU", Timezone: "Europe/Moscow", Attributes: map[string]interface{}{}},
+		},
+		{
+			name:    "invalid timezone",
+			update:  ProfileUpdate{Mask: []string{ProfileFieldTimezone}, Timezone: "Mars/Olympus"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid email",
+			update:  ProfileUpdate{Mask: []string{ProfileFieldEmail}, Email: "not-an-email"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			update:  ProfileUpdate{Mask: []string{"password"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty mask",
+			update:  ProfileUpdate{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{DisplayName: "Old", Locale: "ru-RU", Timezone: "Europe/Moscow"}
+
+			err := applyProfileUpdate(&user, tt.update)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidProfile))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, user)
+		})
+	}
+}
+
+func TestProfileField(t *testing.T) {
+	profile := &User{
+		Email:      "user@example.com",
+		Attributes: map[string]interface{}{"department": "ops"},
+	}
+
+	value, ok := profileField(profile, ProfileFieldEmail)
+	assert.True(t, ok)
+	assert.Equal(t, "user@example.com", value)
+
+	_, ok = profileField(profile, ProfileFieldDisplayName)
+	assert.False(t, ok, "empty fields are not projected")
+
+	value, ok = profileField(profile, "attributes.department")
+	assert.True(t, ok)
+	assert.Equal(t, "ops", value)
+
+	_, ok = profileField(profile, "attributes.missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"auth_test/internal/logging"
+	"context"
+	"time"
+)
+
+// RunUserRetention периодически окончательно удаляет пользователей, помеченных удалёнными
+// дольше retention назад. Блокируется до отмены ctx
+func RunUserRetention(ctx context.Context, adminService AdminService, retention, interval time.Duration) {
+	ctx = WithActor(ctx, ActorRetention)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeDeletedUsers(ctx, adminService, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeDeletedUsers(ctx context.Context, adminService AdminService, retention time.Duration) {
+	purged, err := adminService.PurgeDeletedUsers(ctx, time.Now().Add(-retention))
+	if err != nil {
+		logging.FromContext(ctx).Error("User retention job failed", logging.Err(err))
+		return
+	}
+
+	if purged > 0 {
+		logging.FromContext(ctx).Info("User retention job purged deleted users", "purged", purged)
+	}
+}
+
+// RunRegistrationRetention периодически удаляет регистрации, не подтверждённые за ttl, освобождая
+// имя и адрес. Блокируется до отмены ctx
+func RunRegistrationRetention(ctx context.Context, adminService AdminService, ttl, interval time.Duration) {
+	ctx = WithActor(ctx, ActorRetention)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeUnverifiedUsers(ctx, adminService, ttl)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeUnverifiedUsers(ctx context.Context, adminService AdminService, ttl time.Duration) {
+	purged, err := adminService.PurgeUnverifiedUsers(ctx, time.Now().Add(-ttl))
+	if err != nil {
+		logging.FromContext(ctx).Error("Registration retention job failed", logging.Err(err))
+		return
+	}
+
+	if purged > 0 {
+		logging.FromContext(ctx).Info("Registration retention job purged unverified users", "purged", purged)
+	}
+}
+
+// RunAuditRetention периодически удаляет события аудита старше retention. Блокируется до отмены ctx
+func RunAuditRetention(ctx context.Context, adminService AdminService, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeAuditEvents(ctx, adminService, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeAuditEvents(ctx context.Context, adminService AdminService, retention time.Duration) {
+	purged, err := adminService.PurgeAuditEvents(ctx, time.Now().Add(-retention))
+	if err != nil {
+		logging.FromContext(ctx).Error("Audit retention job failed", logging.Err(err))
+		return
+	}
+
+	if purged > 0 {
+		logging.FromContext(ctx).Info("Audit retention job purged old events", "purged", purged)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRunUserRetention(t *testing.T) {
+	tests := []struct {
+		name    string
+		purged  int64
+		mockErr error
+	}{
+		{
+			name:   "purges expired users",
+			purged: 3,
+		},
+		{
+			name:    "store failure does not stop the job",
+			mockErr: errors.New("database unavailable"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			retention := 24 * time.Hour
+			ctx, cancel := context.WithCancel(context.Background())
+
+			mockAdmin := NewMockAdminService(ctrl)
+			mockAdmin.EXPECT().PurgeDeletedUsers(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, deletedBefore time.Time) (int64, error) {
+					assert.WithinDuration(t, time.Now().Add(-retention), deletedBefore, time.Minute)
+					cancel()
+					return tt.purged, tt.mockErr
+				})
+
+			done := make(chan struct{})
+			go func() {
+				RunUserRetention(ctx, mockAdmin, retention, time.Hour)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("retention job did not stop after context cancel")
+			}
+		})
+	}
+}
+
+func TestRunRegistrationRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ttl := time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockAdmin := NewMockAdminService(ctrl)
+	mockAdmin.EXPECT().PurgeUnverifiedUsers(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, registeredBefore time.Time) (int64, error) {
+			assert.WithinDuration(t, time.Now().Add(-ttl), registeredBefore, time.Minute)
+			assert.Equal(t, ActorRetention, ActorFromContext(ctx))
+			cancel()
+			return 2, nil
+		})
+
+	done := make(chan struct{})
+	go func() {
+		RunRegistrationRetention(ctx, mockAdmin, ttl, time.Hour)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retention job did not stop after context cancel")
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"auth_test/internal/passhash"
+	"auth_test/internal/tracing"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// endSpan завершает спан операции сервиса. Описание ошибки проходит через тот же фильтр,
+// что и журнал аудита: текст внутренних ошибок не уходит в систему трассировки
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(attribute.String("auth.outcome", OutcomeFailure))
+		span.SetStatus(codes.Error, auditReason(err))
+		// Неверный пароль и подобные отказы - ожидаемый результат, а не сбой
+		if auditReason(err) == "internal error" || errors.Is(err, context.DeadlineExceeded) {
+			span.RecordError(err)
+		}
+	} else {
+		span.SetAttributes(attribute.String("auth.outcome", OutcomeSuccess))
+	}
+	span.End()
+}
+
+// hashPassword считает bcrypt хеш в отдельном спане: это самая долгая часть регистрации
+// и смены пароля
+func hashPassword(ctx context.Context, password string) ([]byte, error) {
+	_, span := tracing.Tracer().Start(ctx, "bcrypt.GenerateFromPassword",
+		trace.WithAttributes(attribute.Int("bcrypt.cost", bcrypt.DefaultCost)))
+	defer span.End()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return hashed, err
+}
+
+// verifyPassword сравнивает пароль с хешем в отдельном спане с алгоритмом хеша
+func verifyPassword(ctx context.Context, encoded, password string) (bool, error) {
+	_, span := tracing.Tracer().Start(ctx, "passhash.Verify")
+	defer span.End()
+
+	if algorithm, err := passhash.Identify(encoded); err == nil {
+		span.SetAttributes(attribute.String("password.algorithm", string(algorithm)))
+	}
+
+	ok, err := passhash.Verify(encoded, password)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return ok, err
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"auth_test/internal/store"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestUserService_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := context.Background()
+	svc := NewUserService(store.NewInMemoryStore(), testJWTSecret)
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret123", ""))
+
+	_, err := svc.ValidateCredentials(ctx, "alice", "wrong-password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.GenerateToken(ctx, "alice", TokenTypeAccess)
+	require.NoError(t, err)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	require.Contains(t, spans, "bcrypt.GenerateFromPassword")
+	require.Contains(t, spans, "passhash.Verify")
+	require.Contains(t, spans, "UserService.ValidateCredentials")
+	require.Contains(t, spans, "UserService.GenerateToken")
+
+	login := spans["UserService.ValidateCredentials"]
+	assert.Equal(t, login.SpanContext().SpanID(), spans["passhash.Verify"].Parent().SpanID(),
+		"password verification is a child of the login span")
+	assert.Equal(t, codes.Error, login.Status().Code)
+	assert.Equal(t, ErrInvalidCredentials.Error(), login.Status().Description)
+	assert.Empty(t, login.Events(), "expected failures are not recorded as exceptions")
+
+	assert.Equal(t, codes.Unset, spans["UserService.GenerateToken"].Status().Code)
+}
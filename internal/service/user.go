@@ -2,15 +2,22 @@
 package service
 
 import (
+	"auth_test/internal/logging"
+	"auth_test/internal/passhash"
 	"auth_test/internal/store"
+	"auth_test/internal/tracing"
 	"auth_test/pkg/metrics"
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"net/mail"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -18,6 +25,19 @@ const (
 	TokenTypeRefresh = "refresh"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Статусы жизненного цикла пользователя
+const (
+	StatusActive              = "active"
+	StatusDisabled            = "disabled"
+	StatusPendingVerification = "pending_verification"
+	StatusDeleted             = "deleted"
+)
+
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
@@ -25,66 +45,188 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidTypeToken   = errors.New("invalid token type")
 	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrUserDisabled       = errors.New("user disabled")
+	ErrUserNotVerified    = errors.New("user pending verification")
+	ErrEmailAlreadyInUse  = errors.New("email already in use")
+	// ErrPasswordChangeRequired - пароль верный, но перед входом его нужно сменить
+	ErrPasswordChangeRequired = errors.New("password change required")
+	ErrWeakPassword           = errors.New("password does not meet requirements")
+	// ErrUnavailable - хранилище не ответило; запрос можно повторить позже
+	ErrUnavailable = errors.New("service unavailable")
 )
 
+const MinPasswordLength = 8
+
 type UserService interface {
 	ValidateCredentials(ctx context.Context, username, password string) (bool, error)
 	GenerateToken(ctx context.Context, username string, TokenType string) (string, error)
 	RefreshToken(ctx context.Context, token string) (string, error)
-	CreateUser(ctx context.Context, username, password string) error
+	// CreateUser создаёт пользователя. Если включено подтверждение почты и email задан,
+	// пользователь создаётся в статусе pending_verification и получает письмо со ссылкой
+	CreateUser(ctx context.Context, username, password, email string) error
+	// Register - самостоятельная регистрация. Доступна, только если включено подтверждение почты:
+	// пользователь создаётся в статусе pending_verification и входит после подтверждения адреса.
+	// Для занятого адреса возвращает nil, чтобы ответ не раскрывал зарегистрированные адреса
+	Register(ctx context.Context, username, password, email string) error
+	ParseAccessToken(ctx context.Context, token string) (*TokenClaims, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	GetProfile(ctx context.Context, username string) (*User, error)
+	UpdateProfile(ctx context.Context, username string, update ProfileUpdate) (*User, error)
+	// ChangePassword меняет пароль по текущему паролю и снимает требование смены пароля
+	ChangePassword(ctx context.Context, username, currentPassword, newPassword string) error
 }
 
-type User struct {
-	ID       int
+// TokenClaims - проверенные данные access токена
+type TokenClaims struct {
 	Username string
-	Password string
+	Role     string
 }
 
-type UserStore interface {
-	Get(ctx context.Context, username string) (*User, error)
-	Store(ctx context.Context, user User) error
+// User - профиль пользователя
+type User struct {
+	ID            int32
+	Username      string
+	Email         string
+	EmailVerified bool
+	DisplayName   string
+	Locale        string
+	Timezone      string
+	Attributes    map[string]interface{}
+	Version       int32
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type userService struct {
-	store     *store.PostgresStore
+	store     store.Store
 	jwtSecret string
+
+	// nil, если подтверждение почты выключено
+	verification *emailVerification
+	// claim access токена -> поле профиля
+	profileClaims map[string]string
+
+	audit   *auditLog
+	metrics metrics.Metrics
 }
 
-func NewUserService(store *store.PostgresStore, jwtSecret string) UserService {
-	return &userService{
+type UserServiceOption func(*userService)
+
+// WithMetrics задаёт, куда сервис пишет метрики; по умолчанию они не записываются
+func WithMetrics(m metrics.Metrics) UserServiceOption {
+	return func(s *userService) {
+		s.metrics = m
+	}
+}
+
+// WithAuditTenant задаёт цепочку аудита, в которую пишет сервис
+func WithAuditTenant(tenant string) UserServiceOption {
+	return func(s *userService) {
+		s.audit.tenant = tenant
+	}
+}
+
+func NewUserService(store store.Store, jwtSecret string, opts ...UserServiceOption) UserService {
+	s := &userService{
 		store:     store,
 		jwtSecret: jwtSecret,
+		audit:     newAuditLog(store),
+		metrics:   metrics.Noop{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *userService) ValidateCredentials(ctx context.Context, username, password string) (valid bool, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.ValidateCredentials")
+	defer func() { endSpan(span, err) }()
+
+	valid, err = s.validateCredentials(ctx, username, password)
+
+	entry := auditEntry{EventType: AuditUserLogin, Subject: username, Err: err}
+	if err == nil {
+		entry.Actor = "user:" + username
 	}
+	s.audit.record(ctx, entry)
+
+	return valid, err
 }
 
-func (s *userService) ValidateCredentials(ctx context.Context, username, password string) (bool, error) {
+func (s *userService) validateCredentials(ctx context.Context, username, password string) (valid bool, err error) {
 	start := time.Now()
 	defer func() {
-		metrics.LoginDuration.Observe(time.Since(start).Seconds())
+		result := metrics.ResultSuccess
+		if err != nil || !valid {
+			result = metrics.ResultFailure
+		}
+		s.metrics.LoginAttempt(ctx, result, time.Since(start))
 	}()
 
 	if err := ctx.Err(); err != nil {
-		metrics.LoginAttempts.WithLabelValues("failure").Inc()
 		return false, err
 	}
 
 	user, err := s.store.GetUser(ctx, username)
 	if err != nil {
-		metrics.LoginAttempts.WithLabelValues("failure").Inc()
+		return false, lookupError(err, ErrInvalidCredentials)
+	}
+
+	// Удалённый пользователь неотличим от несуществующего
+	if user.Status == StatusDeleted {
 		return false, ErrInvalidCredentials
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
-	if err != nil {
-		metrics.LoginAttempts.WithLabelValues("failure").Inc()
+	if ok, err := verifyPassword(ctx, user.PasswordHash, password); !ok {
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to verify password hash", "username", username, logging.Err(err))
+		}
 		return false, ErrInvalidCredentials
 	}
 
-	metrics.LoginAttempts.WithLabelValues("success").Inc()
+	if err := checkUserStatus(user.Status); err != nil {
+		return false, err
+	}
+
+	if user.PasswordChangeRequired {
+		return false, ErrPasswordChangeRequired
+	}
+
+	// Хеш заменяется только при успешном входе: у заблокированного пользователя запись не меняется
+	if passhash.NeedsUpgrade(user.PasswordHash) {
+		s.upgradePasswordHash(ctx, username, user.PasswordHash, password)
+	}
+
 	return true, nil
 }
 
-func (s *userService) GenerateToken(ctx context.Context, username string, tokenType string) (string, error) {
+// upgradePasswordHash заменяет импортированный хеш устаревшего формата на bcrypt.
+// Ошибка не мешает входу: замена повторится при следующем входе
+func (s *userService) upgradePasswordHash(ctx context.Context, username, oldHash, password string) {
+	newHash, err := passhash.Hash(password)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to upgrade password hash", "username", username, logging.Err(err))
+		return
+	}
+
+	if _, err := s.store.UpgradePasswordHash(ctx, store.UpgradePasswordHashParams{
+		Username: username,
+		OldHash:  oldHash,
+		NewHash:  newHash,
+	}); err != nil {
+		logging.FromContext(ctx).Error("Failed to upgrade password hash", "username", username, logging.Err(err))
+		return
+	}
+	logging.FromContext(ctx).Info("Password hash upgraded to bcrypt", "username", username)
+}
+
+func (s *userService) GenerateToken(ctx context.Context, username string, tokenType string) (_ string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "UserService.GenerateToken",
+		trace.WithAttributes(attribute.String("token.type", tokenType)))
+	defer func() { endSpan(span, err) }()
+
 	if err := ctx.Err(); err != nil {
 		return "", err
 	}
@@ -99,20 +241,54 @@ func (s *userService) GenerateToken(ctx context.Context, username string, tokenT
 		return "", ErrInvalidTypeToken
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return "", lookupError(err, ErrUserNotFound)
+	}
+
+	// Токены не выдаются неактивным пользователям, в том числе при обновлении по refresh токену
+	if err := checkUserStatus(user.Status); err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
 		"sub":  username,
 		"exp":  time.Now().Add(ttl).Unix(),
 		"type": tokenType,
-	})
+		"role": user.Role,
+		"sv":   user.SessionVersion,
+	}
+
+	if tokenType == TokenTypeAccess && len(s.profileClaims) > 0 {
+		if err := s.addProfileClaims(ctx, username, claims); err != nil {
+			return "", err
+		}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	metrics.TokenGenerated.WithLabelValues(tokenType).Inc()
+	s.metrics.TokenGenerated(tokenType)
 
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
 func (s *userService) RefreshToken(ctx context.Context, tokenString string) (string, error) {
+	username, token, err := s.refreshToken(ctx, tokenString)
+
+	// Владелец refresh токена известен, только если подпись токена проверена
+	entry := auditEntry{EventType: AuditTokenRefresh, Subject: username, Err: err}
+	if username != "" {
+		entry.Actor = "user:" + username
+	}
+	s.audit.record(ctx, entry)
+
+	return token, err
+}
+
+// refreshToken возвращает владельца токена (если подпись верна) и новый access токен
+func (s *userService) refreshToken(ctx context.Context, tokenString string) (string, string, error) {
 	if err := ctx.Err(); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -120,69 +296,313 @@ func (s *userService) RefreshToken(ctx context.Context, tokenString string) (str
 	})
 
 	if err != nil || !token.Valid {
-		metrics.TokensValidated.WithLabelValues("invalid").Inc()
-		return "", ErrInvalidToken
+		s.metrics.TokenValidated("invalid")
+		return "", "", ErrInvalidToken
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
 		// Проверка на refresh токен
 		if tokenType, ok := claims["type"].(string); !ok || tokenType != TokenTypeRefresh {
-			metrics.TokensValidated.WithLabelValues("invalid_type").Inc()
-			return "", ErrInvalidTypeToken
+			s.metrics.TokenValidated("invalid_type")
+			return "", "", ErrInvalidTypeToken
 		}
 
 		if username, ok := claims["sub"].(string); ok {
-			metrics.TokensValidated.WithLabelValues("valid").Inc()
-			return s.GenerateToken(ctx, username, TokenTypeAccess)
+			// Refresh токены, выданные до отзыва сессий, больше не обмениваются
+			user, err := s.store.GetUser(ctx, username)
+			if err != nil {
+				return username, "", lookupError(err, ErrUserNotFound)
+			}
+			if !sessionVersionMatches(claims, user.SessionVersion) {
+				s.metrics.TokenValidated("revoked")
+				return username, "", ErrInvalidToken
+			}
+
+			s.metrics.TokenValidated("valid")
+			accessToken, err := s.GenerateToken(ctx, username, TokenTypeAccess)
+			return username, accessToken, err
 		}
 	}
 
-	return "", ErrInvalidToken
+	return "", "", ErrInvalidToken
 }
 
-func (s *userService) CreateUser(ctx context.Context, username, password string) error {
+func (s *userService) CreateUser(ctx context.Context, username, password, email string) (err error) {
+	defer func() {
+		s.audit.record(ctx, auditEntry{EventType: AuditUserRegister, Subject: username, Err: err})
+	}()
+
 	start := time.Now()
 
 	if err := ctx.Err(); err != nil {
-		metrics.UserCreated.WithLabelValues("failure").Inc()
+		s.metrics.UserCreated(ctx, metrics.ResultFailure, time.Since(start))
 		return err
 	}
 
-	log.Printf("Creating user: %s", username)
+	logging.FromContext(ctx).Debug("Creating user", "username", username)
 
 	exists, err := s.store.UserExists(ctx, username)
 	if err != nil {
+		s.metrics.UserCreated(ctx, metrics.ResultFailure, time.Since(start))
 		return err
 	}
 	if exists {
+		s.metrics.UserCreated(ctx, metrics.ResultConflict, time.Since(start))
 		return ErrUserAlreadyExists
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(ctx, password)
 	if err != nil {
-		log.Printf("Failed to create user %s: %v", username, err)
-		metrics.UserCreated.WithLabelValues("failure").Inc()
+		logging.FromContext(ctx).Error("Failed to create user", "username", username, logging.Err(err))
+		s.metrics.UserCreated(ctx, metrics.ResultFailure, time.Since(start))
 		return err
 	}
 
 	user := store.CreateUserParams{
 		Username:     username,
 		PasswordHash: string(hashedPassword),
+		Role:         RoleUser,
+		Status:       StatusActive,
+	}
+	if email != "" {
+		user.Email = pgtype.Text{String: email, Valid: true}
+	}
+
+	requireVerification := s.verification != nil && email != ""
+	if requireVerification {
+		user.Status = StatusPendingVerification
 	}
 
 	_, err = s.store.CreateUser(ctx, user)
 	if err != nil {
-		if errors.Is(err, ErrUserAlreadyExists) {
-			metrics.UserCreated.WithLabelValues("conflict").Inc()
+		err = mapUniqueViolation(err)
+		if errors.Is(err, ErrUserAlreadyExists) || errors.Is(err, ErrEmailAlreadyInUse) {
+			s.metrics.UserCreated(ctx, metrics.ResultConflict, time.Since(start))
 		} else {
-			metrics.UserCreated.WithLabelValues("failure").Inc()
+			s.metrics.UserCreated(ctx, metrics.ResultFailure, time.Since(start))
+		}
+		logging.FromContext(ctx).Error("Failed to create user", "username", username, logging.Err(err))
+		return err
+	}
+
+	s.metrics.UserCreated(ctx, metrics.ResultSuccess, time.Since(start))
+	logging.FromContext(ctx).Info("User created", "username", username)
+
+	if requireVerification {
+		// Пользователь уже создан: при сбое отправки письмо можно запросить повторно
+		if err := s.sendVerification(ctx, username, email); err != nil {
+			logging.FromContext(ctx).Error("Failed to send verification email", "username", username, logging.Err(err))
+		}
+	}
+
+	return nil
+}
+
+func (s *userService) Register(ctx context.Context, username, password, email string) error {
+	if s.verification == nil {
+		return ErrVerificationDisabled
+	}
+	if username == "" || email == "" {
+		return fmt.Errorf("%w: username and email are required", ErrInvalidArgument)
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return fmt.Errorf("%w: invalid email", ErrInvalidArgument)
+	}
+	if err := checkPasswordStrength(password); err != nil {
+		return err
+	}
+
+	err := s.CreateUser(ctx, username, password, email)
+	if errors.Is(err, ErrEmailAlreadyInUse) {
+		// Занятый адрес неотличим от успешной регистрации, как и в ResendVerification
+		logging.FromContext(ctx).Info("Registration with an email already in use", "username", username)
+		return nil
+	}
+	return err
+}
+
+// checkPasswordStrength - требования к паролю, который задаёт пользователь или администратор
+func checkPasswordStrength(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("%w: at least %d characters required", ErrWeakPassword, MinPasswordLength)
+	}
+	return nil
+}
+
+func (s *userService) ChangePassword(ctx context.Context, username, currentPassword, newPassword string) (err error) {
+	defer func() {
+		// Смена по текущему паролю: при успехе действие выполнил сам пользователь
+		entry := auditEntry{EventType: AuditPasswordChange, Subject: username, Err: err}
+		if err == nil {
+			entry.Actor = "user:" + username
 		}
-		log.Printf("Failed to create user %s: %v", username, err)
+		s.audit.record(ctx, entry)
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return lookupError(err, ErrInvalidCredentials)
+	}
+	if user.Status == StatusDeleted {
+		return ErrInvalidCredentials
+	}
+
+	if ok, _ := verifyPassword(ctx, user.PasswordHash, currentPassword); !ok {
+		return ErrInvalidCredentials
+	}
+
+	if err := checkUserStatus(user.Status); err != nil {
+		return err
+	}
+
+	if err := checkPasswordStrength(newPassword); err != nil {
+		return err
+	}
+	if newPassword == currentPassword {
+		return fmt.Errorf("%w: new password must differ from the current one", ErrWeakPassword)
+	}
+
+	hashedPassword, err := hashPassword(ctx, newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Смена пароля также отзывает все выданные ранее токены
+	affected, err := s.store.SetUserPassword(ctx, store.SetUserPasswordParams{
+		Username:     username,
+		PasswordHash: string(hashedPassword),
+	})
+	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+
+	logging.FromContext(ctx).Info("Password changed", "username", username)
+	return nil
+}
 
-	metrics.UserCreated.WithLabelValues("success").Inc()
-	metrics.UserCreationDuration.Observe(time.Since(start).Seconds())
-	log.Printf("User created successfully: %s", username)
+func (s *userService) ParseAccessToken(ctx context.Context, tokenString string) (*TokenClaims, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	if tokenType, ok := claims["type"].(string); !ok || tokenType != TokenTypeAccess {
+		return nil, ErrInvalidTypeToken
+	}
+
+	username, ok := claims["sub"].(string)
+	if !ok || username == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// Выданные ранее токены перестают работать, как только пользователь деактивирован
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return nil, lookupError(err, ErrInvalidToken)
+	}
+	if err := checkUserStatus(user.Status); err != nil {
+		return nil, err
+	}
+	if !sessionVersionMatches(claims, user.SessionVersion) {
+		return nil, ErrInvalidToken
+	}
+
+	return &TokenClaims{
+		Username: username,
+		Role:     user.Role,
+	}, nil
+}
+
+// lookupError отличает отсутствие пользователя от сбоя хранилища. Сбой оборачивается
+// в ErrUnavailable, чтобы клиент получил "повторите позже", а не ошибку входа
+func lookupError(err, notFound error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return notFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+}
+
+// mapUniqueViolation превращает нарушение уникальности в доменную ошибку
+func mapUniqueViolation(err error) error {
+	err = store.MapUniqueViolation(err)
+	switch {
+	case errors.Is(err, store.ErrDuplicateEmail):
+		return ErrEmailAlreadyInUse
+	case errors.Is(err, store.ErrDuplicateUsername):
+		return ErrUserAlreadyExists
+	default:
+		return err
+	}
+}
+
+// sessionVersionMatches проверяет, что токен выдан после последнего отзыва сессий.
+// Токены без claim sv выданы до появления отзыва и соответствуют начальной версии
+func sessionVersionMatches(claims jwt.MapClaims, current int32) bool {
+	version := int32(1)
+	if raw, ok := claims["sv"]; ok {
+		sv, ok := raw.(float64)
+		if !ok {
+			return false
+		}
+		version = int32(sv)
+	}
+	return version == current
+}
+
+func checkUserStatus(status string) error {
+	switch status {
+	case StatusActive:
+		return nil
+	case StatusDisabled:
+		return ErrUserDisabled
+	case StatusPendingVerification:
+		return ErrUserNotVerified
+	default:
+		return ErrUserNotFound
+	}
+}
+
+// CheckSigningKey проверяет, что ключом можно подписать токен и проверить подпись.
+// Используется в проверке готовности сервиса
+func CheckSigningKey(secret string) error {
+	if secret == "" {
+		return errors.New("jwt signing key is not configured")
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "readiness"}).SignedString([]byte(secret))
+	if err != nil {
+		return fmt.Errorf("sign probe token: %w", err)
+	}
+	_, err = jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return fmt.Errorf("verify probe token: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"auth_test/internal/mail"
+	"auth_test/internal/store"
+	"auth_test/pkg/metrics"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "test-secret"
+
+func TestUserService_LoginFlow(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+	admin := NewAdminService(userStore)
+
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret", ""))
+	assert.ErrorIs(t, svc.CreateUser(ctx, "alice", "other", ""), ErrUserAlreadyExists)
+
+	ok, err := svc.ValidateCredentials(ctx, "alice", "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = svc.ValidateCredentials(ctx, "alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	accessToken, err := svc.GenerateToken(ctx, "alice", TokenTypeAccess)
+	require.NoError(t, err)
+	refreshToken, err := svc.GenerateToken(ctx, "alice", TokenTypeRefresh)
+	require.NoError(t, err)
+
+	claims, err := svc.ParseAccessToken(ctx, accessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+	assert.Equal(t, RoleUser, claims.Role)
+
+	_, err = svc.ParseAccessToken(ctx, refreshToken)
+	assert.ErrorIs(t, err, ErrInvalidTypeToken)
+
+	refreshed, err := svc.RefreshToken(ctx, refreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, refreshed)
+
+	// После отключения уже выданные токены перестают приниматься
+	require.NoError(t, admin.DisableUser(ctx, "alice", "test"))
+
+	_, err = svc.ValidateCredentials(ctx, "alice", "secret")
+	assert.ErrorIs(t, err, ErrUserDisabled)
+	_, err = svc.ParseAccessToken(ctx, accessToken)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+	_, err = svc.RefreshToken(ctx, refreshToken)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+
+	// Удалённый пользователь неотличим от несуществующего
+	require.NoError(t, admin.DeleteUser(ctx, "alice", "test"))
+	_, err = svc.ValidateCredentials(ctx, "alice", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestUserService_Metrics(t *testing.T) {
+	ctx := context.Background()
+	recorder := metrics.NewRecorder()
+	svc := NewUserService(store.NewInMemoryStore(), testJWTSecret, WithMetrics(recorder))
+
+	require.NoError(t, svc.CreateUser(ctx, "alice", "secret", ""))
+	assert.ErrorIs(t, svc.CreateUser(ctx, "alice", "other", ""), ErrUserAlreadyExists)
+
+	_, err := svc.ValidateCredentials(ctx, "alice", "secret")
+	require.NoError(t, err)
+	_, err = svc.ValidateCredentials(ctx, "alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.ValidateCredentials(ctx, "bob", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	refreshToken, err := svc.GenerateToken(ctx, "alice", TokenTypeRefresh)
+	require.NoError(t, err)
+	_, err = svc.RefreshToken(ctx, refreshToken)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, recorder.Count("UserCreated", metrics.ResultSuccess))
+	assert.Equal(t, 1, recorder.Count("UserCreated", metrics.ResultConflict))
+	// Каждая проверка пароля записывается ровно один раз
+	assert.Equal(t, 1, recorder.Count("LoginAttempt", metrics.ResultSuccess))
+	assert.Equal(t, 2, recorder.Count("LoginAttempt", metrics.ResultFailure))
+	assert.Equal(t, 1, recorder.Count("TokenGenerated", TokenTypeRefresh))
+	assert.Equal(t, 1, recorder.Count("TokenGenerated", TokenTypeAccess))
+	assert.Equal(t, 1, recorder.Count("TokenValidated", "valid"))
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+	sender := mail.NewMemorySender()
+	svc := NewUserService(store.NewInMemoryStore(), testJWTSecret, WithEmailVerification(sender, VerificationConfig{
+		LinkBaseURL:    "https://app.example.com/verify",
+		TokenTTL:       time.Hour,
+		ResendInterval: time.Minute,
+	}))
+
+	require.NoError(t, svc.CreateUser(ctx, "bob", "secret", "bob@example.com"))
+	assert.ErrorIs(t, svc.CreateUser(ctx, "bobby", "secret", "BOB@example.com"), ErrEmailAlreadyInUse)
+
+	_, err := svc.ValidateCredentials(ctx, "bob", "secret")
+	assert.ErrorIs(t, err, ErrUserNotVerified)
+
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "bob@example.com", messages[0].To)
+
+	link := regexp.MustCompile(`https://\S+`).FindString(messages[0].Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.VerifyEmail(ctx, u.Query().Get("token")))
+
+	ok, err := svc.ValidateCredentials(ctx, "bob", "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	profile, err := svc.GetProfile(ctx, "bob")
+	require.NoError(t, err)
+	assert.True(t, profile.EmailVerified)
+}
+
+func TestUserService_Register(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	sender := mail.NewMemorySender()
+	svc := NewUserService(userStore, testJWTSecret, WithEmailVerification(sender, VerificationConfig{
+		LinkBaseURL:    "https://app.example.com/verify",
+		TokenTTL:       time.Hour,
+		ResendInterval: time.Minute,
+	}))
+	admin := NewAdminService(userStore)
+
+	require.NoError(t, svc.Register(ctx, "bob", "secret123", "bob@example.com"))
+	// Ответ для занятого адреса не отличается от успешной регистрации
+	require.NoError(t, svc.Register(ctx, "bobby", "secret123", "BOB@example.com"))
+	assert.ErrorIs(t, svc.Register(ctx, "bob", "secret123", "other@example.com"), ErrUserAlreadyExists)
+	assert.Len(t, sender.Messages(), 1)
+
+	_, err := svc.ValidateCredentials(ctx, "bobby", "secret123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// Неподтверждённая регистрация освобождает имя и адрес после истечения ссылки
+	purged, err := admin.PurgeUnverifiedUsers(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	require.NoError(t, svc.Register(ctx, "bobby", "secret123", "bob@example.com"))
+	assert.Len(t, sender.Messages(), 2)
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	svc := NewUserService(store.NewInMemoryStore(), testJWTSecret, WithProfileClaims(map[string]string{
+		"name": ProfileFieldDisplayName,
+	}))
+
+	require.NoError(t, svc.CreateUser(ctx, "carol", "secret", ""))
+
+	profile, err := svc.GetProfile(ctx, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), profile.Version)
+
+	updated, err := svc.UpdateProfile(ctx, "carol", ProfileUpdate{
+		Mask:            []string{ProfileFieldDisplayName, ProfileFieldAttributes},
+		DisplayName:     "Carol",
+		Attributes:      map[string]interface{}{"team": "platform"},
+		ExpectedVersion: profile.Version,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Carol", updated.DisplayName)
+	assert.Equal(t, "platform", updated.Attributes["team"])
+	assert.Equal(t, int32(2), updated.Version)
+
+	// Повторное обновление со старой версией отклоняется
+	_, err = svc.UpdateProfile(ctx, "carol", ProfileUpdate{
+		Mask:            []string{ProfileFieldDisplayName},
+		DisplayName:     "Stale",
+		ExpectedVersion: profile.Version,
+	})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	_, err = svc.GetProfile(ctx, "nobody")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAdminService_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	admin := NewAdminService(store.NewInMemoryStore())
+
+	for _, username := range []string{"user1", "user2", "user3"} {
+		require.NoError(t, admin.CreateUser(ctx, username, "secret123", RoleUser))
+	}
+	require.NoError(t, admin.CreateUser(ctx, "root", "secret123", RoleAdmin))
+	require.NoError(t, admin.DeleteUser(ctx, "user2", "test"))
+
+	users, next, err := admin.ListUsers(ctx, UserFilter{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "user1", users[0].Username)
+	assert.Equal(t, "user3", users[1].Username)
+	require.NotEmpty(t, next)
+
+	users, next, err = admin.ListUsers(ctx, UserFilter{PageSize: 2, PageToken: next})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "root", users[0].Username)
+	assert.Empty(t, next)
+
+	users, _, err = admin.ListUsers(ctx, UserFilter{Status: StatusDeleted})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "user2", users[0].Username)
+	require.NotNil(t, users[0].DeletedAt)
+
+	purged, err := admin.PurgeDeletedUsers(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = admin.GetUser(ctx, "user2")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAdminService_RevokeSessions(t *testing.T) {
+	ctx := context.Background()
+	userStore := store.NewInMemoryStore()
+	svc := NewUserService(userStore, testJWTSecret)
+	admin := NewAdminService(userStore)
+
+	require.NoError(t, svc.CreateUser(ctx, "dave", "secret", ""))
+
+	accessToken, err := svc.GenerateToken(ctx, "dave", TokenTypeAccess)
+	require.NoError(t, err)
+	refreshToken, err := svc.GenerateToken(ctx, "dave", TokenTypeRefresh)
+	require.NoError(t, err)
+
+	require.NoError(t, admin.RevokeSessions(ctx, "dave"))
+
+	_, err = svc.ParseAccessToken(ctx, accessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = svc.RefreshToken(ctx, refreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Новые токены выдаются с актуальной версией сессий
+	accessToken, err = svc.GenerateToken(ctx, "dave", TokenTypeAccess)
+	require.NoError(t, err)
+	_, err = svc.ParseAccessToken(ctx, accessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, admin.ResetPassword(ctx, "dave", "new-secret"))
+
+	_, err = svc.ParseAccessToken(ctx, accessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "password reset revokes sessions")
+	_, err = svc.ValidateCredentials(ctx, "dave", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	ok, err := svc.ValidateCredentials(ctx, "dave", "new-secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, admin.SetRole(ctx, "dave", RoleAdmin))
+	claims, err := svc.ParseAccessToken(ctx, mustGenerateToken(t, svc, "dave"))
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, claims.Role)
+
+	assert.ErrorIs(t, admin.SetRole(ctx, "dave", "root"), ErrInvalidRole)
+	assert.ErrorIs(t, admin.RevokeSessions(ctx, "nobody"), ErrUserNotFound)
+	assert.ErrorIs(t, admin.ResetPassword(ctx, "nobody", "new-secret"), ErrUserNotFound)
+	assert.ErrorIs(t, admin.ResetPassword(ctx, "dave", "x"), ErrWeakPassword)
+	assert.ErrorIs(t, admin.CreateUser(ctx, "erin", "x", RoleUser), ErrWeakPassword)
+}
+
+func mustGenerateToken(t *testing.T, svc UserService, username string) string {
+	token, err := svc.GenerateToken(context.Background(), username, TokenTypeAccess)
+	require.NoError(t, err)
+	return token
+}
+
+// unavailableStore имитирует потерю соединения с базой при чтении пользователя
+type unavailableStore struct {
+	store.Store
+}
+
+func (unavailableStore) GetUser(context.Context, string) (store.GetUserRow, error) {
+	return store.GetUserRow{}, errors.New("dial tcp 127.0.0.1:5432: connect: connection refused")
+}
+
+func TestUserService_StoreUnavailable(t *testing.T) {
+	ctx := context.Background()
+	healthy := NewUserService(store.NewInMemoryStore(), testJWTSecret)
+	require.NoError(t, healthy.CreateUser(ctx, "alice", "secret", ""))
+	accessToken, err := healthy.GenerateToken(ctx, "alice", TokenTypeAccess)
+	require.NoError(t, err)
+	refreshToken, err := healthy.GenerateToken(ctx, "alice", TokenTypeRefresh)
+	require.NoError(t, err)
+
+	// Сбой базы не выдаётся за неверный пароль или недействительный токен
+	svc := NewUserService(unavailableStore{store.NewInMemoryStore()}, testJWTSecret)
+
+	_, err = svc.ValidateCredentials(ctx, "alice", "secret")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NotErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.ParseAccessToken(ctx, accessToken)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = svc.RefreshToken(ctx, refreshToken)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "alice", "secret", "new-secret"), ErrUnavailable)
+
+	// Отсутствие записи по-прежнему означает неверные учётные данные
+	_, err = healthy.ValidateCredentials(ctx, "bob", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestCheckSigningKey(t *testing.T) {
+	assert.NoError(t, CheckSigningKey(testJWTSecret))
+	assert.Error(t, CheckSigningKey(""))
+}
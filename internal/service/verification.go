@@ -0,0 +1,200 @@
+package service
+
+import (
+	"auth_test/internal/logging"
+	"auth_test/internal/mail"
+	"auth_test/internal/store"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const tokenTypeEmailVerification = "email_verification"
+
+var (
+	ErrVerificationDisabled = errors.New("email verification disabled")
+	ErrTooManyRequests      = errors.New("too many requests")
+)
+
+type VerificationConfig struct {
+	// Базовый URL ссылки из письма, токен добавляется параметром token
+	LinkBaseURL string
+	TokenTTL    time.Duration
+	// Минимальный интервал между повторными отправками на один адрес
+	ResendInterval time.Duration
+}
+
+type emailVerification struct {
+	sender  mail.Sender
+	cfg     VerificationConfig
+	limiter *resendLimiter
+}
+
+// WithEmailVerification включает подтверждение почты для пользователей, созданных с email
+func WithEmailVerification(sender mail.Sender, cfg VerificationConfig) UserServiceOption {
+	return func(s *userService) {
+		s.verification = &emailVerification{
+			sender:  sender,
+			cfg:     cfg,
+			limiter: newResendLimiter(cfg.ResendInterval),
+		}
+	}
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, tokenString string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.verification == nil {
+		return ErrVerificationDisabled
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return ErrExpiredToken
+		}
+		return ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	if tokenType, ok := claims["type"].(string); !ok || tokenType != tokenTypeEmailVerification {
+		return ErrInvalidTypeToken
+	}
+
+	username, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	if username == "" || email == "" {
+		return ErrInvalidToken
+	}
+
+	// Ссылка недействительна, если адрес пользователя с тех пор изменился
+	affected, err := s.store.MarkEmailVerified(ctx, store.MarkEmailVerifiedParams{Username: username, Email: email})
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInvalidToken
+	}
+
+	logging.FromContext(ctx).Info("Email verified", "username", username)
+	return nil
+}
+
+// ResendVerification повторно отправляет письмо. Чтобы не раскрывать наличие адреса,
+// для неизвестных и уже подтверждённых адресов ошибка не возвращается
+func (s *userService) ResendVerification(ctx context.Context, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.verification == nil {
+		return ErrVerificationDisabled
+	}
+
+	if !s.verification.limiter.allow(email) {
+		return ErrTooManyRequests
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		// Неизвестный адрес неотличим от успешной отправки
+		return lookupError(err, nil)
+	}
+
+	if user.Status != StatusPendingVerification || user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	return s.sendVerification(ctx, user.Username, user.Email.String)
+}
+
+func (s *userService) sendVerification(ctx context.Context, username, email string) error {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   username,
+		"email": email,
+		"exp":   time.Now().Add(s.verification.cfg.TokenTTL).Unix(),
+		"type":  tokenTypeEmailVerification,
+	})
+
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return fmt.Errorf("sign verification token: %w", err)
+	}
+
+	link, err := verificationLink(s.verification.cfg.LinkBaseURL, signed)
+	if err != nil {
+		return err
+	}
+
+	return s.verification.sender.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Подтверждение адреса электронной почты",
+		Body:    fmt.Sprintf("Для подтверждения адреса перейдите по ссылке:\n\n%s\n", link),
+	})
+}
+
+func verificationLink(baseURL, token string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("parse verification base url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// resendLimiter разрешает не более одной отправки на адрес за interval
+type resendLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+func newResendLimiter(interval time.Duration) *resendLimiter {
+	return &resendLimiter{
+		interval: interval,
+		now:      time.Now,
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+func (l *resendLimiter) allow(email string) bool {
+	key := strings.ToLower(strings.TrimSpace(email))
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.lastSent[key]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+
+	// Чистим устаревшие записи, чтобы карта не росла бесконечно
+	for k, t := range l.lastSent {
+		if now.Sub(t) >= l.interval {
+			delete(l.lastSent, k)
+		}
+	}
+
+	l.lastSent[key] = now
+	return true
+}
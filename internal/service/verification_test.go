@@ -0,0 +1,37 @@
+package service
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResendLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter := newResendLimiter(time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	assert.True(t, limiter.allow("user@example.com"))
+	assert.False(t, limiter.allow("user@example.com"))
+	assert.False(t, limiter.allow(" USER@example.com "), "addresses are compared case-insensitively")
+	assert.True(t, limiter.allow("other@example.com"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, limiter.allow("user@example.com"))
+}
+
+func TestVerificationLink(t *testing.T) {
+	link, err := verificationLink("https://app.example.com/verify?lang=ru", "a.b.c")
+	require.NoError(t, err)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "app.example.com", u.Host)
+	assert.Equal(t, "/verify", u.Path)
+	assert.Equal(t, "a.b.c", u.Query().Get("token"))
+	assert.Equal(t, "ru", u.Query().Get("lang"))
+}
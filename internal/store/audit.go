@@ -0,0 +1,109 @@
+package store
+
+import (
+	"auth_test/internal/auditchain"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AuditAppender добавляет событие в цепочку аудита тенанта. Блокировка головы цепочки, вычисление
+// хеша, вставка и сдвиг головы выполняются в одной транзакции, поэтому параллельные записи
+// (в том числе из разных экземпляров сервиса) не разветвляют цепочку
+type AuditAppender interface {
+	// Seq, PrevHash и Hash в arg заполняются хранилищем
+	AppendAuditEvent(ctx context.Context, arg InsertAuditEventParams) (AuditEvent, error)
+}
+
+func (s *PostgresStore) AppendAuditEvent(ctx context.Context, arg InsertAuditEventParams) (AuditEvent, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return AuditEvent{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.Queries.WithTx(tx)
+	head, err := q.LockAuditChainHead(ctx, arg.Tenant)
+	if err != nil {
+		return AuditEvent{}, fmt.Errorf("lock audit chain: %w", err)
+	}
+
+	if err := sealAuditEvent(&arg, auditchain.Head{Seq: head.Seq, Hash: head.Hash}); err != nil {
+		return AuditEvent{}, err
+	}
+
+	id, err := q.InsertAuditEvent(ctx, arg)
+	if err != nil {
+		return AuditEvent{}, err
+	}
+	if err := q.UpdateAuditChainHead(ctx, UpdateAuditChainHeadParams{
+		Tenant: arg.Tenant,
+		Seq:    arg.Seq,
+		Hash:   arg.Hash,
+	}); err != nil {
+		return AuditEvent{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return AuditEvent{}, err
+	}
+	return auditEventFromParams(id, arg), nil
+}
+
+// sealAuditEvent вычисляет seq и хеши события, добавляемого после head
+func sealAuditEvent(arg *InsertAuditEventParams, head auditchain.Head) error {
+	if len(arg.Metadata) == 0 {
+		arg.Metadata = []byte("{}")
+	}
+
+	record := AuditRecord(auditEventFromParams(0, *arg))
+	if err := auditchain.Seal(&record, head); err != nil {
+		return err
+	}
+
+	arg.Seq = record.Seq
+	arg.PrevHash = record.PrevHash
+	arg.Hash = record.Hash
+	arg.OccurredAt = pgtype.Timestamp{Time: record.OccurredAt, Valid: true}
+	return nil
+}
+
+func auditEventFromParams(id int64, arg InsertAuditEventParams) AuditEvent {
+	return AuditEvent{
+		ID:         id,
+		OccurredAt: arg.OccurredAt,
+		EventType:  arg.EventType,
+		Outcome:    arg.Outcome,
+		Actor:      arg.Actor,
+		Subject:    arg.Subject,
+		Ip:         arg.Ip,
+		UserAgent:  arg.UserAgent,
+		RequestID:  arg.RequestID,
+		Metadata:   arg.Metadata,
+		Tenant:     arg.Tenant,
+		Seq:        arg.Seq,
+		PrevHash:   arg.PrevHash,
+		Hash:       arg.Hash,
+	}
+}
+
+// AuditRecord - поля события, покрываемые хешем цепочки
+func AuditRecord(event AuditEvent) auditchain.Record {
+	return auditchain.Record{
+		ID:         event.ID,
+		Tenant:     event.Tenant,
+		Seq:        event.Seq,
+		OccurredAt: event.OccurredAt.Time,
+		EventType:  event.EventType,
+		Outcome:    event.Outcome,
+		Actor:      event.Actor,
+		Subject:    event.Subject,
+		IP:         event.Ip,
+		UserAgent:  event.UserAgent,
+		RequestID:  event.RequestID,
+		Metadata:   event.Metadata,
+		PrevHash:   event.PrevHash,
+		Hash:       event.Hash,
+	}
+}
@@ -0,0 +1,678 @@
+package store
+
+import (
+	"auth_test/internal/auditchain"
+	"auth_test/internal/migrate"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Общий набор проверок для всех реализаций Store: сервисы полагаются на одинаковую семантику
+
+func TestInMemoryStoreConformance(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) Store {
+		return NewInMemoryStore()
+	})
+}
+
+func TestSQLiteStoreConformance(t *testing.T) {
+	runStoreConformance(t, func(t *testing.T) Store {
+		s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "auth.db"))
+		require.NoError(t, err)
+		_, err = migrate.NewSQLite(s.GetDB()).Up(context.Background())
+		require.NoError(t, err)
+		return s
+	})
+}
+
+// TestPostgresStoreConformance требует доступную базу PostgreSQL, например
+// TEST_POSTGRES_DSN="host=localhost port=5433 user=postgres password=postgres dbname=auth_test sslmode=disable"
+func TestPostgresStoreConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	runStoreConformance(t, func(t *testing.T) Store {
+		s, err := NewPostgresStore(dsn)
+		require.NoError(t, err)
+		_, err = migrate.NewPostgres(s.GetDB()).Up(context.Background())
+		require.NoError(t, err)
+		_, err = s.GetDB().Exec(context.Background(), "TRUNCATE users, audit_events, audit_chain_heads RESTART IDENTITY")
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func runStoreConformance(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s Store)
+	}{
+		{"Ping", testPing},
+		{"CreateAndGetUser", testCreateAndGetUser},
+		{"UniqueConstraints", testUniqueConstraints},
+		{"ListUsers", testListUsers},
+		{"StatusLifecycle", testStatusLifecycle},
+		{"MarkEmailVerified", testMarkEmailVerified},
+		{"PurgeUnverifiedUsers", testPurgeUnverifiedUsers},
+		{"Profile", testProfile},
+		{"CredentialsAndSessions", testCredentialsAndSessions},
+		{"ImportExport", testImportExport},
+		{"UpgradePasswordHash", testUpgradePasswordHash},
+		{"AuditEvents", testAuditEvents},
+		{"AuditChainConcurrentAppend", testAuditChainConcurrentAppend},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			defer s.Close()
+			tt.run(t, s)
+		})
+	}
+}
+
+func createTestUser(t *testing.T, s Store, username, email string) CreateUserRow {
+	params := CreateUserParams{
+		Username:     username,
+		PasswordHash: "hash-" + username,
+		Role:         "user",
+		Status:       "active",
+	}
+	if email != "" {
+		params.Email = pgtype.Text{String: email, Valid: true}
+	}
+
+	row, err := s.CreateUser(context.Background(), params)
+	require.NoError(t, err)
+	return row
+}
+
+func testPing(t *testing.T, s Store) {
+	require.NoError(t, s.Ping(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, s.Ping(ctx))
+}
+
+func testCreateAndGetUser(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	created := createTestUser(t, s, "alice", "Alice@example.com")
+	assert.NotZero(t, created.ID)
+	assert.Equal(t, "alice", created.Username)
+	assert.Equal(t, "hash-alice", created.PasswordHash)
+	assert.True(t, created.CreatedAt.Valid)
+
+	user, err := s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.Equal(t, "user", user.Role)
+	assert.Equal(t, "active", user.Status)
+	assert.Equal(t, "Alice@example.com", user.Email.String)
+	assert.False(t, user.EmailVerifiedAt.Valid)
+	assert.False(t, user.DeletedAt.Valid)
+	assert.True(t, user.StatusChangedAt.Valid)
+
+	byEmail, err := s.GetUserByEmail(ctx, "alice@EXAMPLE.com")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", byEmail.Username)
+
+	exists, err := s.UserExists(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = s.UserExists(ctx, "bob")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = s.GetUser(ctx, "bob")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = s.GetUserByEmail(ctx, "bob@example.com")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testUniqueConstraints(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTestUser(t, s, "alice", "alice@example.com")
+	// Пользователей без почты может быть сколько угодно
+	createTestUser(t, s, "nomail1", "")
+	createTestUser(t, s, "nomail2", "")
+
+	_, err := s.CreateUser(ctx, CreateUserParams{Username: "alice", PasswordHash: "x", Role: "user", Status: "active"})
+	assert.ErrorIs(t, MapUniqueViolation(err), ErrDuplicateUsername)
+
+	_, err = s.CreateUser(ctx, CreateUserParams{
+		Username:     "alice2",
+		PasswordHash: "x",
+		Role:         "user",
+		Status:       "active",
+		Email:        pgtype.Text{String: "ALICE@example.com", Valid: true},
+	})
+	assert.ErrorIs(t, MapUniqueViolation(err), ErrDuplicateEmail)
+}
+
+func testListUsers(t *testing.T, s Store) {
+	ctx := context.Background()
+	for _, username := range []string{"alpha", "beta", "gamma", "delta"} {
+		createTestUser(t, s, username, "")
+	}
+	_, err := s.CreateUser(ctx, CreateUserParams{Username: "root", PasswordHash: "x", Role: "admin", Status: "active"})
+	require.NoError(t, err)
+
+	affected, err := s.SetUserStatus(ctx, SetUserStatusParams{Username: "gamma", Status: "deleted"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affected)
+
+	usernames := func(rows []ListUsersRow) []string {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Username)
+		}
+		return names
+	}
+
+	rows, err := s.ListUsers(ctx, ListUsersParams{PageLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "beta", "delta", "root"}, usernames(rows))
+
+	rows, err = s.ListUsers(ctx, ListUsersParams{PageLimit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "beta"}, usernames(rows))
+
+	rows, err = s.ListUsers(ctx, ListUsersParams{AfterID: rows[1].ID, PageLimit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delta", "root"}, usernames(rows))
+
+	rows, err = s.ListUsers(ctx, ListUsersParams{UsernameFilter: pgtype.Text{String: "ELT", Valid: true}, PageLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delta"}, usernames(rows))
+
+	rows, err = s.ListUsers(ctx, ListUsersParams{Role: pgtype.Text{String: "admin", Valid: true}, PageLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"root"}, usernames(rows))
+
+	rows, err = s.ListUsers(ctx, ListUsersParams{Status: pgtype.Text{String: "deleted", Valid: true}, PageLimit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gamma"}, usernames(rows))
+	assert.True(t, rows[0].DeletedAt.Valid)
+
+	// Символы шаблонов LIKE в фильтре ищутся буквально
+	createTestUser(t, s, "sys_admin", "")
+	for filter, expected := range map[string][]string{
+		"_":    {"sys_admin"},
+		"%":    nil,
+		`\`:    nil,
+		"s_s":  nil,
+		"S_AD": {"sys_admin"},
+	} {
+		rows, err = s.ListUsers(ctx, ListUsersParams{UsernameFilter: pgtype.Text{String: filter, Valid: true}, PageLimit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, expected, usernames(rows), "filter %q", filter)
+	}
+}
+
+func testStatusLifecycle(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTestUser(t, s, "alice", "")
+	createTestUser(t, s, "bob", "")
+
+	affected, err := s.SetUserStatus(ctx, SetUserStatusParams{Username: "nobody", Status: "disabled"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "alice", Status: "disabled", StatusReason: "abuse"})
+	require.NoError(t, err)
+
+	user, err := s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "disabled", user.Status)
+	assert.Equal(t, "abuse", user.StatusReason)
+	assert.False(t, user.DeletedAt.Valid)
+
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "alice", Status: "deleted"})
+	require.NoError(t, err)
+	user, err = s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.True(t, user.DeletedAt.Valid)
+
+	// Повторное удаление не продлевает срок хранения
+	time.Sleep(5 * time.Millisecond)
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "alice", Status: "deleted", StatusReason: "again"})
+	require.NoError(t, err)
+	again, err := s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, user.DeletedAt, again.DeletedAt)
+
+	// Восстановление сбрасывает deleted_at
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "alice", Status: "active"})
+	require.NoError(t, err)
+	user, err = s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.False(t, user.DeletedAt.Valid)
+
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "bob", Status: "deleted"})
+	require.NoError(t, err)
+
+	purged, err := s.PurgeDeletedUsers(ctx, pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "recently deleted users are kept")
+
+	purged, err = s.PurgeDeletedUsers(ctx, pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = s.GetUser(ctx, "bob")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = s.GetUser(ctx, "alice")
+	assert.NoError(t, err)
+}
+
+func testMarkEmailVerified(t *testing.T, s Store) {
+	ctx := context.Background()
+	_, err := s.CreateUser(ctx, CreateUserParams{
+		Username:     "alice",
+		PasswordHash: "x",
+		Role:         "user",
+		Status:       "pending_verification",
+		Email:        pgtype.Text{String: "alice@example.com", Valid: true},
+	})
+	require.NoError(t, err)
+
+	affected, err := s.MarkEmailVerified(ctx, MarkEmailVerifiedParams{Username: "alice", Email: "other@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	affected, err = s.MarkEmailVerified(ctx, MarkEmailVerifiedParams{Username: "alice", Email: "ALICE@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	user, err := s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "active", user.Status)
+	assert.True(t, user.EmailVerifiedAt.Valid)
+}
+
+func testPurgeUnverifiedUsers(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTestUser(t, s, "alice", "alice@example.com")
+	_, err := s.CreateUser(ctx, CreateUserParams{
+		Username:     "bob",
+		PasswordHash: "x",
+		Role:         "user",
+		Status:       "pending_verification",
+		Email:        pgtype.Text{String: "bob@example.com", Valid: true},
+	})
+	require.NoError(t, err)
+
+	purged, err := s.PurgeUnverifiedUsers(ctx, pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "recent registrations are kept")
+
+	purged, err = s.PurgeUnverifiedUsers(ctx, pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = s.GetUser(ctx, "bob")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = s.GetUser(ctx, "alice")
+	assert.NoError(t, err)
+
+	// Имя и адрес освобождены для новой регистрации
+	_, err = s.CreateUser(ctx, CreateUserParams{
+		Username:     "bob",
+		PasswordHash: "y",
+		Role:         "user",
+		Status:       "pending_verification",
+		Email:        pgtype.Text{String: "bob@example.com", Valid: true},
+	})
+	assert.NoError(t, err)
+}
+
+func testProfile(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTestUser(t, s, "alice", "alice@example.com")
+	_, err := s.MarkEmailVerified(ctx, MarkEmailVerifiedParams{Username: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	profile, err := s.GetProfile(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), profile.Version)
+	assert.JSONEq(t, `{}`, string(profile.Attributes))
+	assert.True(t, profile.EmailVerifiedAt.Valid)
+
+	params := UpdateProfileParams{
+		Email:           profile.Email,
+		DisplayName:     "Alice",
+		Locale:          "en-US",
+		Timezone:        "Europe/Berlin",
+		Attributes:      []byte(`{"team":"platform"}`),
+		Username:        "alice",
+		ExpectedVersion: 1,
+	}
+	updated, err := s.UpdateProfile(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), updated.Version)
+	assert.Equal(t, "Alice", updated.DisplayName)
+	assert.JSONEq(t, `{"team":"platform"}`, string(updated.Attributes))
+	assert.True(t, updated.EmailVerifiedAt.Valid, "unchanged email stays verified")
+
+	// Устаревшая версия
+	_, err = s.UpdateProfile(ctx, params)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	params.ExpectedVersion = 2
+	params.Email = pgtype.Text{String: "new@example.com", Valid: true}
+	updated, err = s.UpdateProfile(ctx, params)
+	require.NoError(t, err)
+	assert.False(t, updated.EmailVerifiedAt.Valid, "changed email must be verified again")
+
+	createTestUser(t, s, "bob", "bob@example.com")
+	bobProfile, err := s.GetProfile(ctx, "bob")
+	require.NoError(t, err)
+	_, err = s.UpdateProfile(ctx, UpdateProfileParams{
+		Email:           pgtype.Text{String: "NEW@example.com", Valid: true},
+		Attributes:      []byte(`{}`),
+		Username:        "bob",
+		ExpectedVersion: bobProfile.Version,
+	})
+	assert.ErrorIs(t, MapUniqueViolation(err), ErrDuplicateEmail)
+
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "bob", Status: "deleted"})
+	require.NoError(t, err)
+	_, err = s.GetProfile(ctx, "bob")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func testCredentialsAndSessions(t *testing.T, s Store) {
+	ctx := context.Background()
+	_, err := s.CreateUser(ctx, CreateUserParams{
+		Username:               "alice",
+		PasswordHash:           "hash",
+		Role:                   "user",
+		Status:                 "active",
+		PasswordChangeRequired: true,
+	})
+	require.NoError(t, err)
+
+	user, err := s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), user.SessionVersion)
+	assert.True(t, user.PasswordChangeRequired)
+
+	exists, err := s.AdminExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	affected, err := s.SetUserPassword(ctx, SetUserPasswordParams{Username: "alice", PasswordHash: "new-hash"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	user, err = s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", user.PasswordHash)
+	assert.Equal(t, int32(2), user.SessionVersion, "password change revokes sessions")
+	assert.False(t, user.PasswordChangeRequired)
+
+	affected, err = s.RevokeUserSessions(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	affected, err = s.SetUserRole(ctx, SetUserRoleParams{Username: "alice", Role: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	user, err = s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), user.SessionVersion)
+	assert.Equal(t, "admin", user.Role)
+
+	exists, err = s.AdminExists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Удалённый администратор не считается
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "alice", Status: "deleted"})
+	require.NoError(t, err)
+	exists, err = s.AdminExists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	for _, update := range []func() (int64, error){
+		func() (int64, error) {
+			return s.SetUserPassword(ctx, SetUserPasswordParams{Username: "nobody", PasswordHash: "x"})
+		},
+		func() (int64, error) { return s.SetUserRole(ctx, SetUserRoleParams{Username: "nobody", Role: "admin"}) },
+		func() (int64, error) { return s.RevokeUserSessions(ctx, "nobody") },
+	} {
+		affected, err := update()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), affected)
+	}
+}
+
+func testImportExport(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTestUser(t, s, "alice", "alice@example.com")
+
+	existing, err := s.ExistingUsernames(ctx, []string{"alice", "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, existing)
+
+	existing, err = s.ExistingEmails(ctx, []string{"alice@example.com", "bob@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com"}, existing)
+
+	imported, err := s.ImportUsers(ctx, []ImportUsersParams{
+		{Username: "bob", PasswordHash: "hash-bob", Role: "user", Status: "active", Email: pgtype.Text{String: "bob@example.com", Valid: true}},
+		{Username: "carol", PasswordHash: "hash-carol", Role: "admin", Status: "disabled", PasswordChangeRequired: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), imported)
+
+	carol, err := s.GetUser(ctx, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", carol.Role)
+	assert.Equal(t, "disabled", carol.Status)
+	assert.True(t, carol.PasswordChangeRequired)
+	assert.Equal(t, int32(1), carol.SessionVersion)
+
+	// Пачка с конфликтом не вставляется целиком
+	_, err = s.ImportUsers(ctx, []ImportUsersParams{
+		{Username: "dave", PasswordHash: "hash-dave", Role: "user", Status: "active"},
+		{Username: "alice", PasswordHash: "hash", Role: "user", Status: "active"},
+	})
+	require.Error(t, err)
+	exists, err := s.UserExists(ctx, "dave")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = s.SetUserStatus(ctx, SetUserStatusParams{Username: "alice", Status: "deleted"})
+	require.NoError(t, err)
+
+	page, err := s.ExportUsers(ctx, ExportUsersParams{PageLimit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "bob", page[0].Username, "deleted users are not exported")
+	assert.Equal(t, "hash-bob", page[0].PasswordHash)
+	assert.Equal(t, "bob@example.com", page[0].Email.String)
+
+	page, err = s.ExportUsers(ctx, ExportUsersParams{AfterID: page[0].ID, PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "carol", page[0].Username)
+	assert.True(t, page[0].PasswordChangeRequired)
+}
+
+func testUpgradePasswordHash(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTestUser(t, s, "alice", "")
+
+	// Хеш уже изменился: замена не выполняется
+	affected, err := s.UpgradePasswordHash(ctx, UpgradePasswordHashParams{Username: "alice", OldHash: "stale", NewHash: "new-hash"})
+	require.NoError(t, err)
+	assert.Zero(t, affected)
+
+	affected, err = s.UpgradePasswordHash(ctx, UpgradePasswordHashParams{Username: "alice", OldHash: "hash-alice", NewHash: "new-hash"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	user, err := s.GetUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", user.PasswordHash)
+	assert.Equal(t, int32(1), user.SessionVersion, "hash upgrade keeps sessions")
+}
+
+func testAuditEvents(t *testing.T, s Store) {
+	ctx := context.Background()
+	base := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	events := []InsertAuditEventParams{
+		{EventType: "user.login", Outcome: "success", Actor: "user:alice", Subject: "alice", Ip: "10.0.0.1"},
+		{EventType: "user.login", Outcome: "failure", Actor: "anonymous", Subject: "bob", Metadata: []byte(`{"reason":"invalid_credentials"}`)},
+		{EventType: "admin.user_disable", Outcome: "success", Actor: "user:admin", Subject: "bob"},
+		{EventType: "user.login", Outcome: "success", Actor: "user:bob", Subject: "bob", UserAgent: "curl/8.0", RequestID: "req-4"},
+	}
+	var ids []int64
+	for i, event := range events {
+		event.Tenant = "acme"
+		event.OccurredAt = pgtype.Timestamp{Time: base.Add(time.Duration(i) * time.Hour), Valid: true}
+		appended, err := s.AppendAuditEvent(ctx, event)
+		require.NoError(t, err)
+		assert.Equal(t, int64(i+1), appended.Seq)
+		ids = append(ids, appended.ID)
+	}
+	// Событие другого тенанта не попадает в выборки acme
+	_, err := s.AppendAuditEvent(ctx, InsertAuditEventParams{
+		Tenant: "other", OccurredAt: pgtype.Timestamp{Time: base, Valid: true}, EventType: "user.login", Outcome: "success",
+	})
+	require.NoError(t, err)
+
+	all, err := s.ListAuditEvents(ctx, ListAuditEventsParams{Tenant: "acme", PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	assert.Equal(t, ids[3], all[0].ID, "newest first")
+	assert.Equal(t, "curl/8.0", all[0].UserAgent)
+	assert.Equal(t, "req-4", all[0].RequestID)
+	assert.True(t, base.Add(3*time.Hour).Equal(all[0].OccurredAt.Time))
+	assert.JSONEq(t, `{"reason":"invalid_credentials"}`, string(all[2].Metadata))
+	assert.JSONEq(t, `{}`, string(all[3].Metadata))
+	assert.Equal(t, all[1].Hash, all[0].PrevHash)
+
+	page, err := s.ListAuditEvents(ctx, ListAuditEventsParams{Tenant: "acme", BeforeID: ids[2], PageLimit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, ids[1], page[0].ID)
+
+	filtered, err := s.ListAuditEvents(ctx, ListAuditEventsParams{
+		Tenant:    "acme",
+		EventType: pgtype.Text{String: "user.login", Valid: true},
+		Subject:   pgtype.Text{String: "bob", Valid: true},
+		Since:     pgtype.Timestamp{Time: base.Add(time.Hour), Valid: true},
+		Until:     pgtype.Timestamp{Time: base.Add(3 * time.Hour), Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "failure", filtered[0].Outcome)
+
+	byActor, err := s.ListAuditEvents(ctx, ListAuditEventsParams{
+		Tenant:    "acme",
+		Actor:     pgtype.Text{String: "user:admin", Valid: true},
+		Outcome:   pgtype.Text{String: "success", Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, byActor, 1)
+	assert.Equal(t, "admin.user_disable", byActor[0].EventType)
+
+	// Хеши, прочитанные из хранилища, сходятся с пересчитанными
+	chain, err := s.ListAuditChain(ctx, ListAuditChainParams{Tenant: "acme", PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, chain, 4)
+	verifier := auditchain.NewVerifier("acme")
+	for _, event := range chain {
+		verifier.Add(AuditRecord(event))
+	}
+	head, err := s.GetAuditChainHead(ctx, "acme")
+	require.NoError(t, err)
+	report := verifier.Finish(&auditchain.Head{Seq: head.Seq, Hash: head.Hash})
+	assert.True(t, report.OK(), "%+v", report.Breaks)
+	assert.Equal(t, int64(4), head.Seq)
+
+	_, err = s.GetAuditChainHead(ctx, "missing")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	anchor, err := s.GetAuditPurgeAnchor(ctx, GetAuditPurgeAnchorParams{
+		Tenant: "acme", OccurredAt: pgtype.Timestamp{Time: base.Add(2 * time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), anchor.Seq)
+	assert.Equal(t, chain[1].Hash, anchor.Hash)
+
+	purged, err := s.PurgeAuditEvents(ctx, PurgeAuditEventsParams{Tenant: "acme", MaxSeq: anchor.Seq})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	rest, err := s.ListAuditEvents(ctx, ListAuditEventsParams{Tenant: "acme", PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, rest, 2)
+	assert.Equal(t, ids[2], rest[1].ID)
+
+	after, err := s.ListAuditChain(ctx, ListAuditChainParams{Tenant: "acme", AfterSeq: 3, PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, after, 1)
+	assert.Equal(t, int64(4), after[0].Seq)
+}
+
+func testAuditChainConcurrentAppend(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	const writers, perWriter = 4, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				_, err := s.AppendAuditEvent(ctx, InsertAuditEventParams{
+					Tenant:     "concurrent",
+					OccurredAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+					EventType:  "user.login",
+					Outcome:    "success",
+				})
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	chain, err := s.ListAuditChain(ctx, ListAuditChainParams{Tenant: "concurrent", PageLimit: 100})
+	require.NoError(t, err)
+	require.Len(t, chain, writers*perWriter)
+
+	verifier := auditchain.NewVerifier("concurrent")
+	for _, event := range chain {
+		verifier.Add(AuditRecord(event))
+	}
+	head, err := s.GetAuditChainHead(ctx, "concurrent")
+	require.NoError(t, err)
+	report := verifier.Finish(&auditchain.Head{Seq: head.Seq, Hash: head.Hash})
+	assert.True(t, report.OK(), "%+v", report.Breaks)
+}
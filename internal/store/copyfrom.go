@@ -0,0 +1,47 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: copyfrom.go
+
+package store
+
+import (
+	"context"
+)
+
+// iteratorForImportUsers implements pgx.CopyFromSource.
+type iteratorForImportUsers struct {
+	rows                 []ImportUsersParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForImportUsers) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForImportUsers) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].Username,
+		r.rows[0].PasswordHash,
+		r.rows[0].Role,
+		r.rows[0].Status,
+		r.rows[0].Email,
+		r.rows[0].PasswordChangeRequired,
+	}, nil
+}
+
+func (r iteratorForImportUsers) Err() error {
+	return nil
+}
+
+func (q *Queries) ImportUsers(ctx context.Context, arg []ImportUsersParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"users"}, []string{"username", "password_hash", "role", "status", "email", "password_change_required"}, &iteratorForImportUsers{rows: arg})
+}
@@ -0,0 +1,752 @@
+package store
+
+import (
+	"auth_test/internal/auditchain"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// InMemoryStore - потокобезопасное хранилище в памяти для локальной разработки и тестов.
+// Повторяет семантику запросов из queries.sql: не найденная запись - pgx.ErrNoRows
+type InMemoryStore struct {
+	mu          sync.RWMutex
+	nextID      int32
+	users       map[string]*User
+	nextAuditID int64
+	auditEvents []AuditEvent
+	auditHeads  map[string]auditchain.Head
+	now         func() time.Time
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		users:      make(map[string]*User),
+		auditHeads: make(map[string]auditchain.Head),
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *InMemoryStore) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (s *InMemoryStore) Close() {}
+
+func (s *InMemoryStore) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
+	if err := ctx.Err(); err != nil {
+		return CreateUserRow{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.Username]; ok {
+		return CreateUserRow{}, ErrDuplicateUsername
+	}
+	if arg.Email.Valid && s.findByEmail(arg.Email.String) != nil {
+		return CreateUserRow{}, ErrDuplicateEmail
+	}
+
+	s.nextID++
+	now := s.timestamp()
+	user := &User{
+		ID:                     s.nextID,
+		Username:               arg.Username,
+		PasswordHash:           arg.PasswordHash,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		Role:                   arg.Role,
+		Status:                 arg.Status,
+		StatusChangedAt:        now,
+		Email:                  arg.Email,
+		Attributes:             []byte("{}"),
+		Version:                1,
+		SessionVersion:         1,
+		PasswordChangeRequired: arg.PasswordChangeRequired,
+	}
+	s.users[arg.Username] = user
+
+	return CreateUserRow{
+		ID:           user.ID,
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		Role:         user.Role,
+		CreatedAt:    user.CreatedAt,
+	}, nil
+}
+
+func (s *InMemoryStore) GetUser(ctx context.Context, username string) (GetUserRow, error) {
+	if err := ctx.Err(); err != nil {
+		return GetUserRow{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		return GetUserRow{}, pgx.ErrNoRows
+	}
+
+	return GetUserRow{
+		ID:                     user.ID,
+		Username:               user.Username,
+		PasswordHash:           user.PasswordHash,
+		Role:                   user.Role,
+		Status:                 user.Status,
+		StatusReason:           user.StatusReason,
+		StatusChangedAt:        user.StatusChangedAt,
+		DeletedAt:              user.DeletedAt,
+		Email:                  user.Email,
+		EmailVerifiedAt:        user.EmailVerifiedAt,
+		SessionVersion:         user.SessionVersion,
+		CreatedAt:              user.CreatedAt,
+		UpdatedAt:              user.UpdatedAt,
+		PasswordChangeRequired: user.PasswordChangeRequired,
+	}, nil
+}
+
+func (s *InMemoryStore) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
+	if err := ctx.Err(); err != nil {
+		return GetUserByEmailRow{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user := s.findByEmail(email)
+	if user == nil {
+		return GetUserByEmailRow{}, pgx.ErrNoRows
+	}
+
+	return GetUserByEmailRow{
+		ID:              user.ID,
+		Username:        user.Username,
+		Status:          user.Status,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	}, nil
+}
+
+func (s *InMemoryStore) UserExists(ctx context.Context, username string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.users[username]
+	return ok, nil
+}
+
+func (s *InMemoryStore) AdminExists(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Role == "admin" && user.Status != "deleted" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *InMemoryStore) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []ListUsersRow
+	for _, user := range s.users {
+		if user.ID <= arg.AfterID {
+			continue
+		}
+		if arg.UsernameFilter.Valid && !strings.Contains(strings.ToLower(user.Username), strings.ToLower(arg.UsernameFilter.String)) {
+			continue
+		}
+		if arg.Role.Valid && user.Role != arg.Role.String {
+			continue
+		}
+		// Удалённые пользователи возвращаются только при явном фильтре по статусу
+		if arg.Status.Valid {
+			if user.Status != arg.Status.String {
+				continue
+			}
+		} else if user.Status == "deleted" {
+			continue
+		}
+
+		items = append(items, ListUsersRow{
+			ID:              user.ID,
+			Username:        user.Username,
+			Role:            user.Role,
+			Status:          user.Status,
+			StatusReason:    user.StatusReason,
+			StatusChangedAt: user.StatusChangedAt,
+			DeletedAt:       user.DeletedAt,
+			CreatedAt:       user.CreatedAt,
+			UpdatedAt:       user.UpdatedAt,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	if int32(len(items)) > arg.PageLimit {
+		items = items[:arg.PageLimit]
+	}
+	return items, nil
+}
+
+func (s *InMemoryStore) SetUserStatus(ctx context.Context, arg SetUserStatusParams) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.Username]
+	if !ok {
+		return 0, nil
+	}
+
+	now := s.timestamp()
+	user.Status = arg.Status
+	user.StatusReason = arg.StatusReason
+	user.StatusChangedAt = now
+	switch {
+	case arg.Status != "deleted":
+		user.DeletedAt = pgtype.Timestamp{}
+	case !user.DeletedAt.Valid:
+		user.DeletedAt = now
+	}
+	user.UpdatedAt = now
+	return 1, nil
+}
+
+func (s *InMemoryStore) PurgeDeletedUsers(ctx context.Context, deletedAt pgtype.Timestamp) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for username, user := range s.users {
+		if user.Status == "deleted" && user.DeletedAt.Valid && user.DeletedAt.Time.Before(deletedAt.Time) {
+			delete(s.users, username)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *InMemoryStore) PurgeUnverifiedUsers(ctx context.Context, statusChangedAt pgtype.Timestamp) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for username, user := range s.users {
+		if user.Status == "pending_verification" && user.StatusChangedAt.Time.Before(statusChangedAt.Time) {
+			delete(s.users, username)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *InMemoryStore) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.Username]
+	if !ok || !user.Email.Valid || !strings.EqualFold(user.Email.String, arg.Email) {
+		return 0, nil
+	}
+
+	now := s.timestamp()
+	user.EmailVerifiedAt = now
+	if user.Status == "pending_verification" {
+		user.Status = "active"
+		user.StatusChangedAt = now
+	}
+	user.UpdatedAt = now
+	return 1, nil
+}
+
+func (s *InMemoryStore) GetProfile(ctx context.Context, username string) (GetProfileRow, error) {
+	if err := ctx.Err(); err != nil {
+		return GetProfileRow{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[username]
+	if !ok || user.Status == "deleted" {
+		return GetProfileRow{}, pgx.ErrNoRows
+	}
+
+	return GetProfileRow(profileRow(user)), nil
+}
+
+func (s *InMemoryStore) UpdateProfile(ctx context.Context, arg UpdateProfileParams) (UpdateProfileRow, error) {
+	if err := ctx.Err(); err != nil {
+		return UpdateProfileRow{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.Username]
+	if !ok || user.Status == "deleted" || user.Version != arg.ExpectedVersion {
+		return UpdateProfileRow{}, pgx.ErrNoRows
+	}
+
+	if arg.Email.Valid {
+		if other := s.findByEmail(arg.Email.String); other != nil && other != user {
+			return UpdateProfileRow{}, ErrDuplicateEmail
+		}
+	}
+
+	if user.Email != arg.Email {
+		user.EmailVerifiedAt = pgtype.Timestamp{}
+	}
+	user.Email = arg.Email
+	user.DisplayName = arg.DisplayName
+	user.Locale = arg.Locale
+	user.Timezone = arg.Timezone
+	user.Attributes = append([]byte(nil), arg.Attributes...)
+	user.Version++
+	user.UpdatedAt = s.timestamp()
+
+	return profileRow(user), nil
+}
+
+func (s *InMemoryStore) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error) {
+	return s.updateUser(ctx, arg.Username, func(user *User) {
+		user.PasswordHash = arg.PasswordHash
+		user.PasswordChangeRequired = arg.PasswordChangeRequired
+		user.SessionVersion++
+	})
+}
+
+func (s *InMemoryStore) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	return s.updateUser(ctx, arg.Username, func(user *User) {
+		user.Role = arg.Role
+	})
+}
+
+func (s *InMemoryStore) RevokeUserSessions(ctx context.Context, username string) (int64, error) {
+	return s.updateUser(ctx, username, func(user *User) {
+		user.SessionVersion++
+	})
+}
+
+func (s *InMemoryStore) UpgradePasswordHash(ctx context.Context, arg UpgradePasswordHashParams) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.Username]
+	if !ok || user.PasswordHash != arg.OldHash {
+		return 0, nil
+	}
+
+	user.PasswordHash = arg.NewHash
+	user.UpdatedAt = s.timestamp()
+	return 1, nil
+}
+
+func (s *InMemoryStore) ExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var existing []string
+	for _, username := range usernames {
+		if _, ok := s.users[username]; ok {
+			existing = append(existing, username)
+		}
+	}
+	return existing, nil
+}
+
+func (s *InMemoryStore) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var existing []string
+	for _, email := range emails {
+		if s.findByEmail(email) != nil {
+			existing = append(existing, strings.ToLower(email))
+		}
+	}
+	return existing, nil
+}
+
+// ImportUsers вставляет пачку целиком или не вставляет ничего, как COPY в Postgres
+func (s *InMemoryStore) ImportUsers(ctx context.Context, arg []ImportUsersParams) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usernames := make(map[string]bool, len(arg))
+	emails := make(map[string]bool, len(arg))
+	for _, row := range arg {
+		if _, ok := s.users[row.Username]; ok || usernames[row.Username] {
+			return 0, ErrDuplicateUsername
+		}
+		usernames[row.Username] = true
+
+		if row.Email.Valid {
+			email := strings.ToLower(row.Email.String)
+			if s.findByEmail(email) != nil || emails[email] {
+				return 0, ErrDuplicateEmail
+			}
+			emails[email] = true
+		}
+	}
+
+	now := s.timestamp()
+	for _, row := range arg {
+		s.nextID++
+		s.users[row.Username] = &User{
+			ID:                     s.nextID,
+			Username:               row.Username,
+			PasswordHash:           row.PasswordHash,
+			CreatedAt:              now,
+			UpdatedAt:              now,
+			Role:                   row.Role,
+			Status:                 row.Status,
+			StatusChangedAt:        now,
+			Email:                  row.Email,
+			Attributes:             []byte("{}"),
+			Version:                1,
+			SessionVersion:         1,
+			PasswordChangeRequired: row.PasswordChangeRequired,
+		}
+	}
+	return int64(len(arg)), nil
+}
+
+func (s *InMemoryStore) ExportUsers(ctx context.Context, arg ExportUsersParams) ([]ExportUsersRow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []ExportUsersRow
+	for _, user := range s.users {
+		if user.ID <= arg.AfterID || user.Status == "deleted" {
+			continue
+		}
+		items = append(items, ExportUsersRow{
+			ID:                     user.ID,
+			Username:               user.Username,
+			PasswordHash:           user.PasswordHash,
+			Role:                   user.Role,
+			Status:                 user.Status,
+			Email:                  user.Email,
+			PasswordChangeRequired: user.PasswordChangeRequired,
+			CreatedAt:              user.CreatedAt,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	if int32(len(items)) > arg.PageLimit {
+		items = items[:arg.PageLimit]
+	}
+	return items, nil
+}
+
+func (s *InMemoryStore) AppendAuditEvent(ctx context.Context, arg InsertAuditEventParams) (AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return AuditEvent{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !arg.OccurredAt.Valid {
+		arg.OccurredAt = s.timestamp()
+	}
+	if err := sealAuditEvent(&arg, s.auditHeads[arg.Tenant]); err != nil {
+		return AuditEvent{}, err
+	}
+
+	event := s.insertAuditEvent(arg)
+	s.auditHeads[arg.Tenant] = auditchain.Head{Seq: arg.Seq, Hash: arg.Hash}
+	return event, nil
+}
+
+func (s *InMemoryStore) InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range s.auditEvents {
+		if event.Tenant == arg.Tenant && event.Seq == arg.Seq {
+			return 0, fmt.Errorf("duplicate audit event seq %d", arg.Seq)
+		}
+	}
+	return s.insertAuditEvent(arg).ID, nil
+}
+
+// insertAuditEvent вызывается под блокировкой
+func (s *InMemoryStore) insertAuditEvent(arg InsertAuditEventParams) AuditEvent {
+	if !arg.OccurredAt.Valid {
+		arg.OccurredAt = s.timestamp()
+	}
+	arg.Metadata = append([]byte(nil), arg.Metadata...)
+	if len(arg.Metadata) == 0 {
+		arg.Metadata = []byte("{}")
+	}
+
+	s.nextAuditID++
+	event := auditEventFromParams(s.nextAuditID, arg)
+	s.auditEvents = append(s.auditEvents, event)
+	return event
+}
+
+func (s *InMemoryStore) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []AuditEvent
+	// События хранятся в порядке id: обход с конца даёт новые первыми
+	for i := len(s.auditEvents) - 1; i >= 0 && int32(len(items)) < arg.PageLimit; i-- {
+		event := s.auditEvents[i]
+		if event.Tenant != arg.Tenant {
+			continue
+		}
+		if arg.BeforeID != 0 && event.ID >= arg.BeforeID {
+			continue
+		}
+		if arg.EventType.Valid && event.EventType != arg.EventType.String {
+			continue
+		}
+		if arg.Subject.Valid && event.Subject != arg.Subject.String {
+			continue
+		}
+		if arg.Actor.Valid && event.Actor != arg.Actor.String {
+			continue
+		}
+		if arg.Outcome.Valid && event.Outcome != arg.Outcome.String {
+			continue
+		}
+		if arg.Since.Valid && event.OccurredAt.Time.Before(arg.Since.Time) {
+			continue
+		}
+		if arg.Until.Valid && !event.OccurredAt.Time.Before(arg.Until.Time) {
+			continue
+		}
+
+		event.Metadata = append([]byte(nil), event.Metadata...)
+		items = append(items, event)
+	}
+	return items, nil
+}
+
+func (s *InMemoryStore) ListAuditChain(ctx context.Context, arg ListAuditChainParams) ([]AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var items []AuditEvent
+	for _, event := range s.auditEvents {
+		if event.Tenant == arg.Tenant && event.Seq > arg.AfterSeq {
+			event.Metadata = append([]byte(nil), event.Metadata...)
+			items = append(items, event)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Seq < items[j].Seq })
+	if int32(len(items)) > arg.PageLimit {
+		items = items[:arg.PageLimit]
+	}
+	return items, nil
+}
+
+func (s *InMemoryStore) LockAuditChainHead(ctx context.Context, tenant string) (LockAuditChainHeadRow, error) {
+	if err := ctx.Err(); err != nil {
+		return LockAuditChainHeadRow{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	head, ok := s.auditHeads[tenant]
+	if !ok {
+		s.auditHeads[tenant] = head
+	}
+	return LockAuditChainHeadRow{Seq: head.Seq, Hash: head.Hash}, nil
+}
+
+func (s *InMemoryStore) GetAuditChainHead(ctx context.Context, tenant string) (GetAuditChainHeadRow, error) {
+	if err := ctx.Err(); err != nil {
+		return GetAuditChainHeadRow{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	head, ok := s.auditHeads[tenant]
+	if !ok {
+		return GetAuditChainHeadRow{}, pgx.ErrNoRows
+	}
+	return GetAuditChainHeadRow{Seq: head.Seq, Hash: head.Hash}, nil
+}
+
+func (s *InMemoryStore) UpdateAuditChainHead(ctx context.Context, arg UpdateAuditChainHeadParams) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.auditHeads[arg.Tenant]; ok {
+		s.auditHeads[arg.Tenant] = auditchain.Head{Seq: arg.Seq, Hash: arg.Hash}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) GetAuditPurgeAnchor(ctx context.Context, arg GetAuditPurgeAnchorParams) (GetAuditPurgeAnchorRow, error) {
+	if err := ctx.Err(); err != nil {
+		return GetAuditPurgeAnchorRow{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var anchor *AuditEvent
+	for i, event := range s.auditEvents {
+		if event.Tenant != arg.Tenant || !event.OccurredAt.Time.Before(arg.OccurredAt.Time) {
+			continue
+		}
+		if anchor == nil || event.Seq > anchor.Seq {
+			anchor = &s.auditEvents[i]
+		}
+	}
+	if anchor == nil {
+		return GetAuditPurgeAnchorRow{}, pgx.ErrNoRows
+	}
+	return GetAuditPurgeAnchorRow{Seq: anchor.Seq, Hash: anchor.Hash}, nil
+}
+
+func (s *InMemoryStore) PurgeAuditEvents(ctx context.Context, arg PurgeAuditEventsParams) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.auditEvents[:0]
+	for _, event := range s.auditEvents {
+		if event.Tenant != arg.Tenant || event.Seq > arg.MaxSeq {
+			kept = append(kept, event)
+		}
+	}
+	purged := int64(len(s.auditEvents) - len(kept))
+	s.auditEvents = kept
+	return purged, nil
+}
+
+// updateUser изменяет пользователя под блокировкой; возвращает число изменённых записей, как :execrows
+func (s *InMemoryStore) updateUser(ctx context.Context, username string, update func(user *User)) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[username]
+	if !ok {
+		return 0, nil
+	}
+
+	update(user)
+	user.UpdatedAt = s.timestamp()
+	return 1, nil
+}
+
+// findByEmail ищет пользователя без учёта регистра адреса; вызывается под блокировкой
+func (s *InMemoryStore) findByEmail(email string) *User {
+	for _, user := range s.users {
+		if user.Email.Valid && strings.EqualFold(user.Email.String, email) {
+			return user
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) timestamp() pgtype.Timestamp {
+	return pgtype.Timestamp{Time: s.now(), Valid: true}
+}
+
+func profileRow(user *User) UpdateProfileRow {
+	return UpdateProfileRow{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		DisplayName:     user.DisplayName,
+		Locale:          user.Locale,
+		Timezone:        user.Timezone,
+		Attributes:      append([]byte(nil), user.Attributes...),
+		Version:         user.Version,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+}
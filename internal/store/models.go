@@ -8,10 +8,47 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditChainHead struct {
+	Tenant string `json:"tenant"`
+	Seq    int64  `json:"seq"`
+	Hash   string `json:"hash"`
+}
+
+type AuditEvent struct {
+	ID         int64            `json:"id"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+	EventType  string           `json:"event_type"`
+	Outcome    string           `json:"outcome"`
+	Actor      string           `json:"actor"`
+	Subject    string           `json:"subject"`
+	Ip         string           `json:"ip"`
+	UserAgent  string           `json:"user_agent"`
+	RequestID  string           `json:"request_id"`
+	Metadata   []byte           `json:"metadata"`
+	Tenant     string           `json:"tenant"`
+	Seq        int64            `json:"seq"`
+	PrevHash   string           `json:"prev_hash"`
+	Hash       string           `json:"hash"`
+}
+
 type User struct {
-	ID           int32            `json:"id"`
-	Username     string           `json:"username"`
-	PasswordHash string           `json:"password_hash"`
-	CreatedAt    pgtype.Timestamp `json:"created_at"`
-	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+	ID                     int32            `json:"id"`
+	Username               string           `json:"username"`
+	PasswordHash           string           `json:"password_hash"`
+	CreatedAt              pgtype.Timestamp `json:"created_at"`
+	UpdatedAt              pgtype.Timestamp `json:"updated_at"`
+	Role                   string           `json:"role"`
+	Status                 string           `json:"status"`
+	StatusReason           string           `json:"status_reason"`
+	StatusChangedAt        pgtype.Timestamp `json:"status_changed_at"`
+	DeletedAt              pgtype.Timestamp `json:"deleted_at"`
+	Email                  pgtype.Text      `json:"email"`
+	EmailVerifiedAt        pgtype.Timestamp `json:"email_verified_at"`
+	DisplayName            string           `json:"display_name"`
+	Locale                 string           `json:"locale"`
+	Timezone               string           `json:"timezone"`
+	Attributes             []byte           `json:"attributes"`
+	Version                int32            `json:"version"`
+	SessionVersion         int32            `json:"session_version"`
+	PasswordChangeRequired bool             `json:"password_change_required"`
 }
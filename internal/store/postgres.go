@@ -1,6 +1,7 @@
 package store
 
 import (
+	"auth_test/internal/tracing"
 	"context"
 	"fmt"
 	"time"
@@ -19,6 +20,7 @@ func NewPostgresStore(connStr string) (*PostgresStore, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	config.ConnConfig.Tracer = tracing.NewPgxTracer()
 
 	db, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
@@ -38,6 +40,10 @@ func NewPostgresStore(connStr string) (*PostgresStore, error) {
 	}, nil
 }
 
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
 func (s *PostgresStore) Close() {
 	s.db.Close()
 }
@@ -0,0 +1,61 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	AdminExists(ctx context.Context) (bool, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
+	// Адреса возвращаются в нижнем регистре
+	ExistingEmails(ctx context.Context, emails []string) ([]string, error)
+	ExistingUsernames(ctx context.Context, usernames []string) ([]string, error)
+	ExportUsers(ctx context.Context, arg ExportUsersParams) ([]ExportUsersRow, error)
+	GetAuditChainHead(ctx context.Context, tenant string) (GetAuditChainHeadRow, error)
+	// Последняя запись, которую удалит политика хранения
+	GetAuditPurgeAnchor(ctx context.Context, arg GetAuditPurgeAnchorParams) (GetAuditPurgeAnchorRow, error)
+	GetProfile(ctx context.Context, username string) (GetProfileRow, error)
+	// internal/store/queries.sql
+	GetUser(ctx context.Context, username string) (GetUserRow, error)
+	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
+	ImportUsers(ctx context.Context, arg []ImportUsersParams) (int64, error)
+	// Только для AppendAuditEvent: seq, prev_hash и hash вычисляются под блокировкой головы цепочки
+	InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) (int64, error)
+	// Записи цепочки тенанта по возрастанию seq (проверка и выгрузка)
+	ListAuditChain(ctx context.Context, arg ListAuditChainParams) ([]AuditEvent, error)
+	// Новые события первыми; курсор - id последнего события предыдущей страницы
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	// Удалённые (soft delete) пользователи возвращаются только при явном фильтре по статусу.
+	// Фильтр по имени - подстрока без шаблонов LIKE: % и _ в нём ищутся как обычные символы
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	// Создаёт голову цепочки при первом событии тенанта и блокирует её строку до конца транзакции
+	LockAuditChainHead(ctx context.Context, tenant string) (LockAuditChainHeadRow, error)
+	// Подтверждение адреса активирует пользователя, только если он ожидал подтверждения
+	MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (int64, error)
+	// Удаляется только начало цепочки, поэтому оставшиеся записи продолжают проверяться
+	PurgeAuditEvents(ctx context.Context, arg PurgeAuditEventsParams) (int64, error)
+	PurgeDeletedUsers(ctx context.Context, deletedAt pgtype.Timestamp) (int64, error)
+	// Регистрации, не подтверждённые вовремя: адрес и имя освобождаются для новой регистрации
+	PurgeUnverifiedUsers(ctx context.Context, statusChangedAt pgtype.Timestamp) (int64, error)
+	RevokeUserSessions(ctx context.Context, username string) (int64, error)
+	// Смена пароля отзывает все выданные токены
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error)
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error)
+	// Повторное удаление не сдвигает deleted_at, иначе срок хранения начинается заново
+	SetUserStatus(ctx context.Context, arg SetUserStatusParams) (int64, error)
+	UpdateAuditChainHead(ctx context.Context, arg UpdateAuditChainHeadParams) error
+	// Обновление проходит, только если версия не изменилась с момента чтения
+	UpdateProfile(ctx context.Context, arg UpdateProfileParams) (UpdateProfileRow, error)
+	// Замена хеша после входа со старым алгоритмом; сессии не отзываются.
+	// Условие на старый хеш не даёт перезаписать пароль, сменённый параллельно
+	UpgradePasswordHash(ctx context.Context, arg UpgradePasswordHashParams) (int64, error)
+	UserExists(ctx context.Context, username string) (bool, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -1,14 +1,193 @@
 -- internal/store/queries.sql
 
 -- name: GetUser :one
-SELECT id, username, password_hash, created_at
+SELECT id, username, password_hash, role, status, status_reason, status_changed_at, deleted_at,
+       email, email_verified_at, session_version, password_change_required, created_at, updated_at
 FROM users 
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT id, username, status, email, email_verified_at
+FROM users
+WHERE LOWER(email) = LOWER(sqlc.arg(email)::text) LIMIT 1;
+
 -- name: CreateUser :one
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
-RETURNING id, username, password_hash, created_at;
+INSERT INTO users (username, password_hash, role, status, email, password_change_required)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, username, password_hash, role, created_at;
 
 -- name: UserExists :one
-SELECT EXISTS(SELECT 1 FROM users WHERE username = $1);
\ No newline at end of file
+SELECT EXISTS(SELECT 1 FROM users WHERE username = $1);
+
+-- name: AdminExists :one
+SELECT EXISTS(SELECT 1 FROM users WHERE role = 'admin' AND status <> 'deleted');
+
+-- name: ListUsers :many
+-- Удалённые (soft delete) пользователи возвращаются только при явном фильтре по статусу.
+-- Фильтр по имени - подстрока без шаблонов LIKE: % и _ в нём ищутся как обычные символы
+SELECT id, username, role, status, status_reason, status_changed_at, deleted_at, created_at, updated_at
+FROM users
+WHERE id > sqlc.arg(after_id)
+  AND (sqlc.narg(username_filter)::text IS NULL OR strpos(lower(username), lower(sqlc.narg(username_filter)::text)) > 0)
+  AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role)::text)
+  AND (
+    (sqlc.narg(status)::text IS NULL AND status <> 'deleted')
+    OR status = sqlc.narg(status)::text
+  )
+ORDER BY id
+LIMIT sqlc.arg(page_limit);
+
+-- name: SetUserStatus :execrows
+-- Повторное удаление не сдвигает deleted_at, иначе срок хранения начинается заново
+UPDATE users
+SET status = sqlc.arg(status),
+    status_reason = sqlc.arg(status_reason),
+    status_changed_at = NOW(),
+    deleted_at = CASE WHEN sqlc.arg(status)::text = 'deleted' THEN COALESCE(deleted_at, NOW()) ELSE NULL END,
+    updated_at = NOW()
+WHERE username = sqlc.arg(username);
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE status = 'deleted' AND deleted_at < $1;
+
+-- name: PurgeUnverifiedUsers :execrows
+-- Регистрации, не подтверждённые вовремя: адрес и имя освобождаются для новой регистрации
+DELETE FROM users
+WHERE status = 'pending_verification' AND status_changed_at < $1;
+
+-- name: MarkEmailVerified :execrows
+-- Подтверждение адреса активирует пользователя, только если он ожидал подтверждения
+UPDATE users
+SET email_verified_at = NOW(),
+    status = CASE WHEN status = 'pending_verification' THEN 'active' ELSE status END,
+    status_changed_at = CASE WHEN status = 'pending_verification' THEN NOW() ELSE status_changed_at END,
+    updated_at = NOW()
+WHERE username = $1 AND LOWER(email) = LOWER(sqlc.arg(email)::text);
+
+-- name: GetProfile :one
+SELECT id, username, email, email_verified_at, display_name, locale, timezone, attributes, version, created_at, updated_at
+FROM users
+WHERE username = $1 AND status <> 'deleted' LIMIT 1;
+
+-- name: UpdateProfile :one
+-- Обновление проходит, только если версия не изменилась с момента чтения
+UPDATE users
+SET email = sqlc.narg(email),
+    email_verified_at = CASE WHEN email IS NOT DISTINCT FROM sqlc.narg(email) THEN email_verified_at ELSE NULL END,
+    display_name = sqlc.arg(display_name),
+    locale = sqlc.arg(locale),
+    timezone = sqlc.arg(timezone),
+    attributes = sqlc.arg(attributes),
+    version = version + 1,
+    updated_at = NOW()
+WHERE username = sqlc.arg(username) AND version = sqlc.arg(expected_version) AND status <> 'deleted'
+RETURNING id, username, email, email_verified_at, display_name, locale, timezone, attributes, version, created_at, updated_at;
+
+-- name: SetUserPassword :execrows
+-- Смена пароля отзывает все выданные токены
+UPDATE users
+SET password_hash = sqlc.arg(password_hash),
+    password_change_required = sqlc.arg(password_change_required),
+    session_version = session_version + 1,
+    updated_at = NOW()
+WHERE username = sqlc.arg(username);
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = sqlc.arg(role),
+    updated_at = NOW()
+WHERE username = sqlc.arg(username);
+
+-- name: RevokeUserSessions :execrows
+UPDATE users
+SET session_version = session_version + 1,
+    updated_at = NOW()
+WHERE username = $1;
+
+-- name: UpgradePasswordHash :execrows
+-- Замена хеша после входа со старым алгоритмом; сессии не отзываются.
+-- Условие на старый хеш не даёт перезаписать пароль, сменённый параллельно
+UPDATE users
+SET password_hash = sqlc.arg(new_hash),
+    updated_at = NOW()
+WHERE username = sqlc.arg(username) AND password_hash = sqlc.arg(old_hash);
+
+-- name: ExistingUsernames :many
+SELECT username FROM users
+WHERE username = ANY(sqlc.arg(usernames)::text[]);
+
+-- name: ExistingEmails :many
+-- Адреса возвращаются в нижнем регистре
+SELECT LOWER(email)::text AS email FROM users
+WHERE LOWER(email) = ANY(sqlc.arg(emails)::text[]);
+
+-- name: ImportUsers :copyfrom
+INSERT INTO users (username, password_hash, role, status, email, password_change_required)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ExportUsers :many
+SELECT id, username, password_hash, role, status, email, password_change_required, created_at
+FROM users
+WHERE id > sqlc.arg(after_id) AND status <> 'deleted'
+ORDER BY id
+LIMIT sqlc.arg(page_limit);
+
+-- name: InsertAuditEvent :one
+-- Только для AppendAuditEvent: seq, prev_hash и hash вычисляются под блокировкой головы цепочки
+INSERT INTO audit_events (tenant, seq, prev_hash, hash, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id;
+
+-- name: ListAuditEvents :many
+-- Новые события первыми; курсор - id последнего события предыдущей страницы
+SELECT id, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata, tenant, seq, prev_hash, hash
+FROM audit_events
+WHERE tenant = sqlc.arg(tenant)
+  AND (sqlc.arg(before_id)::bigint = 0 OR id < sqlc.arg(before_id)::bigint)
+  AND (sqlc.narg(event_type)::text IS NULL OR event_type = sqlc.narg(event_type)::text)
+  AND (sqlc.narg(subject)::text IS NULL OR subject = sqlc.narg(subject)::text)
+  AND (sqlc.narg(actor)::text IS NULL OR actor = sqlc.narg(actor)::text)
+  AND (sqlc.narg(outcome)::text IS NULL OR outcome = sqlc.narg(outcome)::text)
+  AND (sqlc.narg(since)::timestamp IS NULL OR occurred_at >= sqlc.narg(since)::timestamp)
+  AND (sqlc.narg(until)::timestamp IS NULL OR occurred_at < sqlc.narg(until)::timestamp)
+ORDER BY id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListAuditChain :many
+-- Записи цепочки тенанта по возрастанию seq (проверка и выгрузка)
+SELECT id, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata, tenant, seq, prev_hash, hash
+FROM audit_events
+WHERE tenant = $1 AND seq > sqlc.arg(after_seq)
+ORDER BY seq
+LIMIT sqlc.arg(page_limit);
+
+-- name: LockAuditChainHead :one
+-- Создаёт голову цепочки при первом событии тенанта и блокирует её строку до конца транзакции
+INSERT INTO audit_chain_heads (tenant)
+VALUES ($1)
+ON CONFLICT (tenant) DO UPDATE SET tenant = EXCLUDED.tenant
+RETURNING seq, hash;
+
+-- name: GetAuditChainHead :one
+SELECT seq, hash
+FROM audit_chain_heads
+WHERE tenant = $1;
+
+-- name: UpdateAuditChainHead :exec
+UPDATE audit_chain_heads
+SET seq = $2, hash = $3
+WHERE tenant = $1;
+
+-- name: GetAuditPurgeAnchor :one
+-- Последняя запись, которую удалит политика хранения
+SELECT seq, hash
+FROM audit_events
+WHERE tenant = $1 AND occurred_at < $2
+ORDER BY seq DESC
+LIMIT 1;
+
+-- name: PurgeAuditEvents :execrows
+-- Удаляется только начало цепочки, поэтому оставшиеся записи продолжают проверяться
+DELETE FROM audit_events
+WHERE tenant = $1 AND seq <= sqlc.arg(max_seq);
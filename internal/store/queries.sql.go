@@ -11,48 +11,270 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const adminExists = `-- name: AdminExists :one
+SELECT EXISTS(SELECT 1 FROM users WHERE role = 'admin' AND status <> 'deleted')
+`
+
+func (q *Queries) AdminExists(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, adminExists)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
-RETURNING id, username, password_hash, created_at
+INSERT INTO users (username, password_hash, role, status, email, password_change_required)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, username, password_hash, role, created_at
 `
 
 type CreateUserParams struct {
-	Username     string `json:"username"`
-	PasswordHash string `json:"password_hash"`
+	Username               string      `json:"username"`
+	PasswordHash           string      `json:"password_hash"`
+	Role                   string      `json:"role"`
+	Status                 string      `json:"status"`
+	Email                  pgtype.Text `json:"email"`
+	PasswordChangeRequired bool        `json:"password_change_required"`
 }
 
 type CreateUserRow struct {
 	ID           int32            `json:"id"`
 	Username     string           `json:"username"`
 	PasswordHash string           `json:"password_hash"`
+	Role         string           `json:"role"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.PasswordHash)
+	row := q.db.QueryRow(ctx, createUser,
+		arg.Username,
+		arg.PasswordHash,
+		arg.Role,
+		arg.Status,
+		arg.Email,
+		arg.PasswordChangeRequired,
+	)
 	var i CreateUserRow
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const existingEmails = `-- name: ExistingEmails :many
+SELECT LOWER(email)::text AS email FROM users
+WHERE LOWER(email) = ANY($1::text[])
+`
+
+// Адреса возвращаются в нижнем регистре
+func (q *Queries) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, existingEmails, emails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		items = append(items, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const existingUsernames = `-- name: ExistingUsernames :many
+SELECT username FROM users
+WHERE username = ANY($1::text[])
+`
+
+func (q *Queries) ExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, existingUsernames, usernames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		items = append(items, username)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUsers = `-- name: ExportUsers :many
+SELECT id, username, password_hash, role, status, email, password_change_required, created_at
+FROM users
+WHERE id > $1 AND status <> 'deleted'
+ORDER BY id
+LIMIT $2
+`
+
+type ExportUsersParams struct {
+	AfterID   int32 `json:"after_id"`
+	PageLimit int32 `json:"page_limit"`
+}
+
+type ExportUsersRow struct {
+	ID                     int32            `json:"id"`
+	Username               string           `json:"username"`
+	PasswordHash           string           `json:"password_hash"`
+	Role                   string           `json:"role"`
+	Status                 string           `json:"status"`
+	Email                  pgtype.Text      `json:"email"`
+	PasswordChangeRequired bool             `json:"password_change_required"`
+	CreatedAt              pgtype.Timestamp `json:"created_at"`
+}
+
+func (q *Queries) ExportUsers(ctx context.Context, arg ExportUsersParams) ([]ExportUsersRow, error) {
+	rows, err := q.db.Query(ctx, exportUsers, arg.AfterID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportUsersRow
+	for rows.Next() {
+		var i ExportUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.Role,
+			&i.Status,
+			&i.Email,
+			&i.PasswordChangeRequired,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditChainHead = `-- name: GetAuditChainHead :one
+SELECT seq, hash
+FROM audit_chain_heads
+WHERE tenant = $1
+`
+
+type GetAuditChainHeadRow struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+func (q *Queries) GetAuditChainHead(ctx context.Context, tenant string) (GetAuditChainHeadRow, error) {
+	row := q.db.QueryRow(ctx, getAuditChainHead, tenant)
+	var i GetAuditChainHeadRow
+	err := row.Scan(&i.Seq, &i.Hash)
+	return i, err
+}
+
+const getAuditPurgeAnchor = `-- name: GetAuditPurgeAnchor :one
+SELECT seq, hash
+FROM audit_events
+WHERE tenant = $1 AND occurred_at < $2
+ORDER BY seq DESC
+LIMIT 1
+`
+
+type GetAuditPurgeAnchorParams struct {
+	Tenant     string           `json:"tenant"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+}
+
+type GetAuditPurgeAnchorRow struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Последняя запись, которую удалит политика хранения
+func (q *Queries) GetAuditPurgeAnchor(ctx context.Context, arg GetAuditPurgeAnchorParams) (GetAuditPurgeAnchorRow, error) {
+	row := q.db.QueryRow(ctx, getAuditPurgeAnchor, arg.Tenant, arg.OccurredAt)
+	var i GetAuditPurgeAnchorRow
+	err := row.Scan(&i.Seq, &i.Hash)
+	return i, err
+}
+
+const getProfile = `-- name: GetProfile :one
+SELECT id, username, email, email_verified_at, display_name, locale, timezone, attributes, version, created_at, updated_at
+FROM users
+WHERE username = $1 AND status <> 'deleted' LIMIT 1
+`
+
+type GetProfileRow struct {
+	ID              int32            `json:"id"`
+	Username        string           `json:"username"`
+	Email           pgtype.Text      `json:"email"`
+	EmailVerifiedAt pgtype.Timestamp `json:"email_verified_at"`
+	DisplayName     string           `json:"display_name"`
+	Locale          string           `json:"locale"`
+	Timezone        string           `json:"timezone"`
+	Attributes      []byte           `json:"attributes"`
+	Version         int32            `json:"version"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
+}
+
+func (q *Queries) GetProfile(ctx context.Context, username string) (GetProfileRow, error) {
+	row := q.db.QueryRow(ctx, getProfile, username)
+	var i GetProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Locale,
+		&i.Timezone,
+		&i.Attributes,
+		&i.Version,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
 
-SELECT id, username, password_hash, created_at
+SELECT id, username, password_hash, role, status, status_reason, status_changed_at, deleted_at,
+       email, email_verified_at, session_version, password_change_required, created_at, updated_at
 FROM users 
 WHERE username = $1 LIMIT 1
 `
 
 type GetUserRow struct {
-	ID           int32            `json:"id"`
-	Username     string           `json:"username"`
-	PasswordHash string           `json:"password_hash"`
-	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	ID                     int32            `json:"id"`
+	Username               string           `json:"username"`
+	PasswordHash           string           `json:"password_hash"`
+	Role                   string           `json:"role"`
+	Status                 string           `json:"status"`
+	StatusReason           string           `json:"status_reason"`
+	StatusChangedAt        pgtype.Timestamp `json:"status_changed_at"`
+	DeletedAt              pgtype.Timestamp `json:"deleted_at"`
+	Email                  pgtype.Text      `json:"email"`
+	EmailVerifiedAt        pgtype.Timestamp `json:"email_verified_at"`
+	SessionVersion         int32            `json:"session_version"`
+	PasswordChangeRequired bool             `json:"password_change_required"`
+	CreatedAt              pgtype.Timestamp `json:"created_at"`
+	UpdatedAt              pgtype.Timestamp `json:"updated_at"`
 }
 
 // internal/store/queries.sql
@@ -63,11 +285,575 @@ func (q *Queries) GetUser(ctx context.Context, username string) (GetUserRow, err
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
+		&i.Role,
+		&i.Status,
+		&i.StatusReason,
+		&i.StatusChangedAt,
+		&i.DeletedAt,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.SessionVersion,
+		&i.PasswordChangeRequired,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, status, email, email_verified_at
+FROM users
+WHERE LOWER(email) = LOWER($1::text) LIMIT 1
+`
+
+type GetUserByEmailRow struct {
+	ID              int32            `json:"id"`
+	Username        string           `json:"username"`
+	Status          string           `json:"status"`
+	Email           pgtype.Text      `json:"email"`
+	EmailVerifiedAt pgtype.Timestamp `json:"email_verified_at"`
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i GetUserByEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Status,
+		&i.Email,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+type ImportUsersParams struct {
+	Username               string      `json:"username"`
+	PasswordHash           string      `json:"password_hash"`
+	Role                   string      `json:"role"`
+	Status                 string      `json:"status"`
+	Email                  pgtype.Text `json:"email"`
+	PasswordChangeRequired bool        `json:"password_change_required"`
+}
+
+const insertAuditEvent = `-- name: InsertAuditEvent :one
+INSERT INTO audit_events (tenant, seq, prev_hash, hash, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id
+`
+
+type InsertAuditEventParams struct {
+	Tenant     string           `json:"tenant"`
+	Seq        int64            `json:"seq"`
+	PrevHash   string           `json:"prev_hash"`
+	Hash       string           `json:"hash"`
+	OccurredAt pgtype.Timestamp `json:"occurred_at"`
+	EventType  string           `json:"event_type"`
+	Outcome    string           `json:"outcome"`
+	Actor      string           `json:"actor"`
+	Subject    string           `json:"subject"`
+	Ip         string           `json:"ip"`
+	UserAgent  string           `json:"user_agent"`
+	RequestID  string           `json:"request_id"`
+	Metadata   []byte           `json:"metadata"`
+}
+
+// Только для AppendAuditEvent: seq, prev_hash и hash вычисляются под блокировкой головы цепочки
+func (q *Queries) InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) (int64, error) {
+	row := q.db.QueryRow(ctx, insertAuditEvent,
+		arg.Tenant,
+		arg.Seq,
+		arg.PrevHash,
+		arg.Hash,
+		arg.OccurredAt,
+		arg.EventType,
+		arg.Outcome,
+		arg.Actor,
+		arg.Subject,
+		arg.Ip,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Metadata,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listAuditChain = `-- name: ListAuditChain :many
+SELECT id, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata, tenant, seq, prev_hash, hash
+FROM audit_events
+WHERE tenant = $1 AND seq > $2
+ORDER BY seq
+LIMIT $3
+`
+
+type ListAuditChainParams struct {
+	Tenant    string `json:"tenant"`
+	AfterSeq  int64  `json:"after_seq"`
+	PageLimit int32  `json:"page_limit"`
+}
+
+// Записи цепочки тенанта по возрастанию seq (проверка и выгрузка)
+func (q *Queries) ListAuditChain(ctx context.Context, arg ListAuditChainParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditChain, arg.Tenant, arg.AfterSeq, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.EventType,
+			&i.Outcome,
+			&i.Actor,
+			&i.Subject,
+			&i.Ip,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Metadata,
+			&i.Tenant,
+			&i.Seq,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata, tenant, seq, prev_hash, hash
+FROM audit_events
+WHERE tenant = $1
+  AND ($2::bigint = 0 OR id < $2::bigint)
+  AND ($3::text IS NULL OR event_type = $3::text)
+  AND ($4::text IS NULL OR subject = $4::text)
+  AND ($5::text IS NULL OR actor = $5::text)
+  AND ($6::text IS NULL OR outcome = $6::text)
+  AND ($7::timestamp IS NULL OR occurred_at >= $7::timestamp)
+  AND ($8::timestamp IS NULL OR occurred_at < $8::timestamp)
+ORDER BY id DESC
+LIMIT $9
+`
+
+type ListAuditEventsParams struct {
+	Tenant    string           `json:"tenant"`
+	BeforeID  int64            `json:"before_id"`
+	EventType pgtype.Text      `json:"event_type"`
+	Subject   pgtype.Text      `json:"subject"`
+	Actor     pgtype.Text      `json:"actor"`
+	Outcome   pgtype.Text      `json:"outcome"`
+	Since     pgtype.Timestamp `json:"since"`
+	Until     pgtype.Timestamp `json:"until"`
+	PageLimit int32            `json:"page_limit"`
+}
+
+// Новые события первыми; курсор - id последнего события предыдущей страницы
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.Tenant,
+		arg.BeforeID,
+		arg.EventType,
+		arg.Subject,
+		arg.Actor,
+		arg.Outcome,
+		arg.Since,
+		arg.Until,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.EventType,
+			&i.Outcome,
+			&i.Actor,
+			&i.Subject,
+			&i.Ip,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Metadata,
+			&i.Tenant,
+			&i.Seq,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, role, status, status_reason, status_changed_at, deleted_at, created_at, updated_at
+FROM users
+WHERE id > $1
+  AND ($2::text IS NULL OR strpos(lower(username), lower($2::text)) > 0)
+  AND ($3::text IS NULL OR role = $3::text)
+  AND (
+    ($4::text IS NULL AND status <> 'deleted')
+    OR status = $4::text
+  )
+ORDER BY id
+LIMIT $5
+`
+
+type ListUsersParams struct {
+	AfterID        int32       `json:"after_id"`
+	UsernameFilter pgtype.Text `json:"username_filter"`
+	Role           pgtype.Text `json:"role"`
+	Status         pgtype.Text `json:"status"`
+	PageLimit      int32       `json:"page_limit"`
+}
+
+type ListUsersRow struct {
+	ID              int32            `json:"id"`
+	Username        string           `json:"username"`
+	Role            string           `json:"role"`
+	Status          string           `json:"status"`
+	StatusReason    string           `json:"status_reason"`
+	StatusChangedAt pgtype.Timestamp `json:"status_changed_at"`
+	DeletedAt       pgtype.Timestamp `json:"deleted_at"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
+}
+
+// Удалённые (soft delete) пользователи возвращаются только при явном фильтре по статусу.
+// Фильтр по имени - подстрока без шаблонов LIKE: % и _ в нём ищутся как обычные символы
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.AfterID,
+		arg.UsernameFilter,
+		arg.Role,
+		arg.Status,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Role,
+			&i.Status,
+			&i.StatusReason,
+			&i.StatusChangedAt,
+			&i.DeletedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAuditChainHead = `-- name: LockAuditChainHead :one
+INSERT INTO audit_chain_heads (tenant)
+VALUES ($1)
+ON CONFLICT (tenant) DO UPDATE SET tenant = EXCLUDED.tenant
+RETURNING seq, hash
+`
+
+type LockAuditChainHeadRow struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Создаёт голову цепочки при первом событии тенанта и блокирует её строку до конца транзакции
+func (q *Queries) LockAuditChainHead(ctx context.Context, tenant string) (LockAuditChainHeadRow, error) {
+	row := q.db.QueryRow(ctx, lockAuditChainHead, tenant)
+	var i LockAuditChainHeadRow
+	err := row.Scan(&i.Seq, &i.Hash)
+	return i, err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :execrows
+UPDATE users
+SET email_verified_at = NOW(),
+    status = CASE WHEN status = 'pending_verification' THEN 'active' ELSE status END,
+    status_changed_at = CASE WHEN status = 'pending_verification' THEN NOW() ELSE status_changed_at END,
+    updated_at = NOW()
+WHERE username = $1 AND LOWER(email) = LOWER($2::text)
+`
+
+type MarkEmailVerifiedParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// Подтверждение адреса активирует пользователя, только если он ожидал подтверждения
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markEmailVerified, arg.Username, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeAuditEvents = `-- name: PurgeAuditEvents :execrows
+DELETE FROM audit_events
+WHERE tenant = $1 AND seq <= $2
+`
+
+type PurgeAuditEventsParams struct {
+	Tenant string `json:"tenant"`
+	MaxSeq int64  `json:"max_seq"`
+}
+
+// Удаляется только начало цепочки, поэтому оставшиеся записи продолжают проверяться
+func (q *Queries) PurgeAuditEvents(ctx context.Context, arg PurgeAuditEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeAuditEvents, arg.Tenant, arg.MaxSeq)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE status = 'deleted' AND deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedAt pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedUsers, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeUnverifiedUsers = `-- name: PurgeUnverifiedUsers :execrows
+DELETE FROM users
+WHERE status = 'pending_verification' AND status_changed_at < $1
+`
+
+// Регистрации, не подтверждённые вовремя: адрес и имя освобождаются для новой регистрации
+func (q *Queries) PurgeUnverifiedUsers(ctx context.Context, statusChangedAt pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeUnverifiedUsers, statusChangedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :execrows
+UPDATE users
+SET session_version = session_version + 1,
+    updated_at = NOW()
+WHERE username = $1
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, username string) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserSessions, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserPassword = `-- name: SetUserPassword :execrows
+UPDATE users
+SET password_hash = $1,
+    password_change_required = $2,
+    session_version = session_version + 1,
+    updated_at = NOW()
+WHERE username = $3
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash           string `json:"password_hash"`
+	PasswordChangeRequired bool   `json:"password_change_required"`
+	Username               string `json:"username"`
+}
+
+// Смена пароля отзывает все выданные токены
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserPassword, arg.PasswordHash, arg.PasswordChangeRequired, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role = $1,
+    updated_at = NOW()
+WHERE username = $2
+`
+
+type SetUserRoleParams struct {
+	Role     string `json:"role"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserRole, arg.Role, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserStatus = `-- name: SetUserStatus :execrows
+UPDATE users
+SET status = $1,
+    status_reason = $2,
+    status_changed_at = NOW(),
+    deleted_at = CASE WHEN $1::text = 'deleted' THEN COALESCE(deleted_at, NOW()) ELSE NULL END,
+    updated_at = NOW()
+WHERE username = $3
+`
+
+type SetUserStatusParams struct {
+	Status       string `json:"status"`
+	StatusReason string `json:"status_reason"`
+	Username     string `json:"username"`
+}
+
+// Повторное удаление не сдвигает deleted_at, иначе срок хранения начинается заново
+func (q *Queries) SetUserStatus(ctx context.Context, arg SetUserStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserStatus, arg.Status, arg.StatusReason, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateAuditChainHead = `-- name: UpdateAuditChainHead :exec
+UPDATE audit_chain_heads
+SET seq = $2, hash = $3
+WHERE tenant = $1
+`
+
+type UpdateAuditChainHeadParams struct {
+	Tenant string `json:"tenant"`
+	Seq    int64  `json:"seq"`
+	Hash   string `json:"hash"`
+}
+
+func (q *Queries) UpdateAuditChainHead(ctx context.Context, arg UpdateAuditChainHeadParams) error {
+	_, err := q.db.Exec(ctx, updateAuditChainHead, arg.Tenant, arg.Seq, arg.Hash)
+	return err
+}
+
+const updateProfile = `-- name: UpdateProfile :one
+UPDATE users
+SET email = $1,
+    email_verified_at = CASE WHEN email IS NOT DISTINCT FROM $1 THEN email_verified_at ELSE NULL END,
+    display_name = $2,
+    locale = $3,
+    timezone = $4,
+    attributes = $5,
+    version = version + 1,
+    updated_at = NOW()
+WHERE username = $6 AND version = $7 AND status <> 'deleted'
+RETURNING id, username, email, email_verified_at, display_name, locale, timezone, attributes, version, created_at, updated_at
+`
+
+type UpdateProfileParams struct {
+	Email           pgtype.Text `json:"email"`
+	DisplayName     string      `json:"display_name"`
+	Locale          string      `json:"locale"`
+	Timezone        string      `json:"timezone"`
+	Attributes      []byte      `json:"attributes"`
+	Username        string      `json:"username"`
+	ExpectedVersion int32       `json:"expected_version"`
+}
+
+type UpdateProfileRow struct {
+	ID              int32            `json:"id"`
+	Username        string           `json:"username"`
+	Email           pgtype.Text      `json:"email"`
+	EmailVerifiedAt pgtype.Timestamp `json:"email_verified_at"`
+	DisplayName     string           `json:"display_name"`
+	Locale          string           `json:"locale"`
+	Timezone        string           `json:"timezone"`
+	Attributes      []byte           `json:"attributes"`
+	Version         int32            `json:"version"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	UpdatedAt       pgtype.Timestamp `json:"updated_at"`
+}
+
+// Обновление проходит, только если версия не изменилась с момента чтения
+func (q *Queries) UpdateProfile(ctx context.Context, arg UpdateProfileParams) (UpdateProfileRow, error) {
+	row := q.db.QueryRow(ctx, updateProfile,
+		arg.Email,
+		arg.DisplayName,
+		arg.Locale,
+		arg.Timezone,
+		arg.Attributes,
+		arg.Username,
+		arg.ExpectedVersion,
+	)
+	var i UpdateProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Locale,
+		&i.Timezone,
+		&i.Attributes,
+		&i.Version,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const upgradePasswordHash = `-- name: UpgradePasswordHash :execrows
+UPDATE users
+SET password_hash = $1,
+    updated_at = NOW()
+WHERE username = $2 AND password_hash = $3
+`
+
+type UpgradePasswordHashParams struct {
+	NewHash  string `json:"new_hash"`
+	Username string `json:"username"`
+	OldHash  string `json:"old_hash"`
+}
+
+// Замена хеша после входа со старым алгоритмом; сессии не отзываются.
+// Условие на старый хеш не даёт перезаписать пароль, сменённый параллельно
+func (q *Queries) UpgradePasswordHash(ctx context.Context, arg UpgradePasswordHashParams) (int64, error) {
+	result, err := q.db.Exec(ctx, upgradePasswordHash, arg.NewHash, arg.Username, arg.OldHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const userExists = `-- name: UserExists :one
 SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)
 `
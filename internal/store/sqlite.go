@@ -0,0 +1,725 @@
+package store
+
+import (
+	"auth_test/internal/auditchain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeFormat совпадает с strftime('%Y-%m-%d %H:%M:%f') в миграциях: строки сравниваются как даты
+const sqliteTimeFormat = "2006-01-02 15:04:05.000"
+
+// SQLiteStore - хранилище на SQLite (pure-Go драйвер, без cgo) для edge-развёртываний и локальной разработки.
+// Повторяет семантику запросов из queries.sql и возвращает те же типы строк, что и PostgresStore.
+// Схема создаётся встроенными миграциями (migrate.NewSQLite)
+type SQLiteStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
+	dsn := "file:" + dbPath + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// SQLite допускает одного писателя: одно соединение избавляет от SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database ping failed: %w", err)
+	}
+
+	return &SQLiteStore{
+		db:  db,
+		now: time.Now,
+	}, nil
+}
+
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteStore) Close() {
+	s.db.Close()
+}
+
+func (s *SQLiteStore) GetDB() *sql.DB {
+	return s.db
+}
+
+const sqliteCreateUser = `INSERT INTO users (username, password_hash, role, status, email, password_change_required, created_at, updated_at, status_changed_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?7, ?7)
+RETURNING id, username, password_hash, role, created_at`
+
+func (s *SQLiteStore) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
+	row := s.db.QueryRowContext(ctx, sqliteCreateUser,
+		arg.Username,
+		arg.PasswordHash,
+		arg.Role,
+		arg.Status,
+		nullText(arg.Email),
+		arg.PasswordChangeRequired,
+		s.timestamp(),
+	)
+
+	var i CreateUserRow
+	var createdAt sql.NullString
+	if err := row.Scan(&i.ID, &i.Username, &i.PasswordHash, &i.Role, &createdAt); err != nil {
+		return i, mapSQLiteError(err)
+	}
+	i.CreatedAt = parseSQLiteTime(createdAt)
+	return i, nil
+}
+
+const sqliteGetUser = `SELECT id, username, password_hash, role, status, status_reason, status_changed_at, deleted_at,
+       email, email_verified_at, session_version, password_change_required, created_at, updated_at
+FROM users
+WHERE username = ?1 LIMIT 1`
+
+func (s *SQLiteStore) GetUser(ctx context.Context, username string) (GetUserRow, error) {
+	row := s.db.QueryRowContext(ctx, sqliteGetUser, username)
+
+	var i GetUserRow
+	var statusChangedAt, deletedAt, email, emailVerifiedAt, createdAt, updatedAt sql.NullString
+	if err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.Role,
+		&i.Status,
+		&i.StatusReason,
+		&statusChangedAt,
+		&deletedAt,
+		&email,
+		&emailVerifiedAt,
+		&i.SessionVersion,
+		&i.PasswordChangeRequired,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return i, mapSQLiteError(err)
+	}
+	i.StatusChangedAt = parseSQLiteTime(statusChangedAt)
+	i.DeletedAt = parseSQLiteTime(deletedAt)
+	i.Email = pgText(email)
+	i.EmailVerifiedAt = parseSQLiteTime(emailVerifiedAt)
+	i.CreatedAt = parseSQLiteTime(createdAt)
+	i.UpdatedAt = parseSQLiteTime(updatedAt)
+	return i, nil
+}
+
+const sqliteGetUserByEmail = `SELECT id, username, status, email, email_verified_at
+FROM users
+WHERE LOWER(email) = LOWER(?1) LIMIT 1`
+
+func (s *SQLiteStore) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
+	row := s.db.QueryRowContext(ctx, sqliteGetUserByEmail, email)
+
+	var i GetUserByEmailRow
+	var emailValue, emailVerifiedAt sql.NullString
+	if err := row.Scan(&i.ID, &i.Username, &i.Status, &emailValue, &emailVerifiedAt); err != nil {
+		return i, mapSQLiteError(err)
+	}
+	i.Email = pgText(emailValue)
+	i.EmailVerifiedAt = parseSQLiteTime(emailVerifiedAt)
+	return i, nil
+}
+
+func (s *SQLiteStore) UserExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username = ?1)", username).Scan(&exists)
+	return exists, err
+}
+
+func (s *SQLiteStore) AdminExists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE role = 'admin' AND status <> 'deleted')").Scan(&exists)
+	return exists, err
+}
+
+// instr вместо LIKE: % и _ в фильтре не являются шаблонами, как и в strpos в Postgres
+const sqliteListUsers = `SELECT id, username, role, status, status_reason, status_changed_at, deleted_at, created_at, updated_at
+FROM users
+WHERE id > ?1
+  AND (?2 IS NULL OR instr(lower(username), lower(?2)) > 0)
+  AND (?3 IS NULL OR role = ?3)
+  AND (
+    (?4 IS NULL AND status <> 'deleted')
+    OR status = ?4
+  )
+ORDER BY id
+LIMIT ?5`
+
+func (s *SQLiteStore) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteListUsers,
+		arg.AfterID,
+		nullText(arg.UsernameFilter),
+		nullText(arg.Role),
+		nullText(arg.Status),
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		var statusChangedAt, deletedAt, createdAt, updatedAt sql.NullString
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Role,
+			&i.Status,
+			&i.StatusReason,
+			&statusChangedAt,
+			&deletedAt,
+			&createdAt,
+			&updatedAt,
+		); err != nil {
+			return nil, err
+		}
+		i.StatusChangedAt = parseSQLiteTime(statusChangedAt)
+		i.DeletedAt = parseSQLiteTime(deletedAt)
+		i.CreatedAt = parseSQLiteTime(createdAt)
+		i.UpdatedAt = parseSQLiteTime(updatedAt)
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteSetUserStatus = `UPDATE users
+SET status = ?1,
+    status_reason = ?2,
+    status_changed_at = ?4,
+    deleted_at = CASE WHEN ?1 = 'deleted' THEN COALESCE(deleted_at, ?4) ELSE NULL END,
+    updated_at = ?4
+WHERE username = ?3`
+
+func (s *SQLiteStore) SetUserStatus(ctx context.Context, arg SetUserStatusParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx, sqliteSetUserStatus, arg.Status, arg.StatusReason, arg.Username, s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) PurgeDeletedUsers(ctx context.Context, deletedAt pgtype.Timestamp) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE status = 'deleted' AND deleted_at < ?1", formatSQLiteTime(deletedAt.Time))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) PurgeUnverifiedUsers(ctx context.Context, statusChangedAt pgtype.Timestamp) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE status = 'pending_verification' AND status_changed_at < ?1", formatSQLiteTime(statusChangedAt.Time))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sqliteMarkEmailVerified = `UPDATE users
+SET email_verified_at = ?3,
+    status = CASE WHEN status = 'pending_verification' THEN 'active' ELSE status END,
+    status_changed_at = CASE WHEN status = 'pending_verification' THEN ?3 ELSE status_changed_at END,
+    updated_at = ?3
+WHERE username = ?1 AND LOWER(email) = LOWER(?2)`
+
+func (s *SQLiteStore) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx, sqliteMarkEmailVerified, arg.Username, arg.Email, s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE users SET password_hash = ?1, password_change_required = ?4, session_version = session_version + 1, updated_at = ?3 WHERE username = ?2",
+		arg.PasswordHash, arg.Username, s.timestamp(), arg.PasswordChangeRequired)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE users SET role = ?1, updated_at = ?3 WHERE username = ?2",
+		arg.Role, arg.Username, s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) RevokeUserSessions(ctx context.Context, username string) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE users SET session_version = session_version + 1, updated_at = ?2 WHERE username = ?1",
+		username, s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) UpgradePasswordHash(ctx context.Context, arg UpgradePasswordHashParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE users SET password_hash = ?1, updated_at = ?4 WHERE username = ?2 AND password_hash = ?3",
+		arg.NewHash, arg.Username, arg.OldHash, s.timestamp())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Массив передаётся JSON строкой и разворачивается json_each вместо = ANY($1) в Postgres
+func (s *SQLiteStore) ExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	return s.queryStrings(ctx, "SELECT username FROM users WHERE username IN (SELECT value FROM json_each(?1))", usernames)
+}
+
+func (s *SQLiteStore) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	return s.queryStrings(ctx, "SELECT LOWER(email) FROM users WHERE LOWER(email) IN (SELECT value FROM json_each(?1))", emails)
+}
+
+func (s *SQLiteStore) queryStrings(ctx context.Context, query string, values []string) ([]string, error) {
+	arg, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, string(arg))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, rows.Err()
+}
+
+// ImportUsers вставляет пачку в одной транзакции: как и COPY в Postgres, ошибка в любой строке отменяет всю пачку
+func (s *SQLiteStore) ImportUsers(ctx context.Context, arg []ImportUsersParams) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO users (username, password_hash, role, status, email, password_change_required, created_at, updated_at, status_changed_at)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?7, ?7)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	now := s.timestamp()
+	for _, row := range arg {
+		if _, err := stmt.ExecContext(ctx,
+			row.Username,
+			row.PasswordHash,
+			row.Role,
+			row.Status,
+			nullText(row.Email),
+			row.PasswordChangeRequired,
+			now,
+		); err != nil {
+			return 0, mapSQLiteError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(arg)), nil
+}
+
+const sqliteExportUsers = `SELECT id, username, password_hash, role, status, email, password_change_required, created_at
+FROM users
+WHERE id > ?1 AND status <> 'deleted'
+ORDER BY id
+LIMIT ?2`
+
+func (s *SQLiteStore) ExportUsers(ctx context.Context, arg ExportUsersParams) ([]ExportUsersRow, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteExportUsers, arg.AfterID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ExportUsersRow
+	for rows.Next() {
+		var i ExportUsersRow
+		var email, createdAt sql.NullString
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.Role,
+			&i.Status,
+			&email,
+			&i.PasswordChangeRequired,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		i.Email = pgText(email)
+		i.CreatedAt = parseSQLiteTime(createdAt)
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// sqliteExecer - *sql.DB или *sql.Tx
+type sqliteExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *SQLiteStore) AppendAuditEvent(ctx context.Context, arg InsertAuditEventParams) (AuditEvent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return AuditEvent{}, err
+	}
+	defer tx.Rollback()
+
+	// Первый оператор транзакции - запись: SQLite сразу берёт блокировку на запись,
+	// и другой процесс (например, CLI) не вклинится между чтением головы и вставкой
+	head, err := lockSQLiteAuditChainHead(ctx, tx, arg.Tenant)
+	if err != nil {
+		return AuditEvent{}, fmt.Errorf("lock audit chain: %w", err)
+	}
+
+	if !arg.OccurredAt.Valid {
+		arg.OccurredAt = pgtype.Timestamp{Time: s.now(), Valid: true}
+	}
+	if err := sealAuditEvent(&arg, auditchain.Head{Seq: head.Seq, Hash: head.Hash}); err != nil {
+		return AuditEvent{}, err
+	}
+
+	id, err := insertSQLiteAuditEvent(ctx, tx, arg)
+	if err != nil {
+		return AuditEvent{}, err
+	}
+	if _, err := tx.ExecContext(ctx, sqliteUpdateAuditChainHead, arg.Tenant, arg.Seq, arg.Hash); err != nil {
+		return AuditEvent{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return AuditEvent{}, err
+	}
+	return auditEventFromParams(id, arg), nil
+}
+
+const sqliteInsertAuditEvent = `INSERT INTO audit_events (tenant, seq, prev_hash, hash, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)
+RETURNING id`
+
+func (s *SQLiteStore) InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) (int64, error) {
+	if !arg.OccurredAt.Valid {
+		arg.OccurredAt = pgtype.Timestamp{Time: s.now(), Valid: true}
+	}
+	return insertSQLiteAuditEvent(ctx, s.db, arg)
+}
+
+func insertSQLiteAuditEvent(ctx context.Context, db sqliteExecer, arg InsertAuditEventParams) (int64, error) {
+	metadata := string(arg.Metadata)
+	if metadata == "" {
+		metadata = "{}"
+	}
+
+	var id int64
+	err := db.QueryRowContext(ctx, sqliteInsertAuditEvent,
+		arg.Tenant,
+		arg.Seq,
+		arg.PrevHash,
+		arg.Hash,
+		formatSQLiteTime(arg.OccurredAt.Time),
+		arg.EventType,
+		arg.Outcome,
+		arg.Actor,
+		arg.Subject,
+		arg.Ip,
+		arg.UserAgent,
+		arg.RequestID,
+		metadata,
+	).Scan(&id)
+	return id, err
+}
+
+const sqliteAuditEventColumns = `id, occurred_at, event_type, outcome, actor, subject, ip, user_agent, request_id, metadata, tenant, seq, prev_hash, hash`
+
+const sqliteListAuditEvents = `SELECT ` + sqliteAuditEventColumns + `
+FROM audit_events
+WHERE tenant = ?1
+  AND (?2 = 0 OR id < ?2)
+  AND (?3 IS NULL OR event_type = ?3)
+  AND (?4 IS NULL OR subject = ?4)
+  AND (?5 IS NULL OR actor = ?5)
+  AND (?6 IS NULL OR outcome = ?6)
+  AND (?7 IS NULL OR occurred_at >= ?7)
+  AND (?8 IS NULL OR occurred_at < ?8)
+ORDER BY id DESC
+LIMIT ?9`
+
+func (s *SQLiteStore) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteListAuditEvents,
+		arg.Tenant,
+		arg.BeforeID,
+		nullText(arg.EventType),
+		nullText(arg.Subject),
+		nullText(arg.Actor),
+		nullText(arg.Outcome),
+		nullTime(arg.Since),
+		nullTime(arg.Until),
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanSQLiteAuditEvents(rows)
+}
+
+const sqliteListAuditChain = `SELECT ` + sqliteAuditEventColumns + `
+FROM audit_events
+WHERE tenant = ?1 AND seq > ?2
+ORDER BY seq
+LIMIT ?3`
+
+func (s *SQLiteStore) ListAuditChain(ctx context.Context, arg ListAuditChainParams) ([]AuditEvent, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteListAuditChain, arg.Tenant, arg.AfterSeq, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSQLiteAuditEvents(rows)
+}
+
+func scanSQLiteAuditEvents(rows *sql.Rows) ([]AuditEvent, error) {
+	defer rows.Close()
+
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		var occurredAt sql.NullString
+		var metadata string
+		if err := rows.Scan(
+			&i.ID,
+			&occurredAt,
+			&i.EventType,
+			&i.Outcome,
+			&i.Actor,
+			&i.Subject,
+			&i.Ip,
+			&i.UserAgent,
+			&i.RequestID,
+			&metadata,
+			&i.Tenant,
+			&i.Seq,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		i.OccurredAt = parseSQLiteTime(occurredAt)
+		i.Metadata = []byte(metadata)
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteLockAuditChainHead = `INSERT INTO audit_chain_heads (tenant)
+VALUES (?1)
+ON CONFLICT (tenant) DO UPDATE SET tenant = excluded.tenant
+RETURNING seq, hash`
+
+func (s *SQLiteStore) LockAuditChainHead(ctx context.Context, tenant string) (LockAuditChainHeadRow, error) {
+	return lockSQLiteAuditChainHead(ctx, s.db, tenant)
+}
+
+func lockSQLiteAuditChainHead(ctx context.Context, db sqliteExecer, tenant string) (LockAuditChainHeadRow, error) {
+	var i LockAuditChainHeadRow
+	err := db.QueryRowContext(ctx, sqliteLockAuditChainHead, tenant).Scan(&i.Seq, &i.Hash)
+	return i, err
+}
+
+func (s *SQLiteStore) GetAuditChainHead(ctx context.Context, tenant string) (GetAuditChainHeadRow, error) {
+	var i GetAuditChainHeadRow
+	err := s.db.QueryRowContext(ctx, "SELECT seq, hash FROM audit_chain_heads WHERE tenant = ?1", tenant).Scan(&i.Seq, &i.Hash)
+	return i, mapSQLiteError(err)
+}
+
+const sqliteUpdateAuditChainHead = `UPDATE audit_chain_heads SET seq = ?2, hash = ?3 WHERE tenant = ?1`
+
+func (s *SQLiteStore) UpdateAuditChainHead(ctx context.Context, arg UpdateAuditChainHeadParams) error {
+	_, err := s.db.ExecContext(ctx, sqliteUpdateAuditChainHead, arg.Tenant, arg.Seq, arg.Hash)
+	return err
+}
+
+const sqliteGetAuditPurgeAnchor = `SELECT seq, hash
+FROM audit_events
+WHERE tenant = ?1 AND occurred_at < ?2
+ORDER BY seq DESC
+LIMIT 1`
+
+func (s *SQLiteStore) GetAuditPurgeAnchor(ctx context.Context, arg GetAuditPurgeAnchorParams) (GetAuditPurgeAnchorRow, error) {
+	var i GetAuditPurgeAnchorRow
+	err := s.db.QueryRowContext(ctx, sqliteGetAuditPurgeAnchor, arg.Tenant, formatSQLiteTime(arg.OccurredAt.Time)).Scan(&i.Seq, &i.Hash)
+	return i, mapSQLiteError(err)
+}
+
+func (s *SQLiteStore) PurgeAuditEvents(ctx context.Context, arg PurgeAuditEventsParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM audit_events WHERE tenant = ?1 AND seq <= ?2", arg.Tenant, arg.MaxSeq)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sqliteGetProfile = `SELECT id, username, email, email_verified_at, display_name, locale, timezone, attributes, version, created_at, updated_at
+FROM users
+WHERE username = ?1 AND status <> 'deleted' LIMIT 1`
+
+func (s *SQLiteStore) GetProfile(ctx context.Context, username string) (GetProfileRow, error) {
+	i, err := scanSQLiteProfile(s.db.QueryRowContext(ctx, sqliteGetProfile, username))
+	return GetProfileRow(i), err
+}
+
+// Оператор IS в SQLite сравнивает с учётом NULL, как IS NOT DISTINCT FROM в Postgres
+const sqliteUpdateProfile = `UPDATE users
+SET email = ?1,
+    email_verified_at = CASE WHEN email IS ?1 THEN email_verified_at ELSE NULL END,
+    display_name = ?2,
+    locale = ?3,
+    timezone = ?4,
+    attributes = ?5,
+    version = version + 1,
+    updated_at = ?8
+WHERE username = ?6 AND version = ?7 AND status <> 'deleted'
+RETURNING id, username, email, email_verified_at, display_name, locale, timezone, attributes, version, created_at, updated_at`
+
+func (s *SQLiteStore) UpdateProfile(ctx context.Context, arg UpdateProfileParams) (UpdateProfileRow, error) {
+	return scanSQLiteProfile(s.db.QueryRowContext(ctx, sqliteUpdateProfile,
+		nullText(arg.Email),
+		arg.DisplayName,
+		arg.Locale,
+		arg.Timezone,
+		string(arg.Attributes),
+		arg.Username,
+		arg.ExpectedVersion,
+		s.timestamp(),
+	))
+}
+
+func scanSQLiteProfile(row *sql.Row) (UpdateProfileRow, error) {
+	var i UpdateProfileRow
+	var email, emailVerifiedAt, createdAt, updatedAt sql.NullString
+	var attributes string
+	if err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&email,
+		&emailVerifiedAt,
+		&i.DisplayName,
+		&i.Locale,
+		&i.Timezone,
+		&attributes,
+		&i.Version,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return i, mapSQLiteError(err)
+	}
+	i.Email = pgText(email)
+	i.EmailVerifiedAt = parseSQLiteTime(emailVerifiedAt)
+	i.Attributes = []byte(attributes)
+	i.CreatedAt = parseSQLiteTime(createdAt)
+	i.UpdatedAt = parseSQLiteTime(updatedAt)
+	return i, nil
+}
+
+// mapSQLiteError приводит ошибки SQLite к ошибкам, которые ожидают сервисы
+func mapSQLiteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		if strings.Contains(sqliteErr.Error(), "idx_users_email") {
+			return ErrDuplicateEmail
+		}
+		return ErrDuplicateUsername
+	}
+	return err
+}
+
+func (s *SQLiteStore) timestamp() string {
+	return formatSQLiteTime(s.now())
+}
+
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+func parseSQLiteTime(value sql.NullString) pgtype.Timestamp {
+	if !value.Valid {
+		return pgtype.Timestamp{}
+	}
+	t, err := time.Parse(sqliteTimeFormat, value.String)
+	if err != nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: t, Valid: true}
+}
+
+func nullText(value pgtype.Text) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return value.String
+}
+
+func nullTime(value pgtype.Timestamp) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return formatSQLiteTime(value.Time)
+}
+
+func pgText(value sql.NullString) pgtype.Text {
+	return pgtype.Text{String: value.String, Valid: value.Valid}
+}
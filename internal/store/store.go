@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Ошибки нарушения уникальности, общие для всех хранилищ
+var (
+	ErrDuplicateUsername = errors.New("duplicate username")
+	ErrDuplicateEmail    = errors.New("duplicate email")
+)
+
+// Store - хранилище пользователей, с которым работают сервисы
+type Store interface {
+	Querier
+	AuditAppender
+	// Ping проверяет доступность базы (проверка готовности)
+	Ping(ctx context.Context) error
+	Close()
+}
+
+var (
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*InMemoryStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+// MapUniqueViolation приводит нарушение уникальности в Postgres к ErrDuplicateUsername/ErrDuplicateEmail.
+// Остальные хранилища возвращают эти ошибки сами
+func MapUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+
+	if pgErr.ConstraintName == "idx_users_email" {
+		return ErrDuplicateEmail
+	}
+	return ErrDuplicateUsername
+}
@@ -0,0 +1,139 @@
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// CertReloader держит актуальную пару сертификат/ключ и перечитывает её при изменении файлов
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		done:     make(chan struct{}),
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create file watcher: %w", err)
+	}
+
+	// Следим за каталогами, а не за файлами: секреты в k8s обновляются подменой симлинка
+	dirs := map[string]struct{}{
+		filepath.Dir(certFile): {},
+		filepath.Dir(keyFile):  {},
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	r.watcher = watcher
+	go r.watch()
+
+	return r, nil
+}
+
+// Reload перечитывает сертификат с диска. При ошибке остаётся предыдущая пара
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+
+	return nil
+}
+
+// GetCertificate подходит для tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// verifyCurrent принимает только сертификат, который сейчас отдаёт GetCertificate
+func (r *CertReloader) verifyCurrent(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	r.mu.RLock()
+	cert := r.cert
+	r.mu.RUnlock()
+
+	if len(rawCerts) == 0 || len(cert.Certificate) == 0 || !bytes.Equal(rawCerts[0], cert.Certificate[0]) {
+		return errors.New("server certificate does not match the current TLS certificate")
+	}
+	return nil
+}
+
+func (r *CertReloader) Close() error {
+	close(r.done)
+	return r.watcher.Close()
+}
+
+func (r *CertReloader) watch() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if !r.isRelevant(event) {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("TLS certificate reload failed: %v", err)
+				continue
+			}
+			log.Printf("TLS certificate reloaded from %s", r.certFile)
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("TLS certificate watcher error: %v", err)
+		}
+	}
+}
+
+func (r *CertReloader) isRelevant(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+
+	name := filepath.Clean(event.Name)
+	dir := filepath.Dir(name)
+
+	// ..data - симлинк, который k8s подменяет при обновлении секрета
+	return name == filepath.Clean(r.certFile) ||
+		name == filepath.Clean(r.keyFile) ||
+		filepath.Base(name) == "..data" && (dir == filepath.Dir(r.certFile) || dir == filepath.Dir(r.keyFile))
+}
@@ -0,0 +1,184 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSelfSignedCert(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func currentCommonName(t *testing.T, r *CertReloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_ReloadsOnFileChange(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeSelfSignedCert(t, certFile, keyFile, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	defer r.Close()
+
+	assert.Equal(t, "first", currentCommonName(t, r))
+
+	writeSelfSignedCert(t, certFile, keyFile, "second")
+
+	assert.Eventually(t, func() bool {
+		return currentCommonName(t, r) == "second"
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestCertReloader_KeepsPreviousOnInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeSelfSignedCert(t, certFile, keyFile, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0o600))
+
+	assert.Error(t, r.Reload())
+	assert.Equal(t, "first", currentCommonName(t, r))
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewCertReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"))
+	assert.Error(t, err)
+}
+
+// handshake выполняет TLS рукопожатие клиента с сервером на локальном адресе
+func handshake(t *testing.T, serverCfg, clientCfg *tls.Config) error {
+	t.Helper()
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	require.NoError(t, err)
+	defer lis.Close()
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Ошибка сервера видна клиенту как неудачное рукопожатие
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", lis.Addr().String(), clientCfg)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func TestGatewayClientConfig_FollowsReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeSelfSignedCert(t, certFile, keyFile, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	defer r.Close()
+
+	serverCfg, err := ServerConfig(r, "")
+	require.NoError(t, err)
+	gatewayCfg, err := GatewayClientConfig(r, "", "localhost")
+	require.NoError(t, err)
+
+	require.NoError(t, handshake(t, serverCfg, gatewayCfg))
+
+	// Самоподписанный сертификат заменён: gateway доверяет новому без перезапуска
+	writeSelfSignedCert(t, certFile, keyFile, "second")
+	require.NoError(t, r.Reload())
+	assert.NoError(t, handshake(t, serverCfg, gatewayCfg))
+
+	// Чужой сертификат отклоняется
+	otherDir := t.TempDir()
+	writeSelfSignedCert(t, filepath.Join(otherDir, "tls.crt"), filepath.Join(otherDir, "tls.key"), "other")
+	other, err := NewCertReloader(filepath.Join(otherDir, "tls.crt"), filepath.Join(otherDir, "tls.key"))
+	require.NoError(t, err)
+	defer other.Close()
+	otherCfg, err := ServerConfig(other, "")
+	require.NoError(t, err)
+	assert.Error(t, handshake(t, otherCfg, gatewayCfg))
+}
+
+func TestGatewayClientConfig_CAFile(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeSelfSignedCert(t, certFile, keyFile, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	defer r.Close()
+
+	serverCfg, err := ServerConfig(r, "")
+	require.NoError(t, err)
+	// Сертификат проверяется по заданному CA; здесь CA - сам самоподписанный сертификат
+	gatewayCfg, err := GatewayClientConfig(r, certFile, "localhost")
+	require.NoError(t, err)
+	assert.NoError(t, handshake(t, serverCfg, gatewayCfg))
+
+	_, err = GatewayClientConfig(r, filepath.Join(dir, "missing.crt"), "localhost")
+	assert.Error(t, err)
+}
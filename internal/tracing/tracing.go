@@ -0,0 +1,114 @@
+// Package tracing настраивает OpenTelemetry: экспорт спанов, распространение W3C trace context
+// и связь трассировок с метриками Prometheus через exemplars
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Имя инструментирующей библиотеки для спанов сервиса
+const instrumentationName = "auth_test"
+
+type Config struct {
+	// none, otlp или stdout
+	Exporter    string
+	ServiceName string
+	Environment string
+	// host:port OTLP/gRPC приёмника, например otel-collector:4317
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// Доля трассировок, записываемых без родительского спана (0..1)
+	SampleRatio float64
+	// Куда пишет stdout-экспортер; nil - os.Stdout
+	Output io.Writer
+}
+
+// Setup устанавливает глобальные TracerProvider и propagator. Возвращаемая функция
+// отправляет накопленные спаны и должна быть вызвана при остановке сервиса
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Контекст трассировки принимается и передаётся дальше даже при выключенном экспорте
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		var err error
+		if exporter, err = otlptracegrpc.New(ctx, opts...); err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+	case ExporterStdout:
+		out := cfg.Output
+		if out == nil {
+			out = os.Stdout
+		}
+		var err error
+		if exporter, err = stdouttrace.New(stdouttrace.WithWriter(out)); err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected none, otlp or stdout", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironmentName(cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Решение о записи берётся у вызывающего сервиса, если он передал контекст трассировки
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трассировщик сервиса. Спаны пишутся в провайдер, установленный Setup,
+// даже если Tracer получен раньше
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Observe записывает значение в гистограмму и, если запрос трассируется, прикладывает
+// trace_id как exemplar: из графика задержек можно перейти к конкретной трассировке
+func Observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanContext.IsSampled() {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+	observer.Observe(value)
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	t.Run("stdout", func(t *testing.T) {
+		var out bytes.Buffer
+		shutdown, err := Setup(context.Background(), Config{
+			Exporter:    ExporterStdout,
+			ServiceName: "auth-test",
+			SampleRatio: 1,
+			Output:      &out,
+		})
+		require.NoError(t, err)
+
+		_, span := Tracer().Start(context.Background(), "test-span")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Contains(t, out.String(), `"Name":"test-span"`)
+		assert.Contains(t, out.String(), "auth-test")
+	})
+
+	t.Run("none", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+		assert.Error(t, err)
+	})
+}
+
+func TestObserve(t *testing.T) {
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_duration_seconds"})
+
+	traceID := trace.TraceID{1, 2, 3}
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	Observe(context.Background(), histogram, 0.2)
+	Observe(sampled, histogram, 0.02)
+
+	var metric dto.Metric
+	require.NoError(t, histogram.Write(&metric))
+	assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+
+	var exemplars []*dto.Exemplar
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if bucket.GetExemplar() != nil {
+			exemplars = append(exemplars, bucket.GetExemplar())
+		}
+	}
+	require.Len(t, exemplars, 1, "only the traced observation has an exemplar")
+	assert.Equal(t, 0.02, exemplars[0].GetValue())
+	assert.Equal(t, "trace_id", exemplars[0].GetLabel()[0].GetName())
+	assert.Equal(t, traceID.String(), exemplars[0].GetLabel()[0].GetValue())
+}
+
+func TestPgxTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := &PgxTracer{tracer: provider.Tracer("test")}
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "-- name: GetUser :one\nSELECT id FROM users WHERE username = $1",
+		Args: []any{"secret-username"},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "update users set role = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("UPDATE 0"),
+		Err:        errors.New("connection reset"),
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "db GetUser", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code, "no rows is not an error")
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "secret-username", "query arguments are not recorded")
+	}
+
+	assert.Equal(t, "db UPDATE", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
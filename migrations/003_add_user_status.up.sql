@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN status_changed_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+
+UPDATE users SET status = 'disabled' WHERE disabled;
+ALTER TABLE users DROP COLUMN disabled;
+
+ALTER TABLE users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('active', 'disabled', 'pending_verification', 'deleted'));
+
+CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE status = 'deleted';
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+ALTER TABLE users DROP COLUMN IF EXISTS attributes;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
+ALTER TABLE users DROP COLUMN IF EXISTS display_name;
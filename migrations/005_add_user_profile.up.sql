@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN display_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+-- Версия для оптимистичной блокировки при обновлении профиля
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
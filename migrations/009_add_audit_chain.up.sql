@@ -0,0 +1,25 @@
+-- Цепочка хешей журнала аудита: запись хранит хеш предыдущей записи своего тенанта,
+-- поэтому удаление или изменение записи обнаруживается проверкой цепочки
+ALTER TABLE audit_events DISABLE TRIGGER audit_events_no_update;
+
+ALTER TABLE audit_events ADD COLUMN tenant VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE audit_events ADD COLUMN seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_events ADD COLUMN prev_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE audit_events ADD COLUMN hash VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Записи, созданные до цепочки, остаются без хеша и получают номера по порядку id
+UPDATE audit_events SET seq = id;
+
+ALTER TABLE audit_events ENABLE TRIGGER audit_events_no_update;
+
+CREATE UNIQUE INDEX idx_audit_events_tenant_seq ON audit_events(tenant, seq);
+
+-- Последняя запись цепочки каждого тенанта; строка блокируется на время добавления события
+CREATE TABLE audit_chain_heads (
+    tenant VARCHAR(64) PRIMARY KEY,
+    seq BIGINT NOT NULL DEFAULT 0,
+    hash VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+INSERT INTO audit_chain_heads (tenant, seq)
+SELECT 'default', MAX(seq) FROM audit_events HAVING COUNT(*) > 0;
@@ -0,0 +1,15 @@
+// Package migrations содержит SQL миграции схемы, встроенные в бинарник.
+// Применяются командой migrate или при старте сервиса (DB_MIGRATIONS_MODE)
+package migrations
+
+import "embed"
+
+// Postgres - миграции для PostgreSQL (в корне каталога, совместимы с golang-migrate)
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+// SQLite - те же миграции, переведённые на диалект SQLite
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
@@ -0,0 +1,25 @@
+-- SQLite не умеет добавлять CHECK через ALTER TABLE, поэтому таблица пересоздаётся
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    role TEXT NOT NULL DEFAULT 'user',
+    status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'disabled', 'pending_verification', 'deleted')),
+    status_reason TEXT NOT NULL DEFAULT '',
+    status_changed_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    deleted_at TEXT
+);
+
+INSERT INTO users_new (id, username, password_hash, created_at, updated_at, role, status)
+SELECT id, username, password_hash, created_at, updated_at, role,
+       CASE WHEN disabled THEN 'disabled' ELSE 'active' END
+FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+CREATE INDEX idx_users_username ON users(username);
+CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE status = 'deleted';
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN version;
+ALTER TABLE users DROP COLUMN attributes;
+ALTER TABLE users DROP COLUMN timezone;
+ALTER TABLE users DROP COLUMN locale;
+ALTER TABLE users DROP COLUMN display_name;
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN attributes TEXT NOT NULL DEFAULT '{}';
+-- Версия для оптимистичной блокировки при обновлении профиля
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+-- Версия сессий попадает в токены; её увеличение отзывает все выданные токены пользователя
+ALTER TABLE users ADD COLUMN session_version INTEGER NOT NULL DEFAULT 1;
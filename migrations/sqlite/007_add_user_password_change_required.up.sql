@@ -0,0 +1,2 @@
+-- Пользователь должен сменить пароль, прежде чем получит токены (например, сгенерированный пароль администратора)
+ALTER TABLE users ADD COLUMN password_change_required INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS audit_chain_heads;
+DROP INDEX IF EXISTS idx_audit_events_tenant_seq;
+ALTER TABLE audit_events DROP COLUMN hash;
+ALTER TABLE audit_events DROP COLUMN prev_hash;
+ALTER TABLE audit_events DROP COLUMN seq;
+ALTER TABLE audit_events DROP COLUMN tenant;
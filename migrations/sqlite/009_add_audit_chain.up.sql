@@ -0,0 +1,29 @@
+-- Цепочка хешей журнала аудита: запись хранит хеш предыдущей записи своего тенанта,
+-- поэтому удаление или изменение записи обнаруживается проверкой цепочки
+DROP TRIGGER audit_events_no_update;
+
+ALTER TABLE audit_events ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE audit_events ADD COLUMN seq INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE audit_events ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_events ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+-- Записи, созданные до цепочки, остаются без хеша и получают номера по порядку id
+UPDATE audit_events SET seq = id;
+
+CREATE TRIGGER audit_events_no_update
+BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit events are immutable');
+END;
+
+CREATE UNIQUE INDEX idx_audit_events_tenant_seq ON audit_events(tenant, seq);
+
+-- Последняя запись цепочки каждого тенанта
+CREATE TABLE audit_chain_heads (
+    tenant TEXT PRIMARY KEY,
+    seq INTEGER NOT NULL DEFAULT 0,
+    hash TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO audit_chain_heads (tenant, seq)
+SELECT 'default', MAX(seq) FROM audit_events HAVING COUNT(*) > 0;
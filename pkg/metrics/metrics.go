@@ -1,59 +1,62 @@
+// Package metrics описывает метрики сервиса. Сервис и обработчики получают реализацию Metrics
+// при создании: Prometheus в работе, Noop там, где метрики не нужны, Recorder в тестах
 package metrics
 
 import (
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"context"
+	"time"
 )
 
-var (
-	// Счетчик авторизации
-	LoginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "auth_login_attempts_total",
-		Help: "Total number of login attempts",
-	}, []string{"status"})
-
-	// Время процесса авторизации
-	LoginDuration = promauto.NewHistogram(prometheus.HistogramOpts{
-		Name:    "auth_login_duration_seconds",
-		Help:    "Duration of login attempts",
-		Buckets: prometheus.DefBuckets,
-	})
-
-	// Кол-во созданных пользователей
-	UserCreated = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "auth_users_created_total",
-		Help: "Total number of users created",
-	}, []string{"status"})
-
-	// Длительность создания пользователя
-	UserCreationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
-		Name:    "auth_user_creation_duration_second",
-		Help:    "Duration of user creation",
-		Buckets: prometheus.DefBuckets,
-	})
-
-	// Кол-во сгенерированных токенов
-	TokenGenerated = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "auth_token_generated_total",
-		Help: "Total number of tokens generated",
-	}, []string{"type"})
-
-	// Кол-во валидированных токенов (сколько прошло проверок)
-	TokensValidated = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "auth_tokens_validated_total",
-		Help: "Total number of token validations",
-	}, []string{"status"})
-
-	// Кол-во запросов к gRPC методам
-	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "grpc_request_total",
-		Help: "Total gRPC requests",
-	}, []string{"method", "status"})
-
-	// длительность gRPC запросов
-	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
-		Name:    "grpc_request_duration_seconds",
-		Help:    "gRPC request duration",
-		Buckets: prometheus.DefBuckets,
-	}, []string{"method"})
+// Результаты входа и создания пользователя
+const (
+	ResultSuccess  = "success"
+	ResultFailure  = "failure"
+	ResultConflict = "conflict"
 )
+
+// Результаты проверки токена в VerifyToken (label verify_result). Метод отвечает OK и на
+// недействительный токен, поэтому код ответа не отличает их от действительных
+const (
+	VerifyResultValid   = "valid"
+	VerifyResultInvalid = "invalid"
+)
+
+// Направление сообщения gRPC
+const (
+	DirectionReceived = "received"
+	DirectionSent     = "sent"
+)
+
+type Metrics interface {
+	// LoginAttempt - проверка логина и пароля: success или failure
+	LoginAttempt(ctx context.Context, result string, duration time.Duration)
+	// UserCreated - регистрация пользователя: success, conflict или failure
+	UserCreated(ctx context.Context, result string, duration time.Duration)
+	TokenGenerated(tokenType string)
+	// TokenValidated - проверка refresh токена: valid, invalid, invalid_type или revoked
+	TokenValidated(result string)
+
+	// GRPCStarted и GRPCFinished окружают каждый вызов gRPC. service и method - части полного
+	// имени "/auth.AuthService/Login", code - код ответа ("OK", "Unauthenticated", ...),
+	// verifyResult заполняется только для VerifyToken
+	GRPCStarted(service, method string)
+	GRPCFinished(ctx context.Context, service, method, code, verifyResult string, duration time.Duration)
+	// GRPCMessage - размер принятого или отправленного сообщения protobuf в байтах
+	GRPCMessage(service, method, direction string, size int)
+	// RateLimited - вызов отклонён ограничением частоты по ключу key (ip, client, username)
+	RateLimited(method, key string)
+}
+
+// Noop ничего не записывает
+type Noop struct{}
+
+var _ Metrics = Noop{}
+
+func (Noop) LoginAttempt(context.Context, string, time.Duration)                         {}
+func (Noop) UserCreated(context.Context, string, time.Duration)                          {}
+func (Noop) TokenGenerated(string)                                                       {}
+func (Noop) TokenValidated(string)                                                       {}
+func (Noop) GRPCStarted(string, string)                                                  {}
+func (Noop) GRPCFinished(context.Context, string, string, string, string, time.Duration) {}
+func (Noop) GRPCMessage(string, string, string, int)                                     {}
+func (Noop) RateLimited(string, string)                                                  {}
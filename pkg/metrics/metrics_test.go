@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheus(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	m := NewPrometheus(reg)
+
+	m.LoginAttempt(ctx, ResultFailure, time.Millisecond)
+	m.UserCreated(ctx, ResultConflict, time.Millisecond)
+	m.GRPCStarted("auth.AuthService", "Login")
+	m.GRPCFinished(ctx, "auth.AuthService", "Login", "Unauthenticated", "", time.Millisecond)
+	m.GRPCMessage("auth.AuthService", "Login", DirectionReceived, 32)
+	m.RateLimited("Login", "ip")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.loginAttempts.WithLabelValues(ResultFailure)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues("auth.AuthService", "Login", "Unauthenticated", "")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.grpcInFlight.WithLabelValues("auth.AuthService", "Login")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.rateLimited.WithLabelValues("Login", "ip")))
+
+	// Длительность неудачного создания пользователя не пишется
+	assert.NoError(t, testutil.CollectAndCompare(m.userCreationDuration, strings.NewReader(""), "auth_user_creation_duration_second_count"))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.grpcRequestSize))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.grpcResponseSize))
+
+	// Независимые реестры не конфликтуют между собой
+	assert.NotPanics(t, func() { NewPrometheus(prometheus.NewRegistry()) })
+}
+
+func TestPoolCollector(t *testing.T) {
+	// Пул создаётся без подключения: соединения открываются при первом запросе
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/test?pool_max_conns=3")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	expected := `
+# HELP db_pool_max_conns Maximum size of the pool
+# TYPE db_pool_max_conns gauge
+db_pool_max_conns 3
+# HELP db_pool_acquired_conns Connections currently acquired from the pool
+# TYPE db_pool_acquired_conns gauge
+db_pool_acquired_conns 0
+`
+	collector := NewPoolCollector(pool)
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "db_pool_max_conns", "db_pool_acquired_conns"))
+	assert.Equal(t, 12, testutil.CollectAndCount(collector))
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc("db_pool_acquired_conns",
+		"Connections currently acquired from the pool", nil, nil)
+	poolIdleConns = prometheus.NewDesc("db_pool_idle_conns",
+		"Idle connections in the pool", nil, nil)
+	poolConstructingConns = prometheus.NewDesc("db_pool_constructing_conns",
+		"Connections being established", nil, nil)
+	poolTotalConns = prometheus.NewDesc("db_pool_total_conns",
+		"Total connections in the pool", nil, nil)
+	poolMaxConns = prometheus.NewDesc("db_pool_max_conns",
+		"Maximum size of the pool", nil, nil)
+	poolAcquires = prometheus.NewDesc("db_pool_acquires_total",
+		"Successful connection acquires", nil, nil)
+	poolAcquireDuration = prometheus.NewDesc("db_pool_acquire_duration_seconds_total",
+		"Total time spent waiting for a connection", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc("db_pool_empty_acquires_total",
+		"Acquires that had to wait because the pool was empty", nil, nil)
+	poolCanceledAcquires = prometheus.NewDesc("db_pool_canceled_acquires_total",
+		"Acquires canceled by the context", nil, nil)
+	poolNewConns = prometheus.NewDesc("db_pool_new_conns_total",
+		"Connections opened", nil, nil)
+	poolDestroyed = prometheus.NewDesc("db_pool_destroyed_conns_total",
+		"Connections closed by the pool", []string{"reason"}, nil)
+)
+
+// PoolCollector отдаёт pgxpool.Stat пула соединений в момент сбора метрик
+type PoolCollector struct {
+	pool *pgxpool.Pool
+}
+
+var _ prometheus.Collector = (*PoolCollector)(nil)
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return &PoolCollector{pool: pool}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, value int32) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+	counter := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labels...)
+	}
+
+	gauge(poolAcquiredConns, stat.AcquiredConns())
+	gauge(poolIdleConns, stat.IdleConns())
+	gauge(poolConstructingConns, stat.ConstructingConns())
+	gauge(poolTotalConns, stat.TotalConns())
+	gauge(poolMaxConns, stat.MaxConns())
+	counter(poolAcquires, float64(stat.AcquireCount()))
+	counter(poolAcquireDuration, stat.AcquireDuration().Seconds())
+	counter(poolEmptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(poolCanceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(poolNewConns, float64(stat.NewConnsCount()))
+	counter(poolDestroyed, float64(stat.MaxLifetimeDestroyCount()), "max_lifetime")
+	counter(poolDestroyed, float64(stat.MaxIdleDestroyCount()), "max_idle")
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"auth_test/internal/tracing"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus записывает метрики в коллекторы, зарегистрированные в переданном реестре.
+// Гистограммы длительности получают trace_id как exemplar (см. tracing.Observe)
+type Prometheus struct {
+	loginAttempts        *prometheus.CounterVec
+	loginDuration        prometheus.Histogram
+	userCreated          *prometheus.CounterVec
+	userCreationDuration prometheus.Histogram
+	tokenGenerated       *prometheus.CounterVec
+	tokensValidated      *prometheus.CounterVec
+
+	grpcRequests     *prometheus.CounterVec
+	grpcDuration     *prometheus.HistogramVec
+	grpcInFlight     *prometheus.GaugeVec
+	grpcRequestSize  *prometheus.HistogramVec
+	grpcResponseSize *prometheus.HistogramVec
+	rateLimited      *prometheus.CounterVec
+}
+
+var _ Metrics = (*Prometheus)(nil)
+
+// NewPrometheus создаёт коллекторы и регистрирует их в reg. Повторная регистрация
+// в том же реестре - паника, поэтому у каждого экземпляра сервиса свой реестр
+func NewPrometheus(reg prometheus.Registerer) *Prometheus {
+	factory := promauto.With(reg)
+	sizeBuckets := prometheus.ExponentialBuckets(64, 4, 8)
+
+	return &Prometheus{
+		// Счетчик авторизации
+		loginAttempts: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_login_attempts_total",
+			Help: "Total number of login attempts",
+		}, []string{"status"}),
+		// Время процесса авторизации
+		loginDuration: factory.NewHistogram(prometheus.HistogramOpts{
+			Name:    "auth_login_duration_seconds",
+			Help:    "Duration of login attempts",
+			Buckets: prometheus.DefBuckets,
+		}),
+		// Кол-во созданных пользователей
+		userCreated: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_users_created_total",
+			Help: "Total number of users created",
+		}, []string{"status"}),
+		// Длительность создания пользователя
+		userCreationDuration: factory.NewHistogram(prometheus.HistogramOpts{
+			Name:    "auth_user_creation_duration_second",
+			Help:    "Duration of user creation",
+			Buckets: prometheus.DefBuckets,
+		}),
+		// Кол-во сгенерированных токенов
+		tokenGenerated: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_token_generated_total",
+			Help: "Total number of tokens generated",
+		}, []string{"type"}),
+		// Кол-во валидированных токенов (сколько прошло проверок)
+		tokensValidated: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_tokens_validated_total",
+			Help: "Total number of token validations",
+		}, []string{"status"}),
+
+		grpcRequests: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_request_total",
+			Help: "Total gRPC requests by status code",
+		}, []string{"service", "method", "code", "verify_result"}),
+		grpcDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC request duration",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"service", "method"}),
+		grpcInFlight: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_requests_in_flight",
+			Help: "gRPC requests currently being handled",
+		}, []string{"service", "method"}),
+		grpcRequestSize: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_request_message_bytes",
+			Help:    "Size of received gRPC messages",
+			Buckets: sizeBuckets,
+		}, []string{"service", "method"}),
+		grpcResponseSize: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_response_message_bytes",
+			Help:    "Size of sent gRPC messages",
+			Buckets: sizeBuckets,
+		}, []string{"service", "method"}),
+		// Кол-во вызовов, отклонённых ограничением частоты
+		rateLimited: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_rate_limited_total",
+			Help: "Total gRPC requests rejected by rate limiting",
+		}, []string{"method", "key"}),
+	}
+}
+
+func (p *Prometheus) LoginAttempt(ctx context.Context, result string, duration time.Duration) {
+	p.loginAttempts.WithLabelValues(result).Inc()
+	tracing.Observe(ctx, p.loginDuration, duration.Seconds())
+}
+
+// UserCreated записывает длительность только успешного создания
+func (p *Prometheus) UserCreated(ctx context.Context, result string, duration time.Duration) {
+	p.userCreated.WithLabelValues(result).Inc()
+	if result == ResultSuccess {
+		tracing.Observe(ctx, p.userCreationDuration, duration.Seconds())
+	}
+}
+
+func (p *Prometheus) TokenGenerated(tokenType string) {
+	p.tokenGenerated.WithLabelValues(tokenType).Inc()
+}
+
+func (p *Prometheus) TokenValidated(result string) {
+	p.tokensValidated.WithLabelValues(result).Inc()
+}
+
+func (p *Prometheus) GRPCStarted(service, method string) {
+	p.grpcInFlight.WithLabelValues(service, method).Inc()
+}
+
+func (p *Prometheus) GRPCFinished(ctx context.Context, service, method, code, verifyResult string, duration time.Duration) {
+	p.grpcInFlight.WithLabelValues(service, method).Dec()
+	p.grpcRequests.WithLabelValues(service, method, code, verifyResult).Inc()
+	tracing.Observe(ctx, p.grpcDuration.WithLabelValues(service, method), duration.Seconds())
+}
+
+func (p *Prometheus) GRPCMessage(service, method, direction string, size int) {
+	histogram := p.grpcRequestSize
+	if direction == DirectionSent {
+		histogram = p.grpcResponseSize
+	}
+	histogram.WithLabelValues(service, method).Observe(float64(size))
+}
+
+func (p *Prometheus) RateLimited(method, key string) {
+	p.rateLimited.WithLabelValues(method, key).Inc()
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder запоминает события в памяти, чтобы тесты могли проверить, что и сколько раз
+// было записано. Событие - имя метода Metrics и его строковые аргументы
+type Recorder struct {
+	mu       sync.Mutex
+	counts   map[string]int
+	inFlight map[string]int
+}
+
+var _ Metrics = (*Recorder)(nil)
+
+func NewRecorder() *Recorder {
+	return &Recorder{counts: make(map[string]int), inFlight: make(map[string]int)}
+}
+
+// Count возвращает число событий с такими аргументами, например
+// Count("LoginAttempt", ResultFailure) или Count("GRPCFinished", "auth.AuthService", "Login", "OK", "")
+func (r *Recorder) Count(event string, labels ...string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[recorderKey(event, labels)]
+}
+
+// InFlight возвращает число начатых и ещё не завершённых вызовов метода
+func (r *Recorder) InFlight(service, method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.inFlight[service+"/"+method]
+}
+
+func (r *Recorder) record(event string, labels ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[recorderKey(event, labels)]++
+}
+
+func recorderKey(event string, labels []string) string {
+	return event + "{" + strings.Join(labels, ",") + "}"
+}
+
+func (r *Recorder) LoginAttempt(_ context.Context, result string, _ time.Duration) {
+	r.record("LoginAttempt", result)
+}
+
+func (r *Recorder) UserCreated(_ context.Context, result string, _ time.Duration) {
+	r.record("UserCreated", result)
+}
+
+func (r *Recorder) TokenGenerated(tokenType string) {
+	r.record("TokenGenerated", tokenType)
+}
+
+func (r *Recorder) TokenValidated(result string) {
+	r.record("TokenValidated", result)
+}
+
+func (r *Recorder) GRPCStarted(service, method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight[service+"/"+method]++
+}
+
+func (r *Recorder) GRPCFinished(_ context.Context, service, method, code, verifyResult string, _ time.Duration) {
+	r.mu.Lock()
+	r.inFlight[service+"/"+method]--
+	r.mu.Unlock()
+	r.record("GRPCFinished", service, method, code, verifyResult)
+}
+
+func (r *Recorder) GRPCMessage(service, method, direction string, _ int) {
+	r.record("GRPCMessage", service, method, direction)
+}
+
+func (r *Recorder) RateLimited(method, key string) {
+	r.record("RateLimited", method, key)
+}